@@ -0,0 +1,325 @@
+package sbee
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// ErrNoCredentials is matched by the errors of credential providers that
+// hold no keys for an account on an exchange.
+var ErrNoCredentials = errors.New("sbee: no credentials")
+
+// CredentialProvider resolves the API keys of an account on an exchange.
+// Providers are called at request time, so keys rotated in the store are
+// picked up by the next call. Implementations must be safe for concurrent
+// use.
+type CredentialProvider interface {
+	Credentials(ctx context.Context, account, exchange string) (Credentials, error)
+}
+
+// CredentialProviderFunc adapts a function to CredentialProvider.
+type CredentialProviderFunc func(ctx context.Context, account, exchange string) (Credentials, error)
+
+func (f CredentialProviderFunc) Credentials(ctx context.Context, account, exchange string) (Credentials, error) {
+	return f(ctx, account, exchange)
+}
+
+// WithCredentialProvider sets the provider behind the accounts returned by
+// SbeeRest.Account. The default, also restored by a nil p, is EnvProvider{}.
+func WithCredentialProvider(p CredentialProvider) Option {
+	return func(s *SbeeRest) {
+		if p == nil {
+			p = EnvProvider{}
+		}
+		s.credentials = p
+	}
+}
+
+// ChainProvider tries providers in order, moving on while they report
+// ErrNoCredentials.
+func ChainProvider(providers ...CredentialProvider) CredentialProvider {
+	return CredentialProviderFunc(func(ctx context.Context, account, exchange string) (Credentials, error) {
+		for _, p := range providers {
+			c, err := p.Credentials(ctx, account, exchange)
+			if !errors.Is(err, ErrNoCredentials) {
+				return c, err
+			}
+		}
+		return Credentials{}, noCredentials(account, exchange)
+	})
+}
+
+func noCredentials(account, exchange string) error {
+	return fmt.Errorf("%w for account %q on %s", ErrNoCredentials, account, exchange)
+}
+
+// EnvProvider reads keys from environment variables named
+// <Prefix>_<ACCOUNT>_<EXCHANGE>_API_KEY, _API_SECRET and _API_PASS, falling
+// back to <Prefix>_<ACCOUNT>_API_KEY and so on for keys shared by every
+// exchange. Names are upper-cased with anything but letters and digits
+// replaced by underscores, and the account part is left out for the
+// account "". Prefix defaults to "SBEE". Only the key is required.
+type EnvProvider struct {
+	Prefix string
+}
+
+func (p EnvProvider) Credentials(ctx context.Context, account, exchange string) (Credentials, error) {
+	prefix := p.Prefix
+	if prefix == "" {
+		prefix = "SBEE"
+	}
+	if account != "" {
+		prefix += "_" + envName(account)
+	}
+	for _, name := range []string{prefix + "_" + envName(exchange), prefix} {
+		if key := os.Getenv(name + "_API_KEY"); key != "" {
+			return Credentials{APIKey: key, APISecret: os.Getenv(name + "_API_SECRET"), APIPass: os.Getenv(name + "_API_PASS")}, nil
+		}
+	}
+	return Credentials{}, noCredentials(account, exchange)
+}
+
+func envName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z':
+			return r - 'a' + 'A'
+		case 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// FileProvider reads keys from a JSON file mapping accounts to exchanges to
+// keys, read again on every call:
+//
+//	{"main": {"Binance": {"apiKey": "...", "apiSecret": "...", "apiPass": ""}, "*": {...}}}
+//
+// Exchanges match regardless of case, and "*" holds the keys of exchanges
+// not listed. Outside Windows the file must not be accessible to the group
+// or others.
+type FileProvider struct {
+	Path string
+}
+
+// NewFileProvider returns a provider reading path.
+func NewFileProvider(path string) *FileProvider {
+	return &FileProvider{Path: path}
+}
+
+func (p *FileProvider) Credentials(ctx context.Context, account, exchange string) (Credentials, error) {
+	info, err := os.Stat(p.Path)
+	if err != nil {
+		return Credentials{}, err
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		return Credentials{}, fmt.Errorf("sbee: credentials file %s is accessible to other users (mode %v)", p.Path, info.Mode().Perm())
+	}
+	b, err := os.ReadFile(p.Path)
+	if err != nil {
+		return Credentials{}, err
+	}
+	var accounts map[string]map[string]Credentials
+	if err := json.Unmarshal(b, &accounts); err != nil {
+		return Credentials{}, fmt.Errorf("sbee: credentials file %s: %w", p.Path, err)
+	}
+	var fallback *Credentials
+	for name, c := range accounts[account] {
+		if strings.EqualFold(name, exchange) {
+			return c, nil
+		}
+		if name == "*" {
+			fallback = &c
+		}
+	}
+	if fallback != nil {
+		return *fallback, nil
+	}
+	return Credentials{}, noCredentials(account, exchange)
+}
+
+// Account is a handle on one trading account. Its methods are the private
+// endpoints of SbeeRest without the key arguments: they send
+// Credentials{Account: name}, whose keys the client's CredentialProvider
+// resolves for the exchange of each request as it is sent.
+type Account struct {
+	client *SbeeRest
+	name   string
+}
+
+// Account returns the handle of the named account.
+func (s *SbeeRest) Account(name string) *Account {
+	return &Account{client: s, name: name}
+}
+
+// Name returns the name of the account.
+func (a *Account) Name() string { return a.name }
+
+// keys returns the Credentials that name the account.
+func (a *Account) keys() Credentials { return Credentials{Account: a.name} }
+
+// Credentials resolves the keys of the account on exchange. OMS and
+// RouteVenue need not be given them: Credentials{Account: name} is resolved
+// on every request.
+func (a *Account) Credentials(ctx context.Context, Exchange string) (Credentials, error) {
+	c := Credentials{Account: a.name}
+	if err := a.client.resolve(ctx, a.client.exchange(Exchange), &c); err != nil {
+		return Credentials{}, fmt.Errorf("sbee: %w", err)
+	}
+	return c, nil
+}
+
+// TradingBalances is SbeeRest.TradingBalances for the account.
+func (a *Account) TradingBalances(ctx context.Context, Exchange, Trade, symbol string) (*Response[[]Balance], error) {
+	return a.client.tradingBalances(ctx, Exchange, Trade, symbol, a.keys())
+}
+
+// OrderHistory is SbeeRest.OrderHistory for the account.
+func (a *Account) OrderHistory(ctx context.Context, Exchange, Trade, symbol, state string) (*Response[[]Order], error) {
+	return a.client.orderHistory(ctx, Exchange, Trade, symbol, state, a.keys())
+}
+
+// PlaceLimitOrder is SbeeRest.PlaceLimitOrder for the account.
+func (a *Account) PlaceLimitOrder(ctx context.Context, Exchange, Trade, symbol, ClientOrderId string, price, quoteQuantity, baseQuantity Decimal, side string) (*Response[Order], error) {
+	return a.client.placeLimitOrder(ctx, Exchange, Trade, symbol, ClientOrderId, price, quoteQuantity, baseQuantity, side, a.keys())
+}
+
+// PlaceMarketOrder is SbeeRest.PlaceMarketOrder for the account.
+func (a *Account) PlaceMarketOrder(ctx context.Context, Exchange, Trade, symbol, ClientOrderId string, price, quoteQuantity, baseQuantity Decimal, leverage, contract int, side string) (*Response[Order], error) {
+	return a.client.placeMarketOrder(ctx, Exchange, Trade, symbol, ClientOrderId, price, quoteQuantity, baseQuantity, leverage, contract, side, a.keys())
+}
+
+// PlaceLimitStopLossOrder is SbeeRest.PlaceLimitStopLossOrder for the account.
+func (a *Account) PlaceLimitStopLossOrder(ctx context.Context, Exchange, Trade, symbol string, quantity Decimal, ClientOrderId string, stopPrice, orderPrice, price, trailingDelta Decimal, side string) (*Response[Order], error) {
+	return a.client.placeLimitStopLossOrder(ctx, Exchange, Trade, symbol, quantity, ClientOrderId, stopPrice, orderPrice, price, trailingDelta, side, a.keys())
+}
+
+// PlaceLimitTakeProfitOrder is SbeeRest.PlaceLimitTakeProfitOrder for the account.
+func (a *Account) PlaceLimitTakeProfitOrder(ctx context.Context, Exchange, Trade, symbol string, quantity Decimal, ClientOrderId string, stopPrice, orderPrice, price, trailingDelta Decimal, side string) (*Response[Order], error) {
+	return a.client.placeLimitTakeProfitOrder(ctx, Exchange, Trade, symbol, quantity, ClientOrderId, stopPrice, orderPrice, price, trailingDelta, side, a.keys())
+}
+
+// SetLeverage is SbeeRest.SetLeverage for the account.
+func (a *Account) SetLeverage(ctx context.Context, Exchange, Trade, symbol, leverage string) (*Response[Leverage], error) {
+	return a.client.setLeverage(ctx, Exchange, Trade, symbol, leverage, a.keys())
+}
+
+// CancelOrder is SbeeRest.CancelOrder for the account.
+func (a *Account) CancelOrder(ctx context.Context, Exchange, Trade, symbol, orderId, clientOrderId string) (*Response[Order], error) {
+	return a.client.cancelOrder(ctx, Exchange, Trade, symbol, orderId, clientOrderId, a.keys())
+}
+
+// CancelOrdersBySymbol is SbeeRest.CancelOrdersBySymbol for the account.
+func (a *Account) CancelOrdersBySymbol(ctx context.Context, Exchange, Trade, symbol string) (*Response[[]Order], error) {
+	return a.client.cancelOrdersBySymbol(ctx, Exchange, Trade, symbol, a.keys())
+}
+
+// CancelBatchOrders is SbeeRest.CancelBatchOrders for the account.
+func (a *Account) CancelBatchOrders(ctx context.Context, Exchange, Trade string, orders []BatchCancelOrder) (*Response[[]OrderResult], error) {
+	return a.client.cancelBatchOrders(ctx, Exchange, Trade, orders, a.keys())
+}
+
+// PlaceBatchMarketOrders is SbeeRest.PlaceBatchMarketOrders for the account.
+func (a *Account) PlaceBatchMarketOrders(ctx context.Context, Exchange, Trade string, orders []BatchMarketOrder) (*Response[[]OrderResult], error) {
+	return a.client.placeBatchMarketOrders(ctx, Exchange, Trade, orders, a.keys())
+}
+
+// PlaceBatchLimitOrders is SbeeRest.PlaceBatchLimitOrders for the account.
+func (a *Account) PlaceBatchLimitOrders(ctx context.Context, Exchange, Trade string, orders []BatchLimitOrder) (*Response[[]OrderResult], error) {
+	return a.client.placeBatchLimitOrders(ctx, Exchange, Trade, orders, a.keys())
+}
+
+// resolveAccounts fills in the keys of the request, or of the entries of a
+// ForPeople call, that name an Account instead. The caller's slices are
+// copied, not written to.
+func (s *SbeeRest) resolveAccounts(ctx context.Context, r *request) error {
+	var err error
+	switch b := r.body.(type) {
+	case TradingBalancesRequest:
+		err = s.resolve(ctx, r.exchange, &b.Credentials)
+		r.body = b
+	case OrderHistoryRequest:
+		err = s.resolve(ctx, r.exchange, &b.Credentials)
+		r.body = b
+	case PlaceLimitOrderRequest:
+		err = s.resolve(ctx, r.exchange, &b.Credentials)
+		r.body = b
+	case PlaceMarketOrderRequest:
+		err = s.resolve(ctx, r.exchange, &b.Credentials)
+		r.body = b
+	case StopOrderRequest:
+		err = s.resolve(ctx, r.exchange, &b.Credentials)
+		r.body = b
+	case SetLeverageRequest:
+		err = s.resolve(ctx, r.exchange, &b.Credentials)
+		r.body = b
+	case CancelOrderRequest:
+		err = s.resolve(ctx, r.exchange, &b.Credentials)
+		r.body = b
+	case CancelOrdersBySymbolRequest:
+		err = s.resolve(ctx, r.exchange, &b.Credentials)
+		r.body = b
+	case CancelBatchOrdersRequest:
+		err = s.resolve(ctx, r.exchange, &b.Credentials)
+		r.body = b
+	case PlaceBatchMarketOrdersRequest:
+		err = s.resolve(ctx, r.exchange, &b.Credentials)
+		r.body = b
+	case PlaceBatchLimitOrdersRequest:
+		err = s.resolve(ctx, r.exchange, &b.Credentials)
+		r.body = b
+	case []LimitOrderForPeople:
+		r.body, err = resolveEach(ctx, s, r.exchange, b, func(o *LimitOrderForPeople) *Credentials { return &o.Credentials })
+	case []MarketOrderForPeople:
+		r.body, err = resolveEach(ctx, s, r.exchange, b, func(o *MarketOrderForPeople) *Credentials { return &o.Credentials })
+	case []CancelOrderForPeople:
+		r.body, err = resolveEach(ctx, s, r.exchange, b, func(o *CancelOrderForPeople) *Credentials { return &o.Credentials })
+	case []BalanceForPeople:
+		r.body, err = resolveEach(ctx, s, r.exchange, b, func(o *BalanceForPeople) *Credentials { return &o.Credentials })
+	}
+	return err
+}
+
+// resolve fills in c from the client's CredentialProvider if it names an
+// Account and holds no keys.
+func (s *SbeeRest) resolve(ctx context.Context, exchange string, c *Credentials) error {
+	if c.Account == "" || c.APIKey != "" || c.APISecret != "" || c.APIPass != "" {
+		return nil
+	}
+	keys, err := s.credentials.Credentials(ctx, c.Account, exchange)
+	if err != nil {
+		return fmt.Errorf("account %q: %w", c.Account, err)
+	}
+	keys.Account = c.Account
+	*c = keys
+	return nil
+}
+
+// resolveEach resolves the Credentials of entries, copying entries before
+// the first change.
+func resolveEach[T any](ctx context.Context, s *SbeeRest, exchange string, entries []T, keys func(*T) *Credentials) ([]T, error) {
+	var out []T
+	for i := range entries {
+		c := *keys(&entries[i])
+		if err := s.resolve(ctx, exchange, &c); err != nil {
+			return nil, err
+		}
+		if c == *keys(&entries[i]) {
+			continue
+		}
+		if out == nil {
+			out = append([]T(nil), entries...)
+		}
+		*keys(&out[i]) = c
+	}
+	if out == nil {
+		return entries, nil
+	}
+	return out, nil
+}
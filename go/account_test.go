@@ -0,0 +1,155 @@
+package sbee
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestEnvProvider(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("SBEE_MAIN_BINANCE_API_KEY", "bkey")
+	t.Setenv("SBEE_MAIN_BINANCE_API_SECRET", "bsecret")
+	t.Setenv("SBEE_MAIN_API_KEY", "key")
+	t.Setenv("SBEE_MAIN_API_PASS", "pass")
+	t.Setenv("BOT_SUB_1_GATEIO_API_KEY", "gkey")
+
+	p := EnvProvider{}
+	if c, err := p.Credentials(ctx, "main", "Binance"); err != nil || c != (Credentials{APIKey: "bkey", APISecret: "bsecret"}) {
+		t.Errorf("main on Binance = %+v, %v", c, err)
+	}
+	if c, err := p.Credentials(ctx, "main", "OKX"); err != nil || c != (Credentials{APIKey: "key", APIPass: "pass"}) {
+		t.Errorf("main on OKX = %+v, %v", c, err)
+	}
+	if c, err := (EnvProvider{Prefix: "BOT"}).Credentials(ctx, "sub-1", "GateIO"); err != nil || c.APIKey != "gkey" {
+		t.Errorf("sub-1 on GateIO = %+v, %v", c, err)
+	}
+	if _, err := p.Credentials(ctx, "other", "Binance"); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("unknown account: err = %v", err)
+	}
+}
+
+func TestFileProvider(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keys.json")
+	b, _ := json.Marshal(map[string]map[string]Credentials{
+		"main": {"binance": {APIKey: "bkey"}, "*": {APIKey: "any"}},
+	})
+	os.WriteFile(path, b, 0o600)
+
+	p := NewFileProvider(path)
+	if c, err := p.Credentials(ctx, "main", "Binance"); err != nil || c.APIKey != "bkey" {
+		t.Errorf("main on Binance = %+v, %v", c, err)
+	}
+	if c, err := p.Credentials(ctx, "main", "KuCoin"); err != nil || c.APIKey != "any" {
+		t.Errorf("main on KuCoin = %+v, %v", c, err)
+	}
+	if _, err := p.Credentials(ctx, "other", "Binance"); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("unknown account: err = %v", err)
+	}
+
+	if runtime.GOOS != "windows" {
+		os.Chmod(path, 0o644)
+		if _, err := p.Credentials(ctx, "main", "Binance"); err == nil {
+			t.Error("world-readable file accepted")
+		}
+	}
+}
+
+func TestAccount(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	provider := ChainProvider(
+		CredentialProviderFunc(func(ctx context.Context, account, exchange string) (Credentials, error) {
+			calls++
+			return Credentials{}, noCredentials(account, exchange)
+		}),
+		CredentialProviderFunc(func(ctx context.Context, account, exchange string) (Credentials, error) {
+			if account != "main" || exchange != "Binance" {
+				return Credentials{}, noCredentials(account, exchange)
+			}
+			return testKeys, nil
+		}),
+	)
+	s, got := newTestServer(t, `{"isSuccess":true,"data":{"orderId":"1"}}`)
+	WithCredentialProvider(provider)(s)
+	WithDefaultExchange("Binance")(s)
+
+	if _, err := s.Account("main").PlaceLimitOrder(ctx, "", "Spot", "BTC-USDT", "id", d("100"), Decimal{}, d("1"), SideBuy); err != nil {
+		t.Fatal(err)
+	}
+	var body PlaceLimitOrderRequest
+	json.Unmarshal(got.body, &body)
+	if body.Credentials != testKeys || calls != 1 {
+		t.Errorf("sent keys %+v after %d calls to the first provider", body.Credentials, calls)
+	}
+
+	// The keys are resolved on every request, so rotated keys are used at once.
+	keys := testKeys
+	WithCredentialProvider(CredentialProviderFunc(func(ctx context.Context, account, exchange string) (Credentials, error) {
+		return keys, nil
+	}))(s)
+	main := s.Account("main")
+	keys.APIKey = "rotated"
+	if _, err := main.SetLeverage(ctx, "", "Futures", "BTC-USDT", "5"); err != nil {
+		t.Fatal(err)
+	}
+	var leverage SetLeverageRequest
+	json.Unmarshal(got.body, &leverage)
+	if leverage.APIKey != "rotated" {
+		t.Errorf("sent key %q after rotation", leverage.APIKey)
+	}
+	WithCredentialProvider(provider)(s)
+
+	got.path = ""
+	_, err := s.Account("main").CancelOrder(ctx, "OKX", "Spot", "BTC-USDT", "1", "")
+	var apiErr *APIError
+	if !errors.Is(err, ErrNoCredentials) || !errors.As(err, &apiErr) || apiErr.Kind != KindCredentials || apiErr.Endpoint != "CancelOrder" {
+		t.Errorf("no keys on OKX: err = %v", err)
+	}
+	if got.path != "" {
+		t.Errorf("request sent to %s without keys", got.path)
+	}
+
+	// A nil provider restores the default rather than panicking.
+	WithCredentialProvider(nil)(s)
+	if _, err := s.Account("nobody").SetLeverage(ctx, "", "Futures", "BTC-USDT", "5"); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("nil provider: err = %v", err)
+	}
+}
+
+func TestForPeopleAccounts(t *testing.T) {
+	s, got := newTestServer(t, `{"isSuccess":true,"data":[]}`)
+	WithCredentialProvider(CredentialProviderFunc(func(ctx context.Context, account, exchange string) (Credentials, error) {
+		if account != "a" {
+			return Credentials{}, noCredentials(account, exchange)
+		}
+		return Credentials{APIKey: "ka", APISecret: "sa"}, nil
+	}))(s)
+
+	orders := []LimitOrderForPeople{
+		{Symbol: "BTC-USDT", ClientOrderID: "1", Credentials: Credentials{Account: "a"}},
+		{Symbol: "BTC-USDT", ClientOrderID: "2", Credentials: Credentials{APIKey: "explicit", Account: "a"}},
+	}
+	if _, err := s.PlaceLimitOrderForPeople(context.Background(), "Binance", "Spot", orders); err != nil {
+		t.Fatal(err)
+	}
+	var sent []map[string]any
+	json.Unmarshal(got.body, &sent)
+	if sent[0]["apiKey"] != "ka" || sent[0]["apiSecret"] != "sa" || sent[1]["apiKey"] != "explicit" || sent[0]["Account"] != nil {
+		t.Errorf("sent %v", sent)
+	}
+	if orders[0].APIKey != "" {
+		t.Error("keys written into the caller's orders")
+	}
+
+	got.path = ""
+	_, err := s.TradingBalancesForPeople(context.Background(), "Binance", "Spot", []BalanceForPeople{{Credentials: Credentials{Account: "b"}}})
+	if !errors.Is(err, ErrNoCredentials) || got.path != "" {
+		t.Errorf("unknown account: err = %v, sent to %q", err, got.path)
+	}
+}
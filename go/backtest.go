@@ -0,0 +1,378 @@
+package sbee
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Strategy decides what to trade as a backtest replays candles. OnCandle is
+// called once per candle, after the orders resting from earlier candles
+// have been matched against it; orders it places fill from the next candle
+// on.
+type Strategy interface {
+	OnCandle(b *Backtest, k Kline)
+}
+
+// StrategyFunc adapts a function to Strategy.
+type StrategyFunc func(b *Backtest, k Kline)
+
+func (f StrategyFunc) OnCandle(b *Backtest, k Kline) { f(b, k) }
+
+// BacktestConfig configures a Backtest.
+type BacktestConfig struct {
+	// InitialCash is the quote balance the backtest starts with.
+	InitialCash Decimal
+	// MakerFee and TakerFee are fee rates charged in the quote asset on
+	// limit orders filled at their price and on everything else.
+	MakerFee, TakerFee Decimal
+	// Slippage is the fraction by which market orders fill worse than the
+	// open of their candle, e.g. 0.0005 for 5 basis points.
+	Slippage Decimal
+	// MaxVolumeShare caps the fills within one candle to this fraction of
+	// its volume, shared by all orders; zero means no cap. Orders the cap
+	// cuts short fill partially.
+	MaxVolumeShare Decimal
+	// PeriodsPerYear annualises the Sharpe ratio. Zero derives it from the
+	// spacing of the candles.
+	PeriodsPerYear float64
+}
+
+// EquityPoint is the account marked at the close of one candle.
+type EquityPoint struct {
+	Time     time.Time
+	Cash     Decimal
+	Position Decimal
+	Equity   Decimal
+}
+
+// BacktestTrade is one fill of a backtest order.
+type BacktestTrade struct {
+	OrderID  int64
+	Time     time.Time
+	Side     string
+	Price    Decimal
+	Quantity Decimal
+	Fee      Decimal
+	Maker    bool
+	// PnL is the profit realised by a sell against the average cost of the
+	// position, buy fees included, less its own fee. It is zero for buys.
+	PnL Decimal
+}
+
+// BacktestStats summarises a backtest.
+type BacktestStats struct {
+	// PnL is the final equity less InitialCash; Return is the same as a
+	// fraction of InitialCash.
+	PnL    Decimal
+	Return float64
+	// Sharpe is the annualised mean over standard deviation of the
+	// per-candle equity returns, with a zero risk-free rate.
+	Sharpe float64
+	// MaxDrawdown is the largest fall of equity from a previous peak, as a
+	// fraction of that peak.
+	MaxDrawdown float64
+	// WinRate is the fraction of sells that realised a positive PnL.
+	WinRate float64
+	Trades  int
+	Fees    Decimal
+}
+
+// BacktestResult is the output of Backtest.Run.
+type BacktestResult struct {
+	Equity []EquityPoint
+	Trades []BacktestTrade
+	Stats  BacktestStats
+}
+
+// Backtest replays candles through a Strategy for one spot market. It holds
+// a quote cash balance and a base position, which never go negative: buys
+// fill only as far as cash covers them, fees included, and sells as far as
+// the position does. Orders are matched against the candles that follow
+// them:
+//
+//   - market orders fill at the open, worsened by Slippage, as taker; what
+//     the candle cannot fill is cancelled;
+//   - limit buys fill when the low reaches their price, at that price as
+//     maker, or at the open as taker when the candle opens through it, and
+//     limit sells likewise against the high; they rest until filled or
+//     cancelled, and are cancelled when the account cannot fund them.
+//
+// A Backtest is not safe for concurrent use; the strategy calls it from
+// within Run.
+type Backtest struct {
+	cfg BacktestConfig
+
+	cash, position, cost Decimal
+	orders               []*backtestOrder
+	nextID               int64
+	candles              []Kline
+	equity               []EquityPoint
+	trades               []BacktestTrade
+}
+
+type backtestOrder struct {
+	id        int64
+	sell      bool
+	price     Decimal // zero for market orders
+	remaining Decimal
+}
+
+// NewBacktest returns a backtest with the account described by cfg.
+func NewBacktest(cfg BacktestConfig) *Backtest {
+	return &Backtest{cfg: cfg, cash: cfg.InitialCash}
+}
+
+// Run replays klines, oldest first, through strategy and returns the equity
+// curve, the fills and their statistics. Orders still open at the end are
+// left unfilled.
+func (b *Backtest) Run(klines []Kline, strategy Strategy) *BacktestResult {
+	for _, k := range klines {
+		b.match(k)
+		b.candles = append(b.candles, k)
+		b.equity = append(b.equity, EquityPoint{
+			Time:     k.OpenTime.Time(),
+			Cash:     b.cash,
+			Position: b.position,
+			Equity:   b.cash.Add(b.position.Mul(k.Close)),
+		})
+		strategy.OnCandle(b, k)
+	}
+	return &BacktestResult{Equity: b.equity, Trades: b.trades, Stats: b.stats()}
+}
+
+// MarketOrder places a market order for quantity of the base asset, filled
+// on the next candle. side is SideBuy or SideSell.
+func (b *Backtest) MarketOrder(side string, quantity Decimal) (int64, error) {
+	return b.place("MarketOrder", side, Decimal{}, quantity)
+}
+
+// LimitOrder places a limit order for quantity of the base asset at price.
+func (b *Backtest) LimitOrder(side string, price, quantity Decimal) (int64, error) {
+	if price.Sign() <= 0 {
+		return 0, &OrderError{Endpoint: "LimitOrder", Field: "price", Value: price.String(), Reason: "must be positive"}
+	}
+	return b.place("LimitOrder", side, price, quantity)
+}
+
+func (b *Backtest) place(endpoint, side string, price, quantity Decimal) (int64, error) {
+	if side != SideBuy && side != SideSell {
+		return 0, &OrderError{Endpoint: endpoint, Field: "side", Value: side, Reason: "must be BUY or SELL"}
+	}
+	if quantity.Sign() <= 0 {
+		return 0, &OrderError{Endpoint: endpoint, Field: "quantity", Value: quantity.String(), Reason: "must be positive"}
+	}
+	b.nextID++
+	b.orders = append(b.orders, &backtestOrder{id: b.nextID, sell: side == SideSell, price: price, remaining: quantity})
+	return b.nextID, nil
+}
+
+// Cancel cancels an open order and reports whether there was one.
+func (b *Backtest) Cancel(id int64) bool {
+	for i, o := range b.orders {
+		if o.id == id {
+			b.orders = append(b.orders[:i], b.orders[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// OpenOrders returns the number of orders not yet filled or cancelled.
+func (b *Backtest) OpenOrders() int { return len(b.orders) }
+
+// Cash returns the quote balance.
+func (b *Backtest) Cash() Decimal { return b.cash }
+
+// Position returns the base balance.
+func (b *Backtest) Position() Decimal { return b.position }
+
+// Equity returns cash plus the position marked at the last close.
+func (b *Backtest) Equity() Decimal {
+	if len(b.equity) == 0 {
+		return b.cash
+	}
+	return b.equity[len(b.equity)-1].Equity
+}
+
+// Candles returns the candles replayed so far, the current one last. The
+// slice must not be modified.
+func (b *Backtest) Candles() []Kline { return b.candles }
+
+// match fills the open orders against k in the order they were placed.
+func (b *Backtest) match(k Kline) {
+	available := Decimal{}
+	capped := b.cfg.MaxVolumeShare.Sign() > 0
+	if capped {
+		available = k.Volume.Mul(b.cfg.MaxVolumeShare)
+	}
+	open := b.orders[:0]
+	for _, o := range b.orders {
+		price, maker, ok := b.fillPrice(o, k)
+		if !ok {
+			open = append(open, o)
+			continue
+		}
+		fee := b.cfg.TakerFee
+		if maker {
+			fee = b.cfg.MakerFee
+		}
+		qty := o.remaining
+		if capped {
+			qty = MinDecimal(qty, available)
+		}
+		affordable := b.affordable(o.sell, price, fee)
+		funded := affordable.GreaterThanOrEqual(qty)
+		qty = MinDecimal(qty, affordable)
+		if qty.Sign() > 0 {
+			b.fill(o, k, price, qty, fee, maker)
+			available = available.Sub(qty)
+		}
+		// Market orders are immediate-or-cancel; limit orders the account
+		// can no longer fund are cancelled.
+		if o.remaining.Sign() > 0 && o.price.Sign() > 0 && funded {
+			open = append(open, o)
+		}
+	}
+	clear(b.orders[len(open):])
+	b.orders = open
+}
+
+// fillPrice returns the price o trades at within k, whether it trades as
+// maker, and whether it trades at all.
+func (b *Backtest) fillPrice(o *backtestOrder, k Kline) (Decimal, bool, bool) {
+	if o.price.IsZero() {
+		slip := b.cfg.Slippage
+		if o.sell {
+			slip = slip.Neg()
+		}
+		return k.Open.Mul(one.Add(slip)), false, true
+	}
+	if o.sell {
+		switch {
+		case k.Open.GreaterThanOrEqual(o.price):
+			return k.Open, false, true
+		case k.High.GreaterThanOrEqual(o.price):
+			return o.price, true, true
+		}
+		return Decimal{}, false, false
+	}
+	switch {
+	case k.Open.LessThanOrEqual(o.price):
+		return k.Open, false, true
+	case k.Low.LessThanOrEqual(o.price):
+		return o.price, true, true
+	}
+	return Decimal{}, false, false
+}
+
+// affordable is the most the account can trade at price with fee rate fee.
+func (b *Backtest) affordable(sell bool, price, fee Decimal) Decimal {
+	if sell {
+		return b.position
+	}
+	return b.cash.DivRound(price.Mul(one.Add(fee)), DivisionPrecision, RoundDown)
+}
+
+func (b *Backtest) fill(o *backtestOrder, k Kline, price, qty, rate Decimal, maker bool) {
+	notional := price.Mul(qty)
+	fee := notional.Mul(rate)
+	t := BacktestTrade{OrderID: o.id, Time: k.OpenTime.Time(), Side: SideBuy, Price: price, Quantity: qty, Fee: fee, Maker: maker}
+	if o.sell {
+		t.Side = SideSell
+		cost := b.cost.Mul(qty).DivRound(b.position, DivisionPrecision, RoundHalfEven)
+		t.PnL = notional.Sub(cost).Sub(fee)
+		b.cost = b.cost.Sub(cost)
+		b.position = b.position.Sub(qty)
+		b.cash = b.cash.Add(notional).Sub(fee)
+		if b.position.IsZero() {
+			b.cost = Decimal{}
+		}
+	} else {
+		b.cost = b.cost.Add(notional).Add(fee)
+		b.position = b.position.Add(qty)
+		b.cash = b.cash.Sub(notional).Sub(fee)
+	}
+	o.remaining = o.remaining.Sub(qty)
+	b.trades = append(b.trades, t)
+}
+
+func (b *Backtest) stats() BacktestStats {
+	s := BacktestStats{Trades: len(b.trades)}
+	var sells, wins int
+	for _, t := range b.trades {
+		s.Fees = s.Fees.Add(t.Fee)
+		if t.Side == SideSell {
+			sells++
+			if t.PnL.Sign() > 0 {
+				wins++
+			}
+		}
+	}
+	if sells > 0 {
+		s.WinRate = float64(wins) / float64(sells)
+	}
+	if len(b.equity) == 0 {
+		return s
+	}
+	s.PnL = b.Equity().Sub(b.cfg.InitialCash)
+	if b.cfg.InitialCash.Sign() > 0 {
+		s.Return = s.PnL.Float64() / b.cfg.InitialCash.Float64()
+	}
+
+	var returns []float64
+	peak := b.cfg.InitialCash.Float64()
+	prev := peak
+	for _, p := range b.equity {
+		e := p.Equity.Float64()
+		if prev > 0 {
+			returns = append(returns, e/prev-1)
+		}
+		prev = e
+		peak = math.Max(peak, e)
+		if peak > 0 {
+			s.MaxDrawdown = math.Max(s.MaxDrawdown, (peak-e)/peak)
+		}
+	}
+	s.Sharpe = sharpe(returns, b.periodsPerYear())
+	return s
+}
+
+// periodsPerYear returns the configured value, or the number of candles a
+// year holds at the median spacing of the replayed ones.
+func (b *Backtest) periodsPerYear() float64 {
+	if b.cfg.PeriodsPerYear > 0 {
+		return b.cfg.PeriodsPerYear
+	}
+	var gaps []int64
+	for i := 1; i < len(b.candles); i++ {
+		if g := openMilli(b.candles[i]) - openMilli(b.candles[i-1]); g > 0 {
+			gaps = append(gaps, g)
+		}
+	}
+	if len(gaps) == 0 {
+		return 0
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+	return float64(365*24*time.Hour/time.Millisecond) / float64(gaps[len(gaps)/2])
+}
+
+func sharpe(returns []float64, periods float64) float64 {
+	if len(returns) < 2 || periods <= 0 {
+		return 0
+	}
+	var mean float64
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+	var variance float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	std := math.Sqrt(variance / float64(len(returns)-1))
+	if std == 0 {
+		return 0
+	}
+	return mean / std * math.Sqrt(periods)
+}
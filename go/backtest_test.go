@@ -0,0 +1,113 @@
+package sbee
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// candle returns the one-minute candle number i with the given prices.
+func candle(i int64, open, high, low, close, volume string) Kline {
+	t := (klineEpoch + i) * 60000
+	return Kline{OpenTime: Timestamp(t), Open: d(open), High: d(high), Low: d(low), Close: d(close), Volume: d(volume), CloseTime: Timestamp(t + 59999)}
+}
+
+func TestBacktest(t *testing.T) {
+	klines := []Kline{
+		candle(0, "100", "100", "100", "100", "10"),
+		candle(1, "100", "105", "95", "104", "10"),
+		candle(2, "104", "108", "89", "100", "1"),
+		candle(3, "111", "112", "111", "112", "10"),
+		candle(4, "100", "100", "100", "100", "10"),
+	}
+	var buyID int64
+	strategy := StrategyFunc(func(b *Backtest, k Kline) {
+		switch len(b.Candles()) {
+		case 1:
+			b.MarketOrder(SideBuy, d("2"))
+		case 2:
+			b.LimitOrder(SideSell, d("110"), d("2"))
+			buyID, _ = b.LimitOrder(SideBuy, d("90"), d("1"))
+		case 4:
+			if !b.Cancel(buyID) {
+				t.Error("resting buy not open")
+			}
+			b.MarketOrder(SideSell, b.Position())
+		}
+	})
+	result := NewBacktest(BacktestConfig{
+		InitialCash:    d("1000"),
+		TakerFee:       d("0.01"),
+		Slippage:       d("0.01"),
+		MaxVolumeShare: d("0.5"),
+	}).Run(klines, strategy)
+
+	// The market buy fills at 101 after slippage; the limit buy gets half of
+	// the thin candle's volume as maker; the sell limit is gapped through
+	// and fills at the open as taker.
+	want := []BacktestTrade{
+		{OrderID: 1, Side: SideBuy, Price: d("101"), Quantity: d("2"), Fee: d("2.02")},
+		{OrderID: 3, Side: SideBuy, Price: d("90"), Quantity: d("0.5"), Maker: true},
+		{OrderID: 2, Side: SideSell, Price: d("111"), Quantity: d("2"), Fee: d("2.22"), PnL: d("20.564")},
+		{OrderID: 4, Side: SideSell, Price: d("99"), Quantity: d("0.5"), Fee: d("0.495"), PnL: d("-0.799")},
+	}
+	if len(result.Trades) != len(want) {
+		t.Fatalf("trades = %+v", result.Trades)
+	}
+	for i, w := range want {
+		g := result.Trades[i]
+		if g.OrderID != w.OrderID || g.Side != w.Side || g.Maker != w.Maker || !g.Price.Equal(w.Price) || !g.Quantity.Equal(w.Quantity) || !g.Fee.Equal(w.Fee) || !g.PnL.Equal(w.PnL) {
+			t.Errorf("trade %d = %+v, want %+v", i, g, w)
+		}
+	}
+
+	equity := []string{"1000", "1003.98", "1000.98", "1026.76", "1019.765"}
+	for i, e := range equity {
+		if !result.Equity[i].Equity.Equal(d(e)) {
+			t.Errorf("equity[%d] = %s, want %s", i, result.Equity[i].Equity, e)
+		}
+	}
+
+	s := result.Stats
+	if !s.PnL.Equal(d("19.765")) || !s.Fees.Equal(d("4.735")) || s.Trades != 4 || s.WinRate != 0.5 {
+		t.Errorf("stats = %+v", s)
+	}
+	if want := 6.995 / 1026.76; math.Abs(s.MaxDrawdown-want) > 1e-9 {
+		t.Errorf("max drawdown = %v, want %v", s.MaxDrawdown, want)
+	}
+	if s.Sharpe <= 0 {
+		t.Errorf("sharpe = %v for a profitable run", s.Sharpe)
+	}
+}
+
+func TestBacktestLimits(t *testing.T) {
+	klines := []Kline{
+		candle(0, "50", "50", "50", "50", "100"),
+		candle(1, "50", "50", "50", "50", "100"),
+	}
+	var errs []error
+	result := NewBacktest(BacktestConfig{InitialCash: d("100")}).Run(klines, StrategyFunc(func(b *Backtest, k Kline) {
+		if len(b.Candles()) > 1 {
+			return
+		}
+		_, err := b.LimitOrder(SideBuy, Decimal{}, d("1"))
+		errs = append(errs, err)
+		_, err = b.MarketOrder("HOLD", d("1"))
+		errs = append(errs, err)
+		b.MarketOrder(SideSell, d("1"))
+		b.MarketOrder(SideBuy, d("10"))
+	}))
+	for _, err := range errs {
+		var oe *OrderError
+		if !errors.As(err, &oe) {
+			t.Errorf("err = %v, want an OrderError", err)
+		}
+	}
+	// Nothing to sell, and cash for only 2 of the 10 bought.
+	if len(result.Trades) != 1 || !result.Trades[0].Quantity.Equal(d("2")) {
+		t.Errorf("trades = %+v", result.Trades)
+	}
+	if !result.Equity[1].Cash.IsZero() || !result.Equity[1].Position.Equal(d("2")) {
+		t.Errorf("account = %+v", result.Equity[1])
+	}
+}
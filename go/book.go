@@ -0,0 +1,373 @@
+package sbee
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrBookGap is returned by BookManager.Apply when an update does not follow
+// the book's last sequence number. The book needs a new snapshot.
+var ErrBookGap = errors.New("sbee: order book sequence gap")
+
+// BookSide selects the bid or ask side of a book.
+type BookSide int
+
+const (
+	BidSide BookSide = iota
+	AskSide
+)
+
+func (s BookSide) String() string {
+	if s == BidSide {
+		return "bid"
+	}
+	return "ask"
+}
+
+// BookChange describes one update applied to a BookManager.
+type BookChange struct {
+	// Resync is set when the book was rebuilt from a snapshot.
+	Resync bool
+	// Bids and Asks are the levels that changed, with their new size; a
+	// zero Size means the level was removed.
+	Bids     []PriceLevel
+	Asks     []PriceLevel
+	UpdateID int64
+}
+
+// BookOption configures a BookManager.
+type BookOption func(*BookManager)
+
+// WithBookStream makes the manager apply the book diffs of st instead of
+// polling OrderBook.
+func WithBookStream(st *Stream) BookOption {
+	return func(b *BookManager) {
+		b.stream = st
+	}
+}
+
+// WithBookDepth sets the depth of the OrderBook snapshots. The default is 100.
+func WithBookDepth(depth int) BookOption {
+	return func(b *BookManager) {
+		b.depth = depth
+	}
+}
+
+// WithBookPollInterval sets how often OrderBook is polled when the manager
+// has no stream. The default is one second.
+func WithBookPollInterval(d time.Duration) BookOption {
+	return func(b *BookManager) {
+		b.pollInterval = d
+	}
+}
+
+// BookManager maintains a local copy of one order book. Run starts it from an
+// OrderBook snapshot and keeps it current from a Stream or by polling,
+// resyncing whenever an update does not follow the previous one. All read
+// methods are safe for concurrent use while Run is applying updates.
+type BookManager struct {
+	client                  *SbeeRest
+	exchange, trade, symbol string
+	stream                  *Stream
+	depth                   int
+	pollInterval            time.Duration
+	changes                 chan BookChange
+
+	mu       sync.RWMutex
+	bids     []PriceLevel // best (highest) first
+	asks     []PriceLevel // best (lowest) first
+	updateID int64
+	synced   bool
+}
+
+// NewBookManager returns a manager for symbol on exchange and trade. It holds
+// no data until Run is called.
+func NewBookManager(client *SbeeRest, exchange, trade, symbol string, opts ...BookOption) *BookManager {
+	b := &BookManager{
+		client:       client,
+		exchange:     client.exchange(exchange),
+		trade:        client.trade(trade),
+		symbol:       symbol,
+		depth:        100,
+		pollInterval: time.Second,
+		changes:      make(chan BookChange, 256),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Changes returns the channel on which every applied update is reported.
+// Changes are dropped while the channel is full, so a slow reader should
+// treat them as hints and read the book itself.
+func (b *BookManager) Changes() <-chan BookChange {
+	return b.changes
+}
+
+// Run loads a snapshot and applies updates until ctx is done or the stream
+// closes. Only a failure of the first snapshot is returned. After a gap, or
+// a failed resync, the book reports Synced false until the next snapshot
+// succeeds, which is attempted on the next poll or stream update.
+func (b *BookManager) Run(ctx context.Context) error {
+	var updates <-chan BookUpdate
+	var sub *Subscription[BookUpdate]
+	if b.stream != nil {
+		var err error
+		sub, err = b.stream.Book(b.exchange, b.trade, b.symbol)
+		if err != nil {
+			return err
+		}
+		defer sub.Unsubscribe()
+		updates = sub.C
+	}
+	if err := b.Resync(ctx); err != nil {
+		return err
+	}
+
+	var poll <-chan time.Time
+	if updates == nil {
+		t := time.NewTicker(b.pollInterval)
+		defer t.Stop()
+		poll = t.C
+	}
+	var dropped int64
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-poll:
+			b.Resync(ctx)
+		case u, ok := <-updates:
+			if !ok {
+				return ErrStreamClosed
+			}
+			err := b.Apply(u)
+			// Diffs lost to a full channel are a gap even without
+			// sequence numbers.
+			if n := sub.Dropped(); n != dropped {
+				dropped, err = n, ErrBookGap
+			}
+			if err != nil {
+				b.Resync(ctx)
+			}
+		}
+	}
+}
+
+// Resync replaces the book with a fresh OrderBook snapshot.
+func (b *BookManager) Resync(ctx context.Context) error {
+	resp, err := b.client.OrderBook(ctx, b.exchange, b.trade, b.symbol, b.depth)
+	if err != nil {
+		b.mu.Lock()
+		b.synced = false
+		b.mu.Unlock()
+		return err
+	}
+	return b.Apply(BookUpdate{
+		Snapshot:     true,
+		LastUpdateID: resp.Data.LastUpdateID,
+		Bids:         resp.Data.Bids,
+		Asks:         resp.Data.Asks,
+	})
+}
+
+// Apply applies one update. Snapshots replace the book. Diffs must follow
+// the last applied sequence number when both carry one: older diffs are
+// ignored and a diff that skips ahead returns ErrBookGap, as does any diff
+// before the first snapshot.
+func (b *BookManager) Apply(u BookUpdate) error {
+	b.mu.Lock()
+	change := BookChange{Resync: u.Snapshot, UpdateID: u.LastUpdateID}
+	switch {
+	case u.Snapshot:
+		bids, asks := sortedLevels(u.Bids, BidSide), sortedLevels(u.Asks, AskSide)
+		change.Bids, change.Asks = diffLevels(b.bids, bids, BidSide), diffLevels(b.asks, asks, AskSide)
+		b.bids, b.asks = bids, asks
+		b.updateID, b.synced = u.LastUpdateID, true
+	case !b.synced:
+		b.mu.Unlock()
+		return ErrBookGap
+	default:
+		if b.updateID != 0 && u.LastUpdateID != 0 {
+			if u.LastUpdateID <= b.updateID {
+				b.mu.Unlock()
+				return nil
+			}
+			first := u.FirstUpdateID
+			if first == 0 {
+				first = u.LastUpdateID
+			}
+			if first > b.updateID+1 {
+				b.synced = false
+				b.mu.Unlock()
+				return ErrBookGap
+			}
+		}
+		for _, l := range u.Bids {
+			b.bids = setLevel(b.bids, l, BidSide)
+		}
+		for _, l := range u.Asks {
+			b.asks = setLevel(b.asks, l, AskSide)
+		}
+		if u.LastUpdateID != 0 {
+			b.updateID = u.LastUpdateID
+		}
+		change.Bids, change.Asks = u.Bids, u.Asks
+	}
+	b.mu.Unlock()
+
+	select {
+	case b.changes <- change:
+	default:
+	}
+	return nil
+}
+
+// Synced reports whether the book holds a snapshot with no gap since.
+func (b *BookManager) Synced() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.synced
+}
+
+// UpdateID returns the sequence number of the last applied update.
+func (b *BookManager) UpdateID() int64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.updateID
+}
+
+// BestBid returns the highest bid, and false when there is none.
+func (b *BookManager) BestBid() (PriceLevel, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.bids) == 0 {
+		return PriceLevel{}, false
+	}
+	return b.bids[0], true
+}
+
+// BestAsk returns the lowest ask, and false when there is none.
+func (b *BookManager) BestAsk() (PriceLevel, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.asks) == 0 {
+		return PriceLevel{}, false
+	}
+	return b.asks[0], true
+}
+
+// Levels returns up to n levels of side, best first, or all of them when n
+// is not positive.
+func (b *BookManager) Levels(side BookSide, n int) []PriceLevel {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	levels := b.side(side)
+	if n <= 0 || n > len(levels) {
+		n = len(levels)
+	}
+	return append([]PriceLevel(nil), levels[:n]...)
+}
+
+// SizeAt returns the size resting at exactly price on side, zero if none.
+func (b *BookManager) SizeAt(side BookSide, price Decimal) Decimal {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	levels := b.side(side)
+	i := searchLevel(levels, price, side)
+	if i < len(levels) && levels[i].Price.Equal(price) {
+		return levels[i].Size
+	}
+	return Decimal{}
+}
+
+// CumulativeVolume returns the total size on side from the best price up to
+// and including price: what a taker could fill without going past price.
+func (b *BookManager) CumulativeVolume(side BookSide, price Decimal) Decimal {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	var total Decimal
+	for _, l := range b.side(side) {
+		if beyond(l.Price, price, side) {
+			break
+		}
+		total = total.Add(l.Size)
+	}
+	return total
+}
+
+func (b *BookManager) side(side BookSide) []PriceLevel {
+	if side == BidSide {
+		return b.bids
+	}
+	return b.asks
+}
+
+// beyond reports whether p is worse than limit on side.
+func beyond(p, limit Decimal, side BookSide) bool {
+	if side == BidSide {
+		return p.LessThan(limit)
+	}
+	return p.GreaterThan(limit)
+}
+
+// searchLevel returns the index of price in levels, or where it would go.
+func searchLevel(levels []PriceLevel, price Decimal, side BookSide) int {
+	return sort.Search(len(levels), func(i int) bool {
+		return !beyond(price, levels[i].Price, side)
+	})
+}
+
+// setLevel sets or, for a zero size, removes the level at l.Price.
+func setLevel(levels []PriceLevel, l PriceLevel, side BookSide) []PriceLevel {
+	i := searchLevel(levels, l.Price, side)
+	found := i < len(levels) && levels[i].Price.Equal(l.Price)
+	switch {
+	case l.Size.Sign() <= 0:
+		if found {
+			levels = append(levels[:i], levels[i+1:]...)
+		}
+	case found:
+		levels[i] = PriceLevel{Price: l.Price, Size: l.Size}
+	default:
+		levels = append(levels, PriceLevel{})
+		copy(levels[i+1:], levels[i:])
+		levels[i] = PriceLevel{Price: l.Price, Size: l.Size}
+	}
+	return levels
+}
+
+// sortedLevels returns the non-empty levels of a snapshot, best first.
+func sortedLevels(in []PriceLevel, side BookSide) []PriceLevel {
+	out := make([]PriceLevel, 0, len(in))
+	for _, l := range in {
+		if l.Size.Sign() > 0 {
+			out = append(out, PriceLevel{Price: l.Price, Size: l.Size})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return beyond(out[j].Price, out[i].Price, side) })
+	return out
+}
+
+// diffLevels returns the levels that differ between old and new, with
+// removed levels given a zero size.
+func diffLevels(old, new []PriceLevel, side BookSide) []PriceLevel {
+	var diff []PriceLevel
+	for _, l := range new {
+		i := searchLevel(old, l.Price, side)
+		if i >= len(old) || !old[i].Price.Equal(l.Price) || !old[i].Size.Equal(l.Size) {
+			diff = append(diff, l)
+		}
+	}
+	for _, l := range old {
+		i := searchLevel(new, l.Price, side)
+		if i >= len(new) || !new[i].Price.Equal(l.Price) {
+			diff = append(diff, PriceLevel{Price: l.Price})
+		}
+	}
+	return diff
+}
@@ -0,0 +1,113 @@
+package sbee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func levels(pairs ...string) []PriceLevel {
+	var out []PriceLevel
+	for i := 0; i < len(pairs); i += 2 {
+		out = append(out, PriceLevel{Price: d(pairs[i]), Size: d(pairs[i+1])})
+	}
+	return out
+}
+
+func TestBookManagerApply(t *testing.T) {
+	b := NewBookManager(NewClient("token"), "Binance", "Spot", "BTC-USDT")
+
+	if err := b.Apply(BookUpdate{FirstUpdateID: 1, LastUpdateID: 1}); !errors.Is(err, ErrBookGap) {
+		t.Errorf("diff before snapshot: err = %v", err)
+	}
+	if err := b.Apply(BookUpdate{
+		Snapshot:     true,
+		LastUpdateID: 100,
+		Bids:         levels("99", "1", "100", "2", "98", "5"),
+		Asks:         levels("102", "3", "101", "1"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if bid, _ := b.BestBid(); bid.Price.String() != "100" {
+		t.Errorf("best bid = %s", bid.Price)
+	}
+
+	// Stale diffs are skipped, contiguous ones applied.
+	b.Apply(BookUpdate{FirstUpdateID: 90, LastUpdateID: 100, Bids: levels("100", "9")})
+	if err := b.Apply(BookUpdate{FirstUpdateID: 95, LastUpdateID: 103, Bids: levels("100", "0", "99.5", "4"), Asks: levels("101", "0.5")}); err != nil {
+		t.Fatal(err)
+	}
+	if bid, _ := b.BestBid(); bid.Price.String() != "99.5" || bid.Size.String() != "4" {
+		t.Errorf("best bid = %+v, want 99.5 x 4", bid)
+	}
+	if ask, _ := b.BestAsk(); ask.Price.String() != "101" || ask.Size.String() != "0.5" {
+		t.Errorf("best ask = %+v, want 101 x 0.5", ask)
+	}
+	if got := b.SizeAt(BidSide, d("99")); got.String() != "1" {
+		t.Errorf("size at 99 = %s", got)
+	}
+	if got := b.SizeAt(BidSide, d("100")); !got.IsZero() {
+		t.Errorf("removed level still has size %s", got)
+	}
+	if got := b.CumulativeVolume(BidSide, d("99")); got.String() != "5" {
+		t.Errorf("bid volume down to 99 = %s, want 5", got)
+	}
+	if got := b.CumulativeVolume(AskSide, d("102")); got.String() != "3.5" {
+		t.Errorf("ask volume up to 102 = %s, want 3.5", got)
+	}
+	if got := b.Levels(BidSide, 2); len(got) != 2 || got[1].Price.String() != "99" {
+		t.Errorf("top bids = %+v", got)
+	}
+
+	if err := b.Apply(BookUpdate{FirstUpdateID: 105, LastUpdateID: 106}); !errors.Is(err, ErrBookGap) {
+		t.Errorf("gap: err = %v", err)
+	}
+	if b.Synced() || b.UpdateID() != 103 {
+		t.Errorf("after gap synced = %v, update id = %d", b.Synced(), b.UpdateID())
+	}
+
+	changes := 0
+	for len(b.Changes()) > 0 {
+		<-b.Changes()
+		changes++
+	}
+	if changes != 2 {
+		t.Errorf("%d changes reported, want 2", changes)
+	}
+}
+
+func TestBookManagerPollingResync(t *testing.T) {
+	var polls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&polls, 1)
+		fmt.Fprintf(w, `{"isSuccess":true,"data":{"lastUpdateId":%d,"bids":[{"price":"%d","size":"1"}],"asks":[{"price":"200","size":"1"}]}}`, n, 100+n)
+	}))
+	defer srv.Close()
+
+	b := NewBookManager(NewClient("token", WithBaseURL(srv.URL)), "Binance", "Spot", "BTC-USDT", WithBookPollInterval(5*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- b.Run(ctx) }()
+
+	first := <-b.Changes()
+	if !first.Resync || len(first.Bids) != 1 || first.Bids[0].Price.String() != "101" {
+		t.Errorf("first change = %+v", first)
+	}
+	second := <-b.Changes()
+	// The old level is reported removed and the new one added.
+	if len(second.Bids) != 2 || !second.Bids[1].Size.IsZero() {
+		t.Errorf("second change = %+v", second)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run = %v", err)
+	}
+	if bid, ok := b.BestBid(); !ok || bid.Price.LessThan(d("102")) {
+		t.Errorf("best bid = %+v after %d polls", bid, atomic.LoadInt32(&polls))
+	}
+}
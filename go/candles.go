@@ -0,0 +1,278 @@
+package sbee
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
+
+// bucketStart returns the open time of the interval bucket holding t, in
+// UTC. Fixed intervals count from the Unix epoch, except weeks, which start
+// on Monday as on the exchanges; months count from January of year 0.
+func bucketStart(t time.Time, interval string) (time.Time, bool) {
+	t = t.UTC()
+	if m, ok := intervalMonths(interval); ok {
+		months := t.Year()*12 + int(t.Month()) - 1
+		months -= months % m
+		return time.Date(months/12, time.Month(months%12+1), 1, 0, 0, 0, 0, time.UTC), true
+	}
+	d, ok := IntervalDuration(interval)
+	if !ok {
+		return time.Time{}, false
+	}
+	offset := time.Duration(0)
+	if d%(7*24*time.Hour) == 0 {
+		// The epoch was a Thursday, three days after a Monday.
+		offset = 3 * 24 * time.Hour
+	}
+	since := t.Sub(time.Unix(0, 0).Add(-offset))
+	return t.Add(-(since % d)), true
+}
+
+// candleBounds returns the open and close times of the candle holding t.
+func candleBounds(t time.Time, interval string) (open, close Timestamp) {
+	start, _ := bucketStart(t, interval)
+	end, _ := advance(start, interval, 1)
+	return Timestamp(start.UnixMilli()), Timestamp(end.UnixMilli() - 1)
+}
+
+func checkInterval(interval string) error {
+	if _, ok := bucketStart(time.Time{}, interval); !ok {
+		return fmt.Errorf("sbee: unknown interval %q", interval)
+	}
+	return nil
+}
+
+// CandleAggregator builds candles of any interval from trades, such as those
+// of RecentTrades or Stream.Trades, including intervals the API does not
+// serve: "3m", "2h", "45s". Candles open on the interval boundaries, with a
+// CloseTime one millisecond before the next, like KLine; intervals without
+// trades produce no candle. Open and Close are the prices of the earliest and
+// latest trades, so trades within a candle may arrive in any order. Trades
+// older than the current candle, or in a candle already returned, are
+// dropped. A CandleAggregator is not safe for concurrent use.
+type CandleAggregator struct {
+	interval    string
+	cur         Kline
+	started     bool
+	first, last Timestamp
+	// closed is the open time of the last candle returned, if any.
+	closed    Timestamp
+	hasClosed bool
+}
+
+// NewCandleAggregator returns an aggregator producing candles of interval.
+func NewCandleAggregator(interval string) (*CandleAggregator, error) {
+	if err := checkInterval(interval); err != nil {
+		return nil, err
+	}
+	return &CandleAggregator{interval: interval}, nil
+}
+
+// Add adds a trade. When it falls in a later interval than the current
+// candle, that candle is closed and returned.
+func (a *CandleAggregator) Add(tr Trade) (closed Kline, ok bool) {
+	open, closeTime := candleBounds(tr.Timestamp.Time(), a.interval)
+	if a.started && open < a.cur.OpenTime || a.hasClosed && open <= a.closed {
+		return Kline{}, false
+	}
+	if a.started && open > a.cur.OpenTime {
+		closed, ok = a.close()
+	}
+	quote := tr.Price.Mul(tr.Amount)
+	if !a.started {
+		a.cur = Kline{OpenTime: open, CloseTime: closeTime, Open: tr.Price, High: tr.Price, Low: tr.Price, Close: tr.Price, Volume: tr.Amount, QuoteVolume: quote}
+		a.first, a.last, a.started = tr.Timestamp, tr.Timestamp, true
+		return closed, ok
+	}
+	a.cur.High = MaxDecimal(a.cur.High, tr.Price)
+	a.cur.Low = MinDecimal(a.cur.Low, tr.Price)
+	a.cur.Volume = a.cur.Volume.Add(tr.Amount)
+	a.cur.QuoteVolume = a.cur.QuoteVolume.Add(quote)
+	if tr.Timestamp < a.first {
+		a.cur.Open, a.first = tr.Price, tr.Timestamp
+	}
+	if tr.Timestamp >= a.last {
+		a.cur.Close, a.last = tr.Price, tr.Timestamp
+	}
+	return closed, ok
+}
+
+// Tick closes and returns the current candle once now is past its close
+// time, for markets quiet enough that no trade does it.
+func (a *CandleAggregator) Tick(now time.Time) (Kline, bool) {
+	if !a.started || now.UnixMilli() <= a.cur.CloseTime.Time().UnixMilli() {
+		return Kline{}, false
+	}
+	return a.close()
+}
+
+// Current returns the candle still open, if any.
+func (a *CandleAggregator) Current() (Kline, bool) {
+	return a.cur, a.started
+}
+
+// Flush closes and returns the current candle however far it got.
+func (a *CandleAggregator) Flush() (Kline, bool) {
+	if !a.started {
+		return Kline{}, false
+	}
+	return a.close()
+}
+
+// close ends the current candle and returns it.
+func (a *CandleAggregator) close() (Kline, bool) {
+	a.started = false
+	a.closed, a.hasClosed = a.cur.OpenTime, true
+	return a.cur, true
+}
+
+// CandlesFromTrades returns the candles of interval built from trades, in
+// time order. The last one is included even if its interval has not ended.
+func CandlesFromTrades(trades []Trade, interval string) ([]Kline, error) {
+	a, err := NewCandleAggregator(interval)
+	if err != nil {
+		return nil, err
+	}
+	trades = slices.Clone(trades)
+	sort.SliceStable(trades, func(i, j int) bool { return trades[i].Timestamp < trades[j].Timestamp })
+	var out []Kline
+	for _, tr := range trades {
+		if k, ok := a.Add(tr); ok {
+			out = append(out, k)
+		}
+	}
+	if k, ok := a.Flush(); ok {
+		out = append(out, k)
+	}
+	return out, nil
+}
+
+// AggregateTrades reads trades, typically the C of a Stream.Trades
+// subscription, and sends each candle of interval on the returned channel
+// once it has closed, either on the first trade of a later interval or when
+// its interval ends. The channel is closed when trades is closed, after the
+// last candle is flushed, or when ctx is done.
+func AggregateTrades(ctx context.Context, trades <-chan Trade, interval string) (<-chan Kline, error) {
+	a, err := NewCandleAggregator(interval)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan Kline, DefaultStreamBuffer)
+	go func() {
+		defer close(out)
+		send := func(k Kline) bool {
+			select {
+			case out <- k:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		timer := time.NewTimer(time.Hour)
+		defer timer.Stop()
+		for {
+			// Wake up just after the current candle ends.
+			timer.Stop()
+			if cur, ok := a.Current(); ok {
+				timer.Reset(time.Until(cur.CloseTime.Time()) + time.Millisecond)
+			}
+			select {
+			case tr, ok := <-trades:
+				if !ok {
+					if k, ok := a.Flush(); ok {
+						send(k)
+					}
+					return
+				}
+				if k, ok := a.Add(tr); ok && !send(k) {
+					return
+				}
+			case now := <-timer.C:
+				if k, ok := a.Tick(now); ok && !send(k) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Resample combines candles into candles of a coarser interval: each opens
+// with the first candle of its bucket and closes with the last, spans their
+// highs and lows and sums their volumes. ks need not be sorted; candles
+// repeated with the same open time count once. Buckets only partly covered by
+// ks, typically the first and last, are returned as they are. interval must
+// be a whole multiple of the interval of ks, which Resample infers from the
+// smallest step between their open times, or from the span of a lone candle.
+func Resample(ks []Kline, interval string) ([]Kline, error) {
+	if err := checkInterval(interval); err != nil {
+		return nil, err
+	}
+	ks = dedupeKlines(slices.Clone(ks))
+	if err := checkResample(ks, interval); err != nil {
+		return nil, err
+	}
+	var out []Kline
+	for _, k := range ks {
+		open, closeTime := candleBounds(k.OpenTime.Time(), interval)
+		if n := len(out); n > 0 && out[n-1].OpenTime == open {
+			c := &out[n-1]
+			c.High = MaxDecimal(c.High, k.High)
+			c.Low = MinDecimal(c.Low, k.Low)
+			c.Close = k.Close
+			c.Volume = c.Volume.Add(k.Volume)
+			c.QuoteVolume = c.QuoteVolume.Add(k.QuoteVolume)
+			continue
+		}
+		k.OpenTime, k.CloseTime = open, closeTime
+		out = append(out, k)
+	}
+	return out, nil
+}
+
+// checkResample reports an error unless interval is a whole multiple of the
+// interval of ks, which are sorted and have distinct open times.
+func checkResample(ks []Kline, interval string) error {
+	var from, to time.Time
+	for i := 1; i < len(ks); i++ {
+		a, b := ks[i-1].OpenTime.Time(), ks[i].OpenTime.Time()
+		if from.IsZero() || b.Sub(a) < to.Sub(from) {
+			from, to = a, b
+		}
+	}
+	if len(ks) == 1 && ks[0].CloseTime > ks[0].OpenTime {
+		from, to = ks[0].OpenTime.Time(), ks[0].CloseTime.Time().Add(time.Millisecond)
+	}
+	if from.IsZero() {
+		return nil
+	}
+	step := to.Sub(from)
+	source, ok := step.String(), false
+	if m, months := monthsApart(from, to); months {
+		n, _ := intervalMonths(interval)
+		source, ok = fmt.Sprintf("%dM", m), n > 0 && n%m == 0
+	} else if d, fixed := IntervalDuration(interval); fixed {
+		ok = d%step == 0
+	} else {
+		// Months hold whole days.
+		ok = 24*time.Hour%step == 0
+	}
+	if !ok {
+		return fmt.Errorf("sbee: interval %q is not a whole multiple of the %s interval of the candles", interval, source)
+	}
+	return nil
+}
+
+// monthsApart returns how many months separate from and to, and reports
+// whether both open a month.
+func monthsApart(from, to time.Time) (int, bool) {
+	from, to = from.UTC(), to.UTC()
+	m := (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+	return m, m > 0 && from.Day() == 1 && to.Day() == 1 &&
+		from.Equal(from.Truncate(24*time.Hour)) && to.Equal(to.Truncate(24*time.Hour))
+}
@@ -0,0 +1,173 @@
+package sbee
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func trade(ms int64, price, amount string) Trade {
+	return Trade{Price: d(price), Amount: d(amount), Timestamp: Timestamp(ms)}
+}
+
+func TestCandlesFromTrades(t *testing.T) {
+	base := int64(klineEpoch-1) * 60000 // a multiple of 3 minutes
+	trades := []Trade{
+		trade(base+1000, "10", "1"),
+		trade(base+500, "9", "2"), // listed late, but the open
+		trade(base+120000, "12", "1"),
+		trade(base+179999, "11", "0.5"),
+		trade(base+6*60000+1, "20", "1"), // after an empty candle
+	}
+	ks, err := CandlesFromTrades(trades, "3m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ks) != 2 {
+		t.Fatalf("candles = %+v", ks)
+	}
+	k := ks[0]
+	if k.OpenTime != Timestamp(base) || k.CloseTime != Timestamp(base+179999) ||
+		!k.Open.Equal(d("9")) || !k.High.Equal(d("12")) || !k.Low.Equal(d("9")) || !k.Close.Equal(d("11")) ||
+		!k.Volume.Equal(d("4.5")) || !k.QuoteVolume.Equal(d("45.5")) {
+		t.Errorf("first candle = %+v", k)
+	}
+	if ks[1].OpenTime != Timestamp(base+6*60000) || !ks[1].Close.Equal(d("20")) {
+		t.Errorf("second candle = %+v", ks[1])
+	}
+
+	a, _ := NewCandleAggregator("3m")
+	// Trades within the candle may arrive out of order.
+	a.Add(trades[0])
+	a.Add(trades[1])
+	if _, ok := a.Add(trade(base-1, "1", "1")); ok {
+		t.Error("a trade from the previous candle closed the current one")
+	}
+	if _, ok := a.Tick(time.UnixMilli(base + 179999)); ok {
+		t.Error("Tick closed the candle before its close time")
+	}
+	if k, ok := a.Tick(time.UnixMilli(base + 180000)); !ok || !k.Volume.Equal(d("3")) || !k.Open.Equal(d("9")) || !k.Close.Equal(d("10")) {
+		t.Errorf("Tick = %+v, %v", k, ok)
+	}
+	// A trade arriving after its candle was ticked does not reopen it.
+	a.Add(trade(base+179000, "1", "1"))
+	if k, ok := a.Current(); ok {
+		t.Errorf("late trade opened %+v", k)
+	}
+	a.Add(trade(base+180000, "13", "1"))
+	if k, ok := a.Flush(); !ok || k.OpenTime != Timestamp(base+180000) {
+		t.Errorf("Flush = %+v, %v", k, ok)
+	}
+	a.Add(trade(base+180001, "14", "1"))
+	if k, ok := a.Current(); ok {
+		t.Errorf("a trade in a flushed candle opened %+v", k)
+	}
+	if _, err := NewCandleAggregator("2x"); err == nil {
+		t.Error("unknown interval accepted")
+	}
+}
+
+func TestBucketStart(t *testing.T) {
+	at := time.Date(2024, 5, 15, 13, 47, 12, 0, time.UTC) // a Wednesday
+	for interval, want := range map[string]time.Time{
+		"45s": time.Date(2024, 5, 15, 13, 46, 30, 0, time.UTC),
+		"2h":  time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC),
+		"12h": time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC),
+		"1w":  time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC),
+		"1M":  time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		"3M":  time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		if got, ok := bucketStart(at, interval); !ok || !got.Equal(want) {
+			t.Errorf("bucketStart(%s) = %v, want %v", interval, got, want)
+		}
+	}
+}
+
+func TestResample(t *testing.T) {
+	var ks []Kline
+	for i := range 12 {
+		k := candle(int64(i), "1", "2", "0.5", "1.5", "1")
+		k.High = DecimalFromInt(int64(i + 2))
+		k.QuoteVolume = d("10")
+		ks = append(ks, k)
+	}
+	// Unsorted, with a duplicate.
+	ks[3], ks[7] = ks[7], ks[3]
+	ks = append(ks, ks[0])
+
+	out, err := Resample(ks, "5m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 3 {
+		t.Fatalf("resampled = %+v", out)
+	}
+	k := out[1]
+	start := int64(klineEpoch+5) * 60000
+	if k.OpenTime != Timestamp(start) || k.CloseTime != Timestamp(start+5*60000-1) || !k.High.Equal(d("11")) ||
+		!k.Low.Equal(d("0.5")) || !k.Volume.Equal(d("5")) || !k.QuoteVolume.Equal(d("50")) {
+		t.Errorf("second 5m candle = %+v", k)
+	}
+	if !out[2].Volume.Equal(d("2")) {
+		t.Errorf("partial last candle = %+v", out[2])
+	}
+
+	// The target must be a whole multiple of the source interval.
+	hourly := []Kline{candle(0, "1", "1", "1", "1", "1"), candle(60, "1", "1", "1", "1", "1")}
+	hourly[0].CloseTime = hourly[0].OpenTime + 3600000 - 1
+	month := func(m time.Month) Kline {
+		return Kline{OpenTime: Timestamp(time.Date(2024, m, 1, 0, 0, 0, 0, time.UTC).UnixMilli())}
+	}
+	monthly := []Kline{month(1), month(2), month(4)}
+	for _, tc := range []struct {
+		ks       []Kline
+		interval string
+		ok       bool
+	}{
+		{ks, "1m", true},
+		{ks, "30s", false},
+		{ks, "90s", false},
+		{ks, "1M", true},
+		{hourly, "2h", true},
+		{hourly, "90m", false},
+		{hourly[:1], "30m", false},
+		{hourly[:1], "1d", true},
+		{monthly, "3M", true},
+		{monthly, "2M", true},
+		{monthly, "1w", false},
+		{monthly[1:], "3M", false},
+	} {
+		if _, err := Resample(tc.ks, tc.interval); (err == nil) != tc.ok {
+			t.Errorf("Resample(%d candles, %s): err = %v", len(tc.ks), tc.interval, err)
+		}
+	}
+}
+
+func TestAggregateTrades(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	trades := make(chan Trade)
+	candles, err := AggregateTrades(ctx, trades, "1s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UnixMilli()
+	trades <- trade(now, "5", "1")
+	// Nothing else trades: the candle closes when its second ends.
+	select {
+	case k := <-candles:
+		if !k.Close.Equal(d("5")) || k.CloseTime.Time().After(time.Now()) {
+			t.Errorf("ticked candle = %+v", k)
+		}
+	case <-ctx.Done():
+		t.Fatal("no candle when the interval ended")
+	}
+	trades <- trade(now+5000, "6", "1")
+	close(trades)
+	if k, ok := <-candles; !ok || !k.Close.Equal(d("6")) {
+		t.Errorf("flushed candle = %+v, %v", k, ok)
+	}
+	if _, ok := <-candles; ok {
+		t.Error("candles not closed")
+	}
+}
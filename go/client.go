@@ -0,0 +1,147 @@
+package sbee
+
+import (
+	"net/http"
+	"time"
+)
+
+// DefaultBaseURL is the public sbee REST API root.
+const DefaultBaseURL = "https://api.sbee.io/api"
+
+// DefaultTimeout bounds each HTTP request made by a client that was not given
+// WithTimeout or WithHTTPClient. Per-call deadlines are set with the context
+// passed to each method.
+const DefaultTimeout = 30 * time.Second
+
+// DefaultUserAgent is sent with every request unless WithUserAgent overrides it.
+const DefaultUserAgent = "sbee-go-sdk"
+
+// SbeeRest is a client for the sbee REST API. Create one with NewClient; the
+// zero value is not usable. A SbeeRest is safe for concurrent use.
+//
+// Every method takes a context.Context as its first argument. Cancelling it,
+// or letting its deadline pass, aborts the request in flight. Every method
+// returns the decoded *Response and, on failure, an *APIError, or an
+// *OrderError for an order rejected locally under WithOrderRules.
+type SbeeRest struct {
+	baseURL    string
+	auth       string
+	httpClient *http.Client
+	timeout    time.Duration
+	userAgent  string
+
+	// defaultExchange and defaultTrade are used by methods whose Exchange or
+	// Trade argument is left empty.
+	defaultExchange string
+	defaultTrade    string
+
+	// rules caches Currencies for the order checks selected by rulesPolicy.
+	rules       rulesCache
+	rulesPolicy RulesPolicy
+
+	retry   RetryPolicy
+	limiter rateLimiter
+
+	// clientOrderIDs names the orders placed without a ClientOrderId.
+	clientOrderIDs ClientOrderIDGenerator
+
+	// streamURL is the WebSocket endpoint of Stream.
+	streamURL string
+
+	// credentials resolves the keys of the handles returned by Account.
+	credentials CredentialProvider
+
+	// hooks are called around every attempt, see WithHooks and WithLogger.
+	hooks []Hooks
+}
+
+// Option configures a SbeeRest created by NewClient.
+type Option func(*SbeeRest)
+
+// WithBaseURL overrides the API root, e.g. for a staging gateway.
+func WithBaseURL(baseURL string) Option {
+	return func(s *SbeeRest) {
+		s.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient makes the client send requests through c instead of a
+// private http.Client.
+func WithHTTPClient(c *http.Client) Option {
+	return func(s *SbeeRest) {
+		if c != nil {
+			s.httpClient = c
+		}
+	}
+}
+
+// WithTimeout sets the overall timeout of each HTTP request. When combined
+// with WithHTTPClient the timeout is set on a copy, so a shared http.Client is
+// not modified.
+func WithTimeout(d time.Duration) Option {
+	return func(s *SbeeRest) {
+		s.timeout = d
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(ua string) Option {
+	return func(s *SbeeRest) {
+		s.userAgent = ua
+	}
+}
+
+// WithDefaultExchange sets the exchange used when a method is called with an
+// empty Exchange, e.g. "Binance".
+func WithDefaultExchange(exchange string) Option {
+	return func(s *SbeeRest) {
+		s.defaultExchange = exchange
+	}
+}
+
+// WithDefaultTrade sets the trade type used when a method is called with an
+// empty Trade, e.g. "Spot" or "Futures".
+func WithDefaultTrade(trade string) Option {
+	return func(s *SbeeRest) {
+		s.defaultTrade = trade
+	}
+}
+
+// NewClient returns a client authenticated with the bearer token generated at
+// www.sbee.io.
+func NewClient(token string, opts ...Option) *SbeeRest {
+	s := &SbeeRest{
+		baseURL:    DefaultBaseURL,
+		auth:       token,
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+		userAgent:  DefaultUserAgent,
+		rules:      rulesCache{ttl: DefaultRulesTTL},
+		retry:      DefaultRetryPolicy(),
+
+		clientOrderIDs: NewClientOrderIDGenerator(""),
+		credentials:    EnvProvider{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.timeout > 0 {
+		c := *s.httpClient
+		c.Timeout = s.timeout
+		s.httpClient = &c
+	}
+	return s
+}
+
+func (s *SbeeRest) exchange(exchange string) string {
+	if exchange == "" {
+		return s.defaultExchange
+	}
+	return exchange
+}
+
+func (s *SbeeRest) trade(trade string) string {
+	if trade == "" {
+		return s.defaultTrade
+	}
+	return trade
+}
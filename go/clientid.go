@@ -0,0 +1,298 @@
+package sbee
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// IDLimits are the constraints an exchange puts on ClientOrderIds.
+type IDLimits struct {
+	// MaxLength is the longest id accepted, in bytes.
+	MaxLength int
+	// Prefix must start every id, e.g. "t-" on Gate.io.
+	Prefix string
+	// Extra lists the characters accepted besides ASCII letters and digits.
+	Extra string
+}
+
+// DefaultIDLimits apply to exchanges without known limits. They are the
+// strictest common ones: 32 letters and digits.
+var DefaultIDLimits = IDLimits{MaxLength: 32}
+
+// clientOrderIDLimits are the known limits, keyed by lower-cased exchange.
+var clientOrderIDLimits = map[string]IDLimits{
+	"binance": {MaxLength: 36, Extra: "._:/-"},
+	"okx":     {MaxLength: 32},
+	"bybit":   {MaxLength: 36, Extra: "-_"},
+	"kucoin":  {MaxLength: 40, Extra: "-_"},
+	"gateio":  {MaxLength: 28, Prefix: "t-", Extra: "-_."},
+	"mexc":    {MaxLength: 32, Extra: "-_"},
+}
+
+// ClientOrderIDLimits returns the ClientOrderId limits of exchange.
+func ClientOrderIDLimits(exchange string) IDLimits {
+	if l, ok := clientOrderIDLimits[strings.ToLower(exchange)]; ok {
+		return l
+	}
+	return DefaultIDLimits
+}
+
+// Check reports why id breaks l, or nil.
+func (l IDLimits) Check(id string) error {
+	if l.MaxLength > 0 && len(id) > l.MaxLength {
+		return fmt.Errorf("longer than %d characters", l.MaxLength)
+	}
+	if !strings.HasPrefix(id, l.Prefix) {
+		return fmt.Errorf("must start with %q", l.Prefix)
+	}
+	for _, c := range id[len(l.Prefix):] {
+		if !l.allows(c) {
+			return fmt.Errorf("character %q is not accepted", c)
+		}
+	}
+	return nil
+}
+
+// child returns the ClientOrderId of child n of the order parent: parent,
+// with l's Prefix if it lacks it, then a separator l allows, if any, and n.
+// parent is shortened as l's MaxLength requires. The result may still break
+// l through the characters of parent; see Check.
+func (l IDLimits) child(parent string, n int) string {
+	if !strings.HasPrefix(parent, l.Prefix) {
+		parent = l.Prefix + parent
+	}
+	suffix := strconv.Itoa(n)
+	for _, sep := range "-_" {
+		if l.allows(sep) {
+			suffix = string(sep) + suffix
+			break
+		}
+	}
+	if l.MaxLength > 0 && len(parent)+len(suffix) > l.MaxLength {
+		parent = parent[:max(len(l.Prefix), l.MaxLength-len(suffix))]
+	}
+	return parent + suffix
+}
+
+func (l IDLimits) allows(c rune) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.ContainsRune(l.Extra, c)
+}
+
+// ClientOrderIDGenerator makes the ClientOrderIds of orders placed without
+// one. Implementations must be safe for concurrent use and return ids that
+// satisfy limits.
+type ClientOrderIDGenerator interface {
+	ClientOrderID(exchange string, limits IDLimits) string
+}
+
+// ClientOrderIDFunc adapts a function to ClientOrderIDGenerator.
+type ClientOrderIDFunc func(exchange string, limits IDLimits) string
+
+func (f ClientOrderIDFunc) ClientOrderID(exchange string, limits IDLimits) string {
+	return f(exchange, limits)
+}
+
+// WithClientOrderIDs sets the generator of the ClientOrderIds given to orders
+// placed without one. By default a NewClientOrderIDGenerator("") is used; nil
+// sends such orders without an id.
+//
+// An order with a ClientOrderId can be retried safely, see RetryPolicy.
+func WithClientOrderIDs(g ClientOrderIDGenerator) Option {
+	return func(s *SbeeRest) {
+		s.clientOrderIDs = g
+	}
+}
+
+// idLength is the length of a generated id, prefixes excluded, when the
+// exchange allows it: 9 characters of time, 3 of counter and 10 random.
+const idLength = 22
+
+// NewClientOrderIDGenerator returns a generator of ids made of prefix, the
+// time in milliseconds, a per-process counter and random characters, all in
+// lower-case base 36. The random part, about 50 bits, keeps ids from
+// different processes apart; the counter keeps ids of one process apart
+// within a millisecond. Characters of prefix an exchange does not accept are
+// dropped, and prefix is shortened when the exchange's maximum length
+// requires.
+func NewClientOrderIDGenerator(prefix string) ClientOrderIDGenerator {
+	g := &idGenerator{prefix: prefix}
+	g.counter.Store(randomUint64())
+	return g
+}
+
+type idGenerator struct {
+	prefix  string
+	counter atomic.Uint64
+}
+
+func (g *idGenerator) ClientOrderID(exchange string, limits IDLimits) string {
+	var prefix strings.Builder
+	for _, c := range g.prefix {
+		if limits.allows(c) {
+			prefix.WriteRune(c)
+		}
+	}
+	user := prefix.String()
+	room := len(user) + idLength
+	if limits.MaxLength > 0 {
+		room = limits.MaxLength - len(limits.Prefix)
+	}
+	if len(user) > room-idLength {
+		user = user[:max(0, room-idLength)]
+	}
+	n := min(room-len(user), idLength)
+
+	var b strings.Builder
+	b.WriteString(limits.Prefix)
+	b.WriteString(user)
+	body := fmt.Sprintf("%09s%03s", strconv.FormatInt(time.Now().UnixMilli(), 36), strconv.FormatUint(g.counter.Add(1)%(36*36*36), 36))
+	if n < len(body) {
+		// Too short for the time: rely on randomness alone.
+		body = ""
+	}
+	b.WriteString(body)
+	for b.Len() < len(limits.Prefix)+len(user)+n {
+		b.WriteString(strconv.FormatUint(randomUint64(), 36))
+	}
+	return b.String()[:len(limits.Prefix)+len(user)+n]
+}
+
+func randomUint64() uint64 {
+	var b [8]byte
+	rand.Read(b[:])
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+// newClientOrderID returns a new id for an order on exchange, or "" when
+// generation is disabled.
+func (s *SbeeRest) newClientOrderID(exchange string) string {
+	if s.clientOrderIDs == nil {
+		return ""
+	}
+	return s.clientOrderIDs.ClientOrderID(exchange, ClientOrderIDLimits(exchange))
+}
+
+// assignClientOrderIDs gives the orders of r without a ClientOrderId a new
+// one.
+func (s *SbeeRest) assignClientOrderIDs(r *request) {
+	if s.clientOrderIDs == nil {
+		return
+	}
+	var ids []*string
+	switch b := r.body.(type) {
+	case PlaceLimitOrderRequest:
+		ids = append(ids, &b.ClientOrderID)
+		defer func() { r.body = b }()
+	case PlaceMarketOrderRequest:
+		ids = append(ids, &b.ClientOrderID)
+		defer func() { r.body = b }()
+	case StopOrderRequest:
+		ids = append(ids, &b.ClientOrderID)
+		defer func() { r.body = b }()
+	case PlaceBatchLimitOrdersRequest:
+		b.Orders = append([]BatchLimitOrder(nil), b.Orders...)
+		for i := range b.Orders {
+			ids = append(ids, &b.Orders[i].ClientOrderID)
+		}
+		defer func() { r.body = b }()
+	case PlaceBatchMarketOrdersRequest:
+		b.Orders = append([]BatchMarketOrder(nil), b.Orders...)
+		for i := range b.Orders {
+			ids = append(ids, &b.Orders[i].ClientOrderID)
+		}
+		defer func() { r.body = b }()
+	case []LimitOrderForPeople:
+		b = append([]LimitOrderForPeople(nil), b...)
+		for i := range b {
+			ids = append(ids, &b[i].ClientOrderID)
+		}
+		defer func() { r.body = b }()
+	case []MarketOrderForPeople:
+		b = append([]MarketOrderForPeople(nil), b...)
+		for i := range b {
+			ids = append(ids, &b[i].ClientOrderID)
+		}
+		defer func() { r.body = b }()
+	}
+	for _, id := range ids {
+		if *id == "" {
+			*id = s.newClientOrderID(r.exchange)
+		}
+	}
+}
+
+// clientOrderIDs returns the ClientOrderIds of the orders r places, in
+// order, or nil if r places none.
+func (r *request) clientOrderIDs() []string {
+	var sent []string
+	switch b := r.body.(type) {
+	case PlaceLimitOrderRequest:
+		sent = []string{b.ClientOrderID}
+	case PlaceMarketOrderRequest:
+		sent = []string{b.ClientOrderID}
+	case StopOrderRequest:
+		sent = []string{b.ClientOrderID}
+	case PlaceBatchLimitOrdersRequest:
+		for _, o := range b.Orders {
+			sent = append(sent, o.ClientOrderID)
+		}
+	case PlaceBatchMarketOrdersRequest:
+		for _, o := range b.Orders {
+			sent = append(sent, o.ClientOrderID)
+		}
+	case []LimitOrderForPeople:
+		for _, o := range b {
+			sent = append(sent, o.ClientOrderID)
+		}
+	case []MarketOrderForPeople:
+		for _, o := range b {
+			sent = append(sent, o.ClientOrderID)
+		}
+	}
+	return sent
+}
+
+// echoClientOrderIDs copies the ClientOrderIds sent with r into the orders of
+// data the API returned without one, so generated ids reach the caller.
+// Batch results are matched by position.
+func (r *request) echoClientOrderIDs(data any) {
+	sent := r.clientOrderIDs()
+	switch d := data.(type) {
+	case *Order:
+		if d.ClientOrderID == "" && len(sent) == 1 {
+			d.ClientOrderID = sent[0]
+		}
+	case *[]OrderResult:
+		if len(*d) != len(sent) {
+			return
+		}
+		for i := range *d {
+			if (*d)[i].ClientOrderID == "" {
+				(*d)[i].ClientOrderID = sent[i]
+			}
+		}
+	}
+}
+
+// tagClientOrderIDs records the ClientOrderIds sent with r in err, when it
+// is an *APIError, so generated ids reach the caller of a call that failed
+// too, e.g. to look up an order whose placement timed out.
+func (r *request) tagClientOrderIDs(err error) {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return
+	}
+	sent := r.clientOrderIDs()
+	switch r.body.(type) {
+	case PlaceLimitOrderRequest, PlaceMarketOrderRequest, StopOrderRequest:
+		apiErr.ClientOrderID = sent[0]
+	default:
+		apiErr.ClientOrderIDs = sent
+	}
+}
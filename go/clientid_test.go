@@ -0,0 +1,138 @@
+package sbee
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestClientOrderIDGenerator(t *testing.T) {
+	g := NewClientOrderIDGenerator("bot 1/")
+	for _, exchange := range []string{"Binance", "OKX", "Bybit", "KuCoin", "GateIO", "Mexc", "Unknown"} {
+		limits := ClientOrderIDLimits(exchange)
+		id := g.ClientOrderID(exchange, limits)
+		if err := limits.Check(id); err != nil {
+			t.Errorf("%s id %q: %v", exchange, id, err)
+		}
+		if !strings.HasPrefix(id, limits.Prefix+"bot1") {
+			t.Errorf("%s id %q does not start with the filtered prefix", exchange, id)
+		}
+	}
+	if id := g.ClientOrderID("Tiny", IDLimits{MaxLength: 8}); len(id) != 8 {
+		t.Errorf("id %q for an 8 character limit", id)
+	}
+
+	// Ids stay distinct across generators, as they would across processes,
+	// and under concurrent use.
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	var wg sync.WaitGroup
+	for range 4 {
+		g := NewClientOrderIDGenerator("")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 2000 {
+				id := g.ClientOrderID("OKX", ClientOrderIDLimits("OKX"))
+				mu.Lock()
+				if seen[id] {
+					t.Errorf("duplicate id %q", id)
+				}
+				seen[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestChildClientOrderID(t *testing.T) {
+	long := strings.Repeat("p", 32)
+	for _, tc := range []struct {
+		exchange, parent string
+		n                int
+		want             string
+	}{
+		{"Binance", "parent", 1, "parent-1"},
+		{"OKX", "parent", 2, "parent2"},
+		{"GateIO", "parent", 3, "t-parent-3"},
+		{"GateIO", "t-parent", 3, "t-parent-3"},
+		{"OKX", long, 12, long[:30] + "12"},
+		{"GateIO", long, 1, "t-" + long[:24] + "-1"},
+	} {
+		limits := ClientOrderIDLimits(tc.exchange)
+		got := limits.child(tc.parent, tc.n)
+		if got != tc.want {
+			t.Errorf("%s child %d of %q = %q, want %q", tc.exchange, tc.n, tc.parent, got, tc.want)
+		}
+		if err := limits.Check(got); err != nil {
+			t.Errorf("%s child %q: %v", tc.exchange, got, err)
+		}
+	}
+}
+
+func TestClientOrderIDAssigned(t *testing.T) {
+	ctx := context.Background()
+	s, got := newTestServer(t, `{"isSuccess":true,"data":{"orderId":"1"}}`)
+
+	resp, err := s.PlaceLimitOrder(ctx, "GateIO", "Spot", "BTC-USDT", "", d("16000"), d("0"), d("0.005"), SideBuy, "key", "secret", "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body PlaceLimitOrderRequest
+	json.Unmarshal(got.body, &body)
+	if !strings.HasPrefix(body.ClientOrderID, "t-") || body.ClientOrderID != resp.Data.ClientOrderID {
+		t.Errorf("sent ClientOrderId %q, returned %q", body.ClientOrderID, resp.Data.ClientOrderID)
+	}
+
+	// Ids given by the caller are kept; batch results get theirs by position.
+	s, got = newTestServer(t, `{"isSuccess":true,"data":[{"orderId":"1"},{"orderId":"2","clientOrderId":"server"}]}`)
+	orders := []BatchLimitOrder{{Symbol: "BTC-USDT", ClientOrderID: "mine"}, {Symbol: "BTC-USDT"}}
+	results, err := s.PlaceBatchLimitOrders(ctx, "Binance", "Spot", orders, "key", "secret", "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var batch PlaceBatchLimitOrdersRequest
+	json.Unmarshal(got.body, &batch)
+	if batch.Orders[0].ClientOrderID != "mine" || batch.Orders[1].ClientOrderID == "" || orders[1].ClientOrderID != "" {
+		t.Errorf("sent %+v, caller's orders now %+v", batch.Orders, orders)
+	}
+	if results.Data[0].ClientOrderID != "mine" || results.Data[1].ClientOrderID != "server" {
+		t.Errorf("results = %+v", results.Data)
+	}
+}
+
+func TestClientOrderIDOnError(t *testing.T) {
+	ctx := context.Background()
+	var sent []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusGatewayTimeout)
+	}))
+	defer srv.Close()
+	s := NewClient("token", WithBaseURL(srv.URL), WithRetryPolicy(RetryPolicy{}))
+
+	_, err := s.Account("").PlaceMarketOrder(ctx, "Binance", "Spot", "BTC-USDT", "", Decimal{}, d("10"), Decimal{}, 0, 0, SideBuy)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v", err)
+	}
+	var body PlaceMarketOrderRequest
+	json.Unmarshal(sent, &body)
+	if apiErr.ClientOrderID == "" || apiErr.ClientOrderID != body.ClientOrderID {
+		t.Errorf("error carries ClientOrderId %q, sent %q", apiErr.ClientOrderID, body.ClientOrderID)
+	}
+
+	_, err = s.PlaceBatchMarketOrders(ctx, "Binance", "Spot", []BatchMarketOrder{{ClientOrderID: "mine"}, {}}, "key", "secret", "")
+	var batch PlaceBatchMarketOrdersRequest
+	json.Unmarshal(sent, &batch)
+	if !errors.As(err, &apiErr) || len(apiErr.ClientOrderIDs) != 2 || apiErr.ClientOrderIDs[0] != "mine" || apiErr.ClientOrderIDs[1] != batch.Orders[1].ClientOrderID {
+		t.Errorf("batch error carries %q, sent %+v", apiErr.ClientOrderIDs, batch.Orders)
+	}
+}
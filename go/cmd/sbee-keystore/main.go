@@ -0,0 +1,151 @@
+// Command sbee-keystore manages an encrypted sbee keystore of exchange API
+// keys.
+//
+//	sbee-keystore [-f file] init
+//	sbee-keystore [-f file] list
+//	sbee-keystore [-f file] add ACCOUNT EXCHANGE
+//	sbee-keystore [-f file] rotate ACCOUNT EXCHANGE
+//	sbee-keystore [-f file] remove ACCOUNT EXCHANGE
+//	sbee-keystore [-f file] passwd
+//
+// The file defaults to $SBEE_KEYSTORE or sbee/keystore.json under the user's
+// configuration directory. The passphrase is read from
+// $SBEE_KEYSTORE_PASSPHRASE, or else as a line of standard input, as are the
+// new passphrase of passwd ($SBEE_KEYSTORE_NEW_PASSPHRASE) and the API key,
+// secret and passphrase of add and rotate, so that secrets stay out of the
+// command line and shell history. Input is echoed when typed at a terminal;
+// prefer piping it from a password manager.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	sbee "github.com/sbeeIO/sdk/go"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "sbee-keystore:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("sbee-keystore", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	file := flags.String("f", "", "keystore `file`")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		p, err := sbee.DefaultKeystorePath()
+		if err != nil {
+			return err
+		}
+		*file = p
+	}
+	args = flags.Args()
+	if len(args) == 0 {
+		return errors.New("missing command: init, list, add, rotate, remove or passwd")
+	}
+
+	in := bufio.NewReader(stdin)
+	prompt := func(env, label string) ([]byte, error) {
+		if v := os.Getenv(env); v != "" {
+			return []byte(v), nil
+		}
+		fmt.Fprintf(stderr, "%s: ", label)
+		line, err := in.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return nil, fmt.Errorf("read %s: %w", strings.ToLower(label), err)
+		}
+		return []byte(strings.TrimRight(line, "\r\n")), nil
+	}
+	keys := func() (sbee.Credentials, error) {
+		var c sbee.Credentials
+		for _, f := range []struct {
+			label string
+			dst   *string
+		}{{"API key", &c.APIKey}, {"API secret", &c.APISecret}, {"API passphrase (may be empty)", &c.APIPass}} {
+			v, err := prompt("", f.label)
+			if err != nil {
+				return c, err
+			}
+			*f.dst = string(v)
+		}
+		if c.APIKey == "" {
+			return c, errors.New("empty API key")
+		}
+		return c, nil
+	}
+	pair := func() (string, string, error) {
+		if len(args) != 3 {
+			return "", "", fmt.Errorf("usage: %s ACCOUNT EXCHANGE", args[0])
+		}
+		return args[1], args[2], nil
+	}
+
+	passphrase, err := prompt("SBEE_KEYSTORE_PASSPHRASE", "Keystore passphrase")
+	if err != nil {
+		return err
+	}
+	if args[0] == "init" {
+		if _, err := sbee.CreateKeystore(*file, passphrase); err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, "created", *file)
+		return nil
+	}
+	ks, err := sbee.OpenKeystore(*file, passphrase)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		entries, err := ks.List()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ACCOUNT\tEXCHANGE\tCREATED\tROTATED")
+		for _, e := range entries {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Account, e.Exchange, e.Created.Format(time.RFC3339), e.Rotated.Format(time.RFC3339))
+		}
+		return w.Flush()
+	case "add", "rotate":
+		account, exchange, err := pair()
+		if err != nil {
+			return err
+		}
+		c, err := keys()
+		if err != nil {
+			return err
+		}
+		if args[0] == "add" {
+			return ks.Add(account, exchange, c)
+		}
+		return ks.Rotate(account, exchange, c)
+	case "remove":
+		account, exchange, err := pair()
+		if err != nil {
+			return err
+		}
+		return ks.Remove(account, exchange)
+	case "passwd":
+		next, err := prompt("SBEE_KEYSTORE_NEW_PASSPHRASE", "New passphrase")
+		if err != nil {
+			return err
+		}
+		return ks.ChangePassphrase(next)
+	}
+	return fmt.Errorf("unknown command %q", args[0])
+}
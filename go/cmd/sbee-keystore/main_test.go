@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sbee "github.com/sbeeIO/sdk/go"
+)
+
+// sbeeKeystore runs the command with args and stdin, returning its output.
+func sbeeKeystore(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	err := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return stdout.String(), err
+}
+
+func TestInit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keystore.json")
+	if out, err := sbeeKeystore(t, "pw\n", "-f", path, "init"); err != nil || !strings.Contains(out, path) {
+		t.Fatalf("init = %q, %v", out, err)
+	}
+	if _, err := sbeeKeystore(t, "pw\n", "-f", path, "init"); !errors.Is(err, sbee.ErrKeystoreExists) {
+		t.Errorf("second init: err = %v", err)
+	}
+	if _, err := sbee.OpenKeystore(path, []byte("pw")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keystore.json")
+	if _, err := sbee.CreateKeystore(path, []byte("pw"), sbee.WithKeystoreIterations(1000)); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SBEE_KEYSTORE_PASSPHRASE", "pw")
+	ks, err := sbee.OpenKeystore(path, []byte("pw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := sbeeKeystore(t, "key\nsecret\n\n", "-f", path, "add", "main", "Binance"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sbeeKeystore(t, "okx\nokx-secret\nokx-pass", "-f", path, "add", "alt", "OKX"); err != nil {
+		t.Fatal(err)
+	}
+	if c, err := ks.Credentials(ctx, "alt", "OKX"); err != nil || c != (sbee.Credentials{APIKey: "okx", APISecret: "okx-secret", APIPass: "okx-pass"}) {
+		t.Errorf("added keys = %+v, %v", c, err)
+	}
+	if _, err := sbeeKeystore(t, "key\nsecret\n\n", "-f", path, "add", "main", "Binance"); !errors.Is(err, sbee.ErrKeyExists) {
+		t.Errorf("duplicate add: err = %v", err)
+	}
+	if _, err := sbeeKeystore(t, "\nsecret\n\n", "-f", path, "add", "x", "Binance"); err == nil {
+		t.Error("add with an empty key succeeded")
+	}
+
+	if _, err := sbeeKeystore(t, "new-key\nnew-secret\n\n", "-f", path, "rotate", "main", "Binance"); err != nil {
+		t.Fatal(err)
+	}
+	if c, err := ks.Credentials(ctx, "main", "Binance"); err != nil || c.APIKey != "new-key" || c.APISecret != "new-secret" {
+		t.Errorf("rotated keys = %+v, %v", c, err)
+	}
+	if _, err := sbeeKeystore(t, "k\ns\n\n", "-f", path, "rotate", "main", "KuCoin"); !errors.Is(err, sbee.ErrNoCredentials) {
+		t.Errorf("rotate missing: err = %v", err)
+	}
+
+	out, err := sbeeKeystore(t, "", "-f", path, "list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "ACCOUNT") || !strings.HasPrefix(lines[1], "alt ") || !strings.HasPrefix(lines[2], "main ") {
+		t.Errorf("list:\n%s", out)
+	}
+	if strings.Contains(out, "secret") || strings.Contains(out, "new-key") {
+		t.Errorf("list shows keys:\n%s", out)
+	}
+
+	if _, err := sbeeKeystore(t, "", "-f", path, "remove", "alt", "OKX"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.Credentials(ctx, "alt", "OKX"); !errors.Is(err, sbee.ErrNoCredentials) {
+		t.Errorf("removed entry: err = %v", err)
+	}
+	if _, err := sbeeKeystore(t, "", "-f", path, "remove", "alt", "OKX"); !errors.Is(err, sbee.ErrNoCredentials) {
+		t.Errorf("second remove: err = %v", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keystore.json")
+	if _, err := sbee.CreateKeystore(path, []byte("pw"), sbee.WithKeystoreIterations(1000)); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		stdin string
+		args  []string
+		want  string
+	}{
+		{"pw\n", nil, "missing command"},
+		{"pw\n", []string{"frobnicate"}, "unknown command"},
+		{"pw\n", []string{"remove", "main"}, "usage: remove ACCOUNT EXCHANGE"},
+		{"", []string{"list"}, "read keystore passphrase"},
+		{"wrong\n", []string{"list"}, sbee.ErrWrongPassphrase.Error()},
+	} {
+		_, err := sbeeKeystore(t, tc.stdin, append([]string{"-f", path}, tc.args...)...)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%q: err = %v, want %q", tc.args, err, tc.want)
+		}
+	}
+}
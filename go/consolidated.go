@@ -0,0 +1,283 @@
+package sbee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ConsolidatedBookRequest selects the venues and depth of a ConsolidatedBook.
+type ConsolidatedBookRequest struct {
+	Trade     string
+	Symbol    string
+	Depth     int
+	Precision int
+	Exchanges []string
+}
+
+// VenueQuantity is the size one exchange contributes to a price level.
+type VenueQuantity struct {
+	Exchange string
+	Size     Decimal
+}
+
+// ConsolidatedLevel is one price of a consolidated book with the total size
+// across venues and each venue's share.
+type ConsolidatedLevel struct {
+	Price  Decimal
+	Size   Decimal
+	Venues []VenueQuantity
+}
+
+// ConsolidatedBook is an order book merged across exchanges. Bids and Asks
+// are best first. Exchanges reports which venues answered; venues that
+// failed contribute no levels.
+type ConsolidatedBook struct {
+	Symbol    string
+	Exchanges []ExchangeStatus
+	Bids      []ConsolidatedLevel
+	Asks      []ConsolidatedLevel
+}
+
+// Fill is the outcome of walking a book for a given size.
+type Fill struct {
+	// Exchange is set when the fill is restricted to one venue.
+	Exchange string
+	// Size is how much of the requested size the book can fill; Complete
+	// reports whether that is all of it.
+	Size     Decimal
+	Complete bool
+	// Notional is the quote amount exchanged, AvgPrice Notional / Size and
+	// WorstPrice the last level reached.
+	Notional   Decimal
+	AvgPrice   Decimal
+	WorstPrice Decimal
+	// Venues is the size taken from each exchange, in book order.
+	Venues []VenueQuantity
+}
+
+// CrossSpread is the spread between the best bid and best ask of any venues.
+// A negative Spread means the book is crossed: one venue bids above
+// another's ask.
+type CrossSpread struct {
+	BestBid, BestAsk           Decimal
+	BestBidVenue, BestAskVenue string
+	Spread                     Decimal
+}
+
+// ConsolidatedBook fetches the book of req.Symbol from each exchange through
+// MultiOrderBook, concurrently, and merges them level by level keeping each
+// venue's size. It fails only when no venue answers.
+func (s *SbeeRest) ConsolidatedBook(ctx context.Context, req ConsolidatedBookRequest) (*ConsolidatedBook, error) {
+	type result struct {
+		status ExchangeStatus
+		book   OrderBook
+		err    error
+	}
+	results := make([]result, len(req.Exchanges))
+	var wg sync.WaitGroup
+	for i, ex := range req.Exchanges {
+		wg.Add(1)
+		go func(i int, ex string) {
+			defer wg.Done()
+			results[i].status = ExchangeStatus{ExchangeName: ex}
+			resp, err := s.MultiOrderBook(ctx, req.Trade, MultiMarketRequest{
+				Symbol:    req.Symbol,
+				Depth:     req.Depth,
+				Precision: req.Precision,
+				Exchanges: []string{ex},
+			})
+			if err != nil {
+				results[i].err = err
+				results[i].status.ErrorMessage = err.Error()
+				var apiErr *APIError
+				if errors.As(err, &apiErr) {
+					results[i].status.ErrorCode = apiErr.Code
+				}
+				return
+			}
+			for _, st := range resp.Data.Exchanges {
+				if strings.EqualFold(st.ExchangeName, ex) {
+					results[i].status = st
+				}
+			}
+			if len(resp.Data.Exchanges) == 0 {
+				results[i].status.IsSuccess = true
+			}
+			results[i].book = resp.Data.OrderBook
+		}(i, ex)
+	}
+	wg.Wait()
+
+	book := &ConsolidatedBook{Symbol: req.Symbol}
+	var bids, asks []venueLevel
+	var firstErr error
+	for _, r := range results {
+		book.Exchanges = append(book.Exchanges, r.status)
+		if !r.status.IsSuccess {
+			if firstErr == nil {
+				firstErr = r.err
+				if firstErr == nil {
+					firstErr = errors.New(r.status.ErrorMessage)
+				}
+			}
+			continue
+		}
+		name := r.status.ExchangeName
+		for _, l := range r.book.Bids {
+			bids = append(bids, venueLevel{name, l})
+		}
+		for _, l := range r.book.Asks {
+			asks = append(asks, venueLevel{name, l})
+		}
+	}
+	if len(bids) == 0 && len(asks) == 0 && firstErr != nil {
+		return book, fmt.Errorf("sbee: ConsolidatedBook: no exchange answered: %w", firstErr)
+	}
+	book.Bids = mergeLevels(bids, BidSide)
+	book.Asks = mergeLevels(asks, AskSide)
+	return book, nil
+}
+
+type venueLevel struct {
+	exchange string
+	PriceLevel
+}
+
+// mergeLevels groups venue levels by price, best first.
+func mergeLevels(in []venueLevel, side BookSide) []ConsolidatedLevel {
+	sort.SliceStable(in, func(i, j int) bool { return beyond(in[j].Price, in[i].Price, side) })
+	var out []ConsolidatedLevel
+	for _, l := range in {
+		if l.Size.Sign() <= 0 {
+			continue
+		}
+		if n := len(out); n > 0 && out[n-1].Price.Equal(l.Price) {
+			out[n-1].Size = out[n-1].Size.Add(l.Size)
+			out[n-1].Venues = append(out[n-1].Venues, VenueQuantity{l.exchange, l.Size})
+			continue
+		}
+		out = append(out, ConsolidatedLevel{
+			Price:  l.Price,
+			Size:   l.Size,
+			Venues: []VenueQuantity{{l.exchange, l.Size}},
+		})
+	}
+	return out
+}
+
+// takerSide returns the book side a taker of side walks: asks for a buy,
+// bids for a sell.
+func (b *ConsolidatedBook) takerSide(side string) []ConsolidatedLevel {
+	if strings.EqualFold(side, SideSell) {
+		return b.Bids
+	}
+	return b.Asks
+}
+
+// EffectivePrice walks the consolidated book as a taker of size on side
+// (SideBuy or SideSell) across every venue.
+func (b *ConsolidatedBook) EffectivePrice(side string, size Decimal) Fill {
+	return walkBook(b.takerSide(side), size, "")
+}
+
+// VenuePrice walks only exchange's share of the book.
+func (b *ConsolidatedBook) VenuePrice(exchange, side string, size Decimal) Fill {
+	return walkBook(b.takerSide(side), size, exchange)
+}
+
+// BestVenue returns the single venue that fills size on side at the best
+// average price. Venues that can fill all of size are preferred to those
+// that cannot. It returns false when no venue has liquidity on that side.
+func (b *ConsolidatedBook) BestVenue(side string, size Decimal) (Fill, bool) {
+	var best Fill
+	found := false
+	sell := strings.EqualFold(side, SideSell)
+	for _, st := range b.Exchanges {
+		if !st.IsSuccess {
+			continue
+		}
+		f := b.VenuePrice(st.ExchangeName, side, size)
+		if f.Size.Sign() <= 0 {
+			continue
+		}
+		if !found || betterFill(f, best, sell) {
+			best, found = f, true
+		}
+	}
+	return best, found
+}
+
+// betterFill reports whether f beats best: complete fills first, then the
+// larger partial fill, then the better average price.
+func betterFill(f, best Fill, sell bool) bool {
+	if f.Complete != best.Complete {
+		return f.Complete
+	}
+	if !f.Complete && !f.Size.Equal(best.Size) {
+		return f.Size.GreaterThan(best.Size)
+	}
+	if sell {
+		return f.AvgPrice.GreaterThan(best.AvgPrice)
+	}
+	return f.AvgPrice.LessThan(best.AvgPrice)
+}
+
+// Spread returns the best bid and ask across venues.
+func (b *ConsolidatedBook) Spread() (CrossSpread, bool) {
+	if len(b.Bids) == 0 || len(b.Asks) == 0 {
+		return CrossSpread{}, false
+	}
+	bid, ask := b.Bids[0], b.Asks[0]
+	return CrossSpread{
+		BestBid:      bid.Price,
+		BestAsk:      ask.Price,
+		BestBidVenue: bid.Venues[0].Exchange,
+		BestAskVenue: ask.Venues[0].Exchange,
+		Spread:       ask.Price.Sub(bid.Price),
+	}, true
+}
+
+// walkBook takes size from levels, best first, from every venue or only from
+// exchange when it is set.
+func walkBook(levels []ConsolidatedLevel, size Decimal, exchange string) Fill {
+	f := Fill{Exchange: exchange}
+	remaining := size
+	for _, l := range levels {
+		if remaining.Sign() <= 0 {
+			break
+		}
+		for _, v := range l.Venues {
+			if remaining.Sign() <= 0 {
+				break
+			}
+			if exchange != "" && !strings.EqualFold(v.Exchange, exchange) {
+				continue
+			}
+			take := MinDecimal(v.Size, remaining)
+			remaining = remaining.Sub(take)
+			f.Size = f.Size.Add(take)
+			f.Notional = f.Notional.Add(take.Mul(l.Price))
+			f.WorstPrice = l.Price
+			f.Venues = addVenue(f.Venues, v.Exchange, take)
+		}
+	}
+	f.Complete = remaining.Sign() <= 0
+	if f.Size.Sign() > 0 {
+		f.AvgPrice = f.Notional.Div(f.Size)
+	}
+	return f
+}
+
+func addVenue(venues []VenueQuantity, exchange string, size Decimal) []VenueQuantity {
+	for i := range venues {
+		if venues[i].Exchange == exchange {
+			venues[i].Size = venues[i].Size.Add(size)
+			return venues
+		}
+	}
+	return append(venues, VenueQuantity{exchange, size})
+}
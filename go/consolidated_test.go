@@ -0,0 +1,83 @@
+package sbee
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// venueBooks are the books served per exchange by newMultiBookServer.
+var venueBooks = map[string]string{
+	"Binance": `{"bids":[{"price":"100","size":"1"},{"price":"99","size":"2"}],"asks":[{"price":"101","size":"1"},{"price":"103","size":"5"}]}`,
+	"OKX":     `{"bids":[{"price":"100.0","size":"0.5"},{"price":"98","size":"4"}],"asks":[{"price":"101.5","size":"3"}]}`,
+}
+
+// newMultiBookServer answers MultiOrderBook for one exchange at a time from
+// venueBooks; other exchanges are reported as failed.
+func newMultiBookServer(t *testing.T) *SbeeRest {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req MultiMarketRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		ex := req.Exchanges[0]
+		book, ok := venueBooks[ex]
+		if !ok {
+			io.WriteString(w, `{"isSuccess":true,"data":{"exchanges":[{"exchangeName":"`+ex+`","isSuccess":false,"errorMessage":"(SMSG) Specified symbol does not exist!","errorCode":"1014"}],"orderBook":{}}}`)
+			return
+		}
+		io.WriteString(w, `{"isSuccess":true,"data":{"exchanges":[{"exchangeName":"`+ex+`","isSuccess":true}],"orderBook":`+book+`}}`)
+	}))
+	t.Cleanup(srv.Close)
+	return NewClient("token", WithBaseURL(srv.URL))
+}
+
+func TestConsolidatedBook(t *testing.T) {
+	s := newMultiBookServer(t)
+	book, err := s.ConsolidatedBook(context.Background(), ConsolidatedBookRequest{
+		Trade:     "Spot",
+		Symbol:    "BTC-USDT",
+		Depth:     20,
+		Exchanges: []string{"Binance", "OKX", "Bitfinex"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(book.Exchanges) != 3 || book.Exchanges[2].IsSuccess || book.Exchanges[2].ErrorCode != "1014" {
+		t.Errorf("exchanges = %+v", book.Exchanges)
+	}
+
+	// 100 and 100.0 are the same level, shared by both venues.
+	top := book.Bids[0]
+	if len(book.Bids) != 3 || top.Price.String() != "100" || top.Size.String() != "1.5" || len(top.Venues) != 2 || top.Venues[1].Exchange != "OKX" {
+		t.Fatalf("bids = %+v", book.Bids)
+	}
+
+	spread, ok := book.Spread()
+	if !ok || spread.Spread.String() != "1" || spread.BestAskVenue != "Binance" {
+		t.Errorf("spread = %+v", spread)
+	}
+
+	// Buying 3 takes 1 @ 101 on Binance then 2 @ 101.5 on OKX.
+	fill := book.EffectivePrice(SideBuy, d("3"))
+	if !fill.Complete || fill.Notional.String() != "304.0" || fill.AvgPrice.String() != "101.3333333333333333" || fill.WorstPrice.String() != "101.5" {
+		t.Errorf("buy fill = %+v", fill)
+	}
+	if len(fill.Venues) != 2 || fill.Venues[1].Size.String() != "2" {
+		t.Errorf("buy venues = %+v", fill.Venues)
+	}
+	if f := book.EffectivePrice(SideSell, d("100")); f.Complete || f.Size.String() != "7.5" {
+		t.Errorf("oversized sell = %+v", f)
+	}
+
+	// OKX alone fills 3 at 101.5; Binance needs its 103 level.
+	if best, ok := book.BestVenue(SideBuy, d("3")); !ok || best.Exchange != "OKX" || !best.AvgPrice.Equal(d("101.5")) {
+		t.Errorf("best venue for buy = %+v", best)
+	}
+	// Only Binance can sell 3 in full.
+	if best, ok := book.BestVenue(SideSell, d("3")); !ok || best.Exchange != "Binance" || !best.Complete {
+		t.Errorf("best venue for sell = %+v", best)
+	}
+}
@@ -0,0 +1,441 @@
+package sbee
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact, arbitrary-precision decimal number used for every
+// price, quantity and balance in the SDK. Its value is unscaled × 10^-scale.
+// The zero value is 0. Decimals are immutable; every operation returns a new
+// value, so they are safe to share between goroutines.
+//
+// Decimals encode to JSON as bare numbers with the exact digits they hold,
+// and decode from JSON numbers and numeric strings alike.
+type Decimal struct {
+	unscaled *big.Int
+	scale    int32
+}
+
+// RoundingMode selects how Round, DivRound and RoundStep discard digits.
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds to nearest, ties away from zero.
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven rounds to nearest, ties to the even neighbour.
+	RoundHalfEven
+	// RoundHalfDown rounds to nearest, ties toward zero.
+	RoundHalfDown
+	// RoundDown truncates toward zero.
+	RoundDown
+	// RoundUp rounds away from zero.
+	RoundUp
+	// RoundFloor rounds toward negative infinity.
+	RoundFloor
+	// RoundCeiling rounds toward positive infinity.
+	RoundCeiling
+)
+
+func (m RoundingMode) String() string {
+	switch m {
+	case RoundHalfUp:
+		return "HalfUp"
+	case RoundHalfEven:
+		return "HalfEven"
+	case RoundHalfDown:
+		return "HalfDown"
+	case RoundDown:
+		return "Down"
+	case RoundUp:
+		return "Up"
+	case RoundFloor:
+		return "Floor"
+	case RoundCeiling:
+		return "Ceiling"
+	}
+	return fmt.Sprintf("RoundingMode(%d)", int(m))
+}
+
+// DivisionPrecision is the number of decimal places Div keeps.
+const DivisionPrecision = 16
+
+// NewDecimal returns unscaled × 10^-scale, e.g. NewDecimal(16005, 2) is 160.05.
+// A negative scale multiplies by a power of ten.
+func NewDecimal(unscaled int64, scale int32) Decimal {
+	return normalize(big.NewInt(unscaled), scale)
+}
+
+// normalize returns unscaled × 10^-scale, folding a negative scale into
+// unscaled so that every Decimal has a scale of at least 0. unscaled is
+// owned by the result.
+func normalize(unscaled *big.Int, scale int32) Decimal {
+	if scale < 0 {
+		unscaled.Mul(unscaled, pow10(-scale))
+		scale = 0
+	}
+	return Decimal{unscaled: unscaled, scale: scale}
+}
+
+// DecimalFromInt returns i as a Decimal.
+func DecimalFromInt(i int64) Decimal {
+	return NewDecimal(i, 0)
+}
+
+// DecimalFromFloat returns the shortest decimal that converts back to f, so
+// DecimalFromFloat(0.1) is exactly 0.1. It panics on NaN and infinities.
+func DecimalFromFloat(f float64) Decimal {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		panic(fmt.Sprintf("sbee: DecimalFromFloat(%v)", f))
+	}
+	return MustParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// maxExponent bounds the exponent ParseDecimal accepts, and the scale it
+// produces, far beyond any price or quantity, so that a hostile "1e2000000000"
+// cannot make it build a number with billions of digits.
+const maxExponent = 1000
+
+// ParseDecimal parses a decimal such as "42014", "-0.00013" or "1.5e-3".
+func ParseDecimal(s string) (Decimal, error) {
+	orig := s
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Decimal{}, fmt.Errorf("sbee: invalid decimal %q", orig)
+	}
+
+	var exp int64
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("sbee: invalid decimal %q", orig)
+		}
+		if e > maxExponent || e < -maxExponent {
+			return Decimal{}, fmt.Errorf("sbee: decimal %q out of range", orig)
+		}
+		exp, s = e, s[:i]
+	}
+
+	neg := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		neg, s = true, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	digits := intPart + fracPart
+	if digits == "" || strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return Decimal{}, fmt.Errorf("sbee: invalid decimal %q", orig)
+	}
+
+	unscaled, _ := new(big.Int).SetString(digits, 10)
+	if neg {
+		unscaled.Neg(unscaled)
+	}
+	scale := int64(len(fracPart)) - exp
+	if scale > maxExponent {
+		return Decimal{}, fmt.Errorf("sbee: decimal %q out of range", orig)
+	}
+	return normalize(unscaled, int32(scale)), nil
+}
+
+// MustParseDecimal is like ParseDecimal but panics on malformed input. It is
+// meant for constants.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// int returns the unscaled value, treating the zero Decimal as 0. The result
+// must not be modified.
+func (d Decimal) int() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return d.unscaled
+}
+
+// rescale returns the unscaled value of d at a scale of at least d.scale.
+func (d Decimal) rescale(scale int32) *big.Int {
+	if scale <= d.scale {
+		return d.int()
+	}
+	return new(big.Int).Mul(d.int(), pow10(scale-d.scale))
+}
+
+// Scale returns the number of digits after the decimal point.
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Sign returns -1, 0 or +1.
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+// IsZero reports whether d is 0.
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return Decimal{unscaled: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+// Abs returns |d|.
+func (d Decimal) Abs() Decimal {
+	return Decimal{unscaled: new(big.Int).Abs(d.int()), scale: d.scale}
+}
+
+// Add returns d + e.
+func (d Decimal) Add(e Decimal) Decimal {
+	scale := max(d.scale, e.scale)
+	return Decimal{unscaled: new(big.Int).Add(d.rescale(scale), e.rescale(scale)), scale: scale}
+}
+
+// Sub returns d - e.
+func (d Decimal) Sub(e Decimal) Decimal {
+	scale := max(d.scale, e.scale)
+	return Decimal{unscaled: new(big.Int).Sub(d.rescale(scale), e.rescale(scale)), scale: scale}
+}
+
+// Mul returns d × e exactly.
+func (d Decimal) Mul(e Decimal) Decimal {
+	return Decimal{unscaled: new(big.Int).Mul(d.int(), e.int()), scale: d.scale + e.scale}
+}
+
+// Div returns d / e rounded half-even to DivisionPrecision places. It panics
+// if e is zero.
+func (d Decimal) Div(e Decimal) Decimal {
+	return d.DivRound(e, DivisionPrecision, RoundHalfEven)
+}
+
+// DivRound returns d / e rounded to scale places with mode. A negative scale
+// rounds to a multiple of 10^-scale. It panics if e is zero.
+func (d Decimal) DivRound(e Decimal, scale int32, mode RoundingMode) Decimal {
+	if e.IsZero() {
+		panic("sbee: decimal division by zero")
+	}
+	// d/e at scale s is D·10^(se+s) / (E·10^sd).
+	num := new(big.Int).Set(d.int())
+	den := new(big.Int).Set(e.int())
+	if k := e.scale + scale - d.scale; k >= 0 {
+		num.Mul(num, pow10(k))
+	} else {
+		den.Mul(den, pow10(-k))
+	}
+	return normalize(roundQuo(num, den, mode), scale)
+}
+
+// Round returns d rounded to scale places with mode. When d has fewer
+// places it is padded with zeros, so Round also fixes the printed scale. A
+// negative scale rounds to a multiple of 10^-scale, e.g. Round(-1, ...) to
+// tens.
+func (d Decimal) Round(scale int32, mode RoundingMode) Decimal {
+	if scale >= d.scale {
+		return Decimal{unscaled: d.rescale(scale), scale: scale}
+	}
+	return normalize(roundQuo(d.int(), pow10(d.scale-scale), mode), scale)
+}
+
+// Truncate returns d with the digits past scale dropped.
+func (d Decimal) Truncate(scale int32) Decimal {
+	return d.Round(scale, RoundDown)
+}
+
+// RoundStep returns d rounded to a multiple of step with mode, e.g. a price
+// to its tick size. A zero step returns d unchanged.
+func (d Decimal) RoundStep(step Decimal, mode RoundingMode) Decimal {
+	if step.IsZero() {
+		return d
+	}
+	return d.DivRound(step, 0, mode).Mul(step)
+}
+
+// Cmp returns -1, 0 or +1 as d is less than, equal to or greater than e.
+func (d Decimal) Cmp(e Decimal) int {
+	scale := max(d.scale, e.scale)
+	return d.rescale(scale).Cmp(e.rescale(scale))
+}
+
+// Equal reports whether d and e have the same value, whatever their scales.
+func (d Decimal) Equal(e Decimal) bool { return d.Cmp(e) == 0 }
+
+// LessThan reports whether d < e.
+func (d Decimal) LessThan(e Decimal) bool { return d.Cmp(e) < 0 }
+
+// LessThanOrEqual reports whether d <= e.
+func (d Decimal) LessThanOrEqual(e Decimal) bool { return d.Cmp(e) <= 0 }
+
+// GreaterThan reports whether d > e.
+func (d Decimal) GreaterThan(e Decimal) bool { return d.Cmp(e) > 0 }
+
+// GreaterThanOrEqual reports whether d >= e.
+func (d Decimal) GreaterThanOrEqual(e Decimal) bool { return d.Cmp(e) >= 0 }
+
+// IsInteger reports whether d has no fractional part.
+func (d Decimal) IsInteger() bool {
+	if d.scale == 0 {
+		return true
+	}
+	return new(big.Int).Rem(d.int(), pow10(d.scale)).Sign() == 0
+}
+
+// Float64 returns the nearest float64 to d, for statistics and display only.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String formats d in plain notation, keeping its scale: "0.00500" stays
+// "0.00500".
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.int()).String()
+	sign := ""
+	if d.Sign() < 0 {
+		sign = "-"
+	}
+	if d.scale == 0 {
+		return sign + digits
+	}
+	if n := int(d.scale) + 1 - len(digits); n > 0 {
+		digits = strings.Repeat("0", n) + digits
+	}
+	i := len(digits) - int(d.scale)
+	return sign + digits[:i] + "." + digits[i:]
+}
+
+// StringFixed formats d rounded half-up to exactly places decimal places.
+func (d Decimal) StringFixed(places int32) string {
+	return d.Round(places, RoundHalfUp).String()
+}
+
+// MarshalJSON encodes d as a bare JSON number.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON decodes a JSON number or numeric string. null and "" decode
+// to 0.
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" {
+		*d = Decimal{}
+		return nil
+	}
+	if unq, err := strconv.Unquote(s); err == nil {
+		s = unq
+	}
+	if s == "" {
+		*d = Decimal{}
+		return nil
+	}
+	v, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Decimal) UnmarshalText(b []byte) error {
+	v, err := ParseDecimal(string(b))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// MinDecimal returns the smallest of its arguments.
+func MinDecimal(first Decimal, rest ...Decimal) Decimal {
+	m := first
+	for _, d := range rest {
+		if d.LessThan(m) {
+			m = d
+		}
+	}
+	return m
+}
+
+// MaxDecimal returns the largest of its arguments.
+func MaxDecimal(first Decimal, rest ...Decimal) Decimal {
+	m := first
+	for _, d := range rest {
+		if d.GreaterThan(m) {
+			m = d
+		}
+	}
+	return m
+}
+
+// roundQuo returns num/den rounded to an integer with mode.
+func roundQuo(num, den *big.Int, mode RoundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+	sign := int64(num.Sign() * den.Sign())
+	// half compares the remainder with half the divisor: 2|r| vs |den|.
+	half := new(big.Int).Abs(r)
+	half.Lsh(half, 1)
+	cmp := half.Cmp(new(big.Int).Abs(den))
+
+	var away bool
+	switch mode {
+	case RoundDown:
+		away = false
+	case RoundUp:
+		away = true
+	case RoundFloor:
+		away = sign < 0
+	case RoundCeiling:
+		away = sign > 0
+	case RoundHalfUp:
+		away = cmp >= 0
+	case RoundHalfDown:
+		away = cmp > 0
+	case RoundHalfEven:
+		away = cmp > 0 || (cmp == 0 && q.Bit(0) == 1)
+	default:
+		panic(errors.New("sbee: unknown rounding mode " + mode.String()))
+	}
+	if away {
+		q.Add(q, big.NewInt(sign))
+	}
+	return q
+}
+
+var pow10Cache [32]*big.Int
+
+func init() {
+	p := big.NewInt(1)
+	for i := range pow10Cache {
+		pow10Cache[i] = new(big.Int).Set(p)
+		p.Mul(p, big.NewInt(10))
+	}
+}
+
+// pow10 returns 10^n. The result must not be modified.
+func pow10(n int32) *big.Int {
+	if int(n) < len(pow10Cache) {
+		return pow10Cache[n]
+	}
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,116 @@
+package sbee
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"42014", "42014"},
+		{"0.00500", "0.00500"},
+		{"-0.00013", "-0.00013"},
+		{"+1.5", "1.5"},
+		{".5", "0.5"},
+		{"7.", "7"},
+		{"1.5e-3", "0.0015"},
+		{"2.5E2", "250"},
+	}
+	for _, tt := range tests {
+		got, err := ParseDecimal(tt.in)
+		if err != nil {
+			t.Errorf("ParseDecimal(%q): %v", tt.in, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("ParseDecimal(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "-", ".", "1.2.3", "abc", "1e", "0x10", "1e2000000000", "1e-2000000000", "1e1001"} {
+		if _, err := ParseDecimal(bad); err == nil {
+			t.Errorf("ParseDecimal(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	if got := d("0.1").Add(d("0.2")); got.String() != "0.3" {
+		t.Errorf("0.1+0.2 = %s", got)
+	}
+	if got := d("1").Sub(d("0.999")); got.String() != "0.001" {
+		t.Errorf("1-0.999 = %s", got)
+	}
+	if got := d("42014.5").Mul(d("0.005")); got.String() != "210.0725" {
+		t.Errorf("42014.5*0.005 = %s", got)
+	}
+	if got := d("1").Div(d("3")); got.String() != "0.3333333333333333" {
+		t.Errorf("1/3 = %s", got)
+	}
+	if got := d("10").DivRound(d("4"), 0, RoundHalfEven); got.String() != "2" {
+		t.Errorf("10/4 half-even = %s", got)
+	}
+	if !d("1.50").Equal(d("1.5")) || d("-1").Cmp(d("0")) != -1 {
+		t.Error("comparison ignores scale incorrectly")
+	}
+	if got := DecimalFromFloat(0.1); got.String() != "0.1" {
+		t.Errorf("DecimalFromFloat(0.1) = %s", got)
+	}
+	var zero Decimal
+	if !zero.IsZero() || zero.String() != "0" || zero.Add(d("2")).String() != "2" {
+		t.Error("zero Decimal is not usable as 0")
+	}
+}
+
+func TestDecimalRounding(t *testing.T) {
+	tests := []struct {
+		in   string
+		mode RoundingMode
+		want string
+	}{
+		{"2.345", RoundHalfUp, "2.35"},
+		{"2.345", RoundHalfEven, "2.34"},
+		{"2.355", RoundHalfEven, "2.36"},
+		{"2.345", RoundHalfDown, "2.34"},
+		{"2.349", RoundDown, "2.34"},
+		{"2.341", RoundUp, "2.35"},
+		{"-2.341", RoundFloor, "-2.35"},
+		{"-2.349", RoundCeiling, "-2.34"},
+		{"-2.345", RoundHalfUp, "-2.35"},
+		{"2.3", RoundHalfUp, "2.30"},
+	}
+	for _, tt := range tests {
+		if got := d(tt.in).Round(2, tt.mode); got.String() != tt.want {
+			t.Errorf("Round(%s, 2, %v) = %s, want %s", tt.in, tt.mode, got, tt.want)
+		}
+	}
+	if got := d("16003.7").RoundStep(d("0.5"), RoundDown); got.String() != "16003.5" {
+		t.Errorf("RoundStep to 0.5 = %s", got)
+	}
+	if got := d("123").Round(-1, RoundHalfUp); got.String() != "120" || got.Scale() != 0 {
+		t.Errorf("Round(123, -1) = %s at scale %d", got, got.Scale())
+	}
+	if got := d("1250").DivRound(d("1"), -2, RoundHalfEven); got.String() != "1200" {
+		t.Errorf("DivRound(1250, 1, -2) = %s", got)
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	var v struct {
+		A, B, C, D Decimal
+	}
+	if err := json.Unmarshal([]byte(`{"A":"41994.11000000","B":0.005,"C":null,"D":""}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A.String() != "41994.11000000" || v.B.String() != "0.005" || !v.C.IsZero() || !v.D.IsZero() {
+		t.Errorf("decoded %+v", v)
+	}
+	out, err := json.Marshal(struct{ Price Decimal }{d("0.1").Add(d("0.2"))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"Price":0.3}` {
+		t.Errorf("encoded %s", out)
+	}
+}
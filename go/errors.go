@@ -0,0 +1,152 @@
+package sbee
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors matched by APIError.Is, for use with errors.Is.
+var (
+	ErrUnauthorized        = errors.New("sbee: unauthorized")
+	ErrRateLimited         = errors.New("sbee: rate limited")
+	ErrInsufficientBalance = errors.New("sbee: insufficient balance")
+	ErrInvalidSymbol       = errors.New("sbee: invalid symbol")
+	ErrOrderNotFound       = errors.New("sbee: order not found")
+
+	// ErrInvalidOrder matches an *OrderError, an order rejected before it
+	// was sent.
+	ErrInvalidOrder = errors.New("sbee: invalid order")
+)
+
+// ErrorKind tells which stage of a call an APIError comes from.
+type ErrorKind int
+
+const (
+	// KindAPI is a response the API rejected, by HTTP status or with
+	// isSuccess false.
+	KindAPI ErrorKind = iota
+	// KindTransport is a request that never produced a response, e.g. a
+	// network failure or a cancelled context.
+	KindTransport
+	// KindDecode is a response body that could not be decoded.
+	KindDecode
+	// KindCredentials is a request naming an Account whose keys the
+	// CredentialProvider could not give; it was not sent. Err is the
+	// provider's error, which errors.Is matches against ErrNoCredentials.
+	KindCredentials
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case KindAPI:
+		return "api"
+	case KindTransport:
+		return "transport"
+	case KindDecode:
+		return "decode"
+	case KindCredentials:
+		return "credentials"
+	}
+	return fmt.Sprintf("ErrorKind(%d)", int(k))
+}
+
+// APIError is the error returned by every SbeeRest method. Use errors.As to
+// inspect it, or errors.Is with the Err* sentinels to classify it.
+type APIError struct {
+	Kind ErrorKind
+	// StatusCode is the HTTP status, or 0 for transport failures.
+	StatusCode int
+	// Code and Message are the sbee errorCode and message, which for
+	// exchange failures carry the exchange's own code (e.g. "-2014").
+	Code    string
+	Message string
+
+	Exchange  string
+	Endpoint  string
+	RequestID string
+
+	// ClientOrderID is the ClientOrderId, given or generated, of the order a
+	// failed placement sent, and ClientOrderIDs are those of the orders of a
+	// failed batch, in order. After a timeout or a 5xx the orders may still
+	// have been placed; look them up by these ids.
+	ClientOrderID  string
+	ClientOrderIDs []string
+
+	// Err is the underlying transport, decode or credentials error, if any.
+	Err error
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	b.WriteString("sbee: ")
+	b.WriteString(e.Endpoint)
+	if e.Exchange != "" {
+		b.WriteString(" on ")
+		b.WriteString(e.Exchange)
+	}
+	switch {
+	case e.Kind == KindTransport:
+		fmt.Fprintf(&b, ": %v", e.Err)
+	case e.Kind == KindDecode:
+		fmt.Fprintf(&b, ": decode response: %v", e.Err)
+	case e.Kind == KindCredentials:
+		fmt.Fprintf(&b, ": %v", e.Err)
+	default:
+		if e.StatusCode != 0 && e.StatusCode != http.StatusOK {
+			fmt.Fprintf(&b, ": %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+		}
+		if e.Message != "" {
+			b.WriteString(": ")
+			b.WriteString(e.Message)
+		} else if e.StatusCode == 0 || e.StatusCode == http.StatusOK {
+			b.WriteString(": request was not successful")
+		}
+		if e.Code != "" {
+			fmt.Fprintf(&b, " (code %s)", e.Code)
+		}
+	}
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, " [request %s]", e.RequestID)
+	}
+	return b.String()
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether e matches one of the Err* sentinels, by HTTP status,
+// by sbee or exchange error code, or by message.
+func (e *APIError) Is(target error) bool {
+	if e.Kind != KindAPI {
+		return false
+	}
+	msg := strings.ToLower(e.Message)
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden ||
+			unauthorizedCodes[e.Code] || strings.Contains(msg, "api-key") || strings.Contains(msg, "permission")
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests || rateLimitedCodes[e.Code] ||
+			strings.Contains(msg, "too many requests") || strings.Contains(msg, "rate limit")
+	case ErrInsufficientBalance:
+		return insufficientBalanceCodes[e.Code] || strings.Contains(msg, "insufficient")
+	case ErrInvalidSymbol:
+		return invalidSymbolCodes[e.Code] || strings.Contains(msg, "symbol does not exist") || strings.Contains(msg, "invalid symbol")
+	case ErrOrderNotFound:
+		return orderNotFoundCodes[e.Code] || strings.Contains(msg, "order does not exist") || strings.Contains(msg, "order not found")
+	}
+	return false
+}
+
+// Error codes known to mean each sentinel. sbee passes through the exchange
+// code when an exchange rejects a call, so Binance-style codes appear too.
+var (
+	unauthorizedCodes        = map[string]bool{"1055": true, "-2014": true, "-2015": true, "-1022": true}
+	rateLimitedCodes         = map[string]bool{"-1003": true, "-1015": true}
+	insufficientBalanceCodes = map[string]bool{"-2010": true, "-2019": true}
+	invalidSymbolCodes       = map[string]bool{"1014": true, "-1121": true}
+	orderNotFoundCodes       = map[string]bool{"-2011": true, "-2013": true}
+)
@@ -0,0 +1,79 @@
+package sbee
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		reply    string
+		sentinel error
+		code     string
+	}{
+		{"http 401", http.StatusUnauthorized, `{"isSuccess":false,"message":"Unauthorized"}`, ErrUnauthorized, ""},
+		{"http 429", http.StatusTooManyRequests, `Too Many Requests`, ErrRateLimited, ""},
+		{"api key rejected", http.StatusOK, `{"isSuccess":false,"resultType":1,"message":"API-key format invalid.","errorCode":"-2014"}`, ErrUnauthorized, "-2014"},
+		{"unknown symbol", http.StatusOK, `{"isSuccess":false,"message":"(SMSG) Specified symbol does not exist!","errorCode":"1014"}`, ErrInvalidSymbol, "1014"},
+		{"insufficient balance", http.StatusOK, `{"isSuccess":false,"message":"Account has insufficient balance for requested action.","errorCode":"-2010"}`, ErrInsufficientBalance, "-2010"},
+		{"unknown order", http.StatusOK, `{"isSuccess":false,"message":"Unknown order sent.","errorCode":"-2011"}`, ErrOrderNotFound, "-2011"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Request-Id", "req-1")
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.reply)
+			}))
+			defer srv.Close()
+
+			s := NewClient("token", WithBaseURL(srv.URL), WithRetryPolicy(RetryPolicy{}))
+			_, err := s.OrderBook(context.Background(), "Binance", "Spot", "BTC-USDT", 20)
+			if !errors.Is(err, tt.sentinel) {
+				t.Fatalf("errors.Is(%v, %v) = false", err, tt.sentinel)
+			}
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("error %T is not an *APIError", err)
+			}
+			if apiErr.Kind != KindAPI || apiErr.StatusCode != tt.status || apiErr.Code != tt.code {
+				t.Errorf("APIError = %+v", apiErr)
+			}
+			if apiErr.Exchange != "Binance" || apiErr.Endpoint != "OrderBook" || apiErr.RequestID != "req-1" {
+				t.Errorf("APIError context = %q %q %q", apiErr.Exchange, apiErr.Endpoint, apiErr.RequestID)
+			}
+			for _, other := range []error{ErrUnauthorized, ErrRateLimited, ErrInsufficientBalance, ErrInvalidSymbol, ErrOrderNotFound} {
+				if other != tt.sentinel && errors.Is(err, other) {
+					t.Errorf("error also matches %v", other)
+				}
+			}
+		})
+	}
+}
+
+func TestTransportAndDecodeErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<html>bad gateway</html>`)
+	}))
+	defer srv.Close()
+	s := NewClient("token", WithBaseURL(srv.URL))
+
+	var apiErr *APIError
+	_, err := s.Tickers(context.Background(), "OKX", "Spot", "BTC-USDT")
+	if !errors.As(err, &apiErr) || apiErr.Kind != KindDecode {
+		t.Errorf("HTML body: got %v, want a decode APIError", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = s.Tickers(ctx, "OKX", "Spot", "BTC-USDT")
+	if !errors.As(err, &apiErr) || apiErr.Kind != KindTransport || !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled context: got %v, want a transport APIError wrapping context.Canceled", err)
+	}
+}
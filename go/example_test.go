@@ -0,0 +1,24 @@
+package sbee_test
+
+import (
+	"context"
+	"fmt"
+
+	sbee "github.com/sbeeIO/sdk/go"
+)
+
+func ExampleAccount_PlaceBatchLimitOrders() {
+	client := sbee.NewClient("token", sbee.WithCredentialProvider(sbee.EnvProvider{}))
+	orders := []sbee.BatchLimitOrder{
+		{Symbol: "BTC-USDT", ClientOrderID: "ID5501", Price: sbee.DecimalFromInt(20000), BaseQuantity: sbee.MustParseDecimal("0.005"), Side: sbee.SideBuy},
+		{Symbol: "BTC-USDT", ClientOrderID: "ID5502", Price: sbee.DecimalFromInt(19900), BaseQuantity: sbee.MustParseDecimal("0.005"), Side: sbee.SideBuy},
+	}
+	resp, err := client.Account("main").PlaceBatchLimitOrders(context.Background(), "Binance", "Spot", orders)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, r := range resp.Data {
+		fmt.Printf("%+v\n", r)
+	}
+}
@@ -0,0 +1,29 @@
+// Command basic shows the minimal use of the sbee client: it reads a bearer
+// token from SBEE_TOKEN and prints the money pair values.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	sbee "github.com/sbeeIO/sdk/go"
+)
+
+func main() {
+	sbeeRest := sbee.NewClient(os.Getenv("SBEE_TOKEN"),
+		sbee.WithTimeout(30*time.Second),
+		sbee.WithDefaultExchange("Binance"),
+		sbee.WithDefaultTrade("Spot"),
+	)
+
+	result, err := sbeeRest.MoneyPairValues(context.Background())
+	if err != nil {
+		fmt.Println("MoneyPairValues Error:", err)
+		return
+	}
+	for _, pair := range result.Data {
+		fmt.Printf("%s/%s = %s\n", pair.BaseCurrency, pair.QuoteCurrency, pair.Value)
+	}
+}
@@ -0,0 +1,133 @@
+package sbee
+
+import "fmt"
+
+// Formation names understood by KlineFormation and NewIndicator.
+const (
+	FormationSMA       = "SMA"
+	FormationEMA       = "EMA"
+	FormationRSI       = "RSI"
+	FormationMACD      = "MACD"
+	FormationDX        = "DX"
+	FormationADX       = "ADX"
+	FormationBollinger = "BBANDS"
+	FormationATR       = "ATR"
+	FormationMAX       = "MAX"
+	FormationMIN       = "MIN"
+)
+
+// Source is the candle value an indicator reads.
+type Source string
+
+// Candle sources. The composite ones average several prices: hl2 is
+// (high+low)/2, hlc3 adds the close and ohlc4 the open.
+const (
+	SourceOpen   Source = "open"
+	SourceHigh   Source = "high"
+	SourceLow    Source = "low"
+	SourceClose  Source = "close"
+	SourceVolume Source = "volume"
+	SourceHL2    Source = "hl2"
+	SourceHLC3   Source = "hlc3"
+	SourceOHLC4  Source = "ohlc4"
+)
+
+// Formation is one indicator requested from KlineFormation, or computed
+// locally with NewIndicator. Build it with the constructors below, which
+// set the fields each formation uses.
+type Formation struct {
+	Name         string  `json:"Formation"`
+	TimePeriod   int     `json:"TimePeriod,omitempty"`
+	FastPeriod   int     `json:"FastPeriod,omitempty"`
+	SlowPeriod   int     `json:"SlowPeriod,omitempty"`
+	SignalPeriod int     `json:"SignalPeriod,omitempty"`
+	NbDevUp      float64 `json:"NbDevUp,omitempty"`
+	NbDevDn      float64 `json:"NbDevDn,omitempty"`
+	Source       Source  `json:"Source,omitempty"`
+}
+
+// SMAFormation is the simple moving average of source over period candles.
+func SMAFormation(period int, source Source) Formation {
+	return Formation{Name: FormationSMA, TimePeriod: period, Source: source}
+}
+
+// EMAFormation is the exponential moving average of source over period
+// candles.
+func EMAFormation(period int, source Source) Formation {
+	return Formation{Name: FormationEMA, TimePeriod: period, Source: source}
+}
+
+// RSIFormation is Wilder's relative strength index of source.
+func RSIFormation(period int, source Source) Formation {
+	return Formation{Name: FormationRSI, TimePeriod: period, Source: source}
+}
+
+// MACDFormation is the moving average convergence divergence of source.
+func MACDFormation(fast, slow, signal int, source Source) Formation {
+	return Formation{Name: FormationMACD, FastPeriod: fast, SlowPeriod: slow, SignalPeriod: signal, Source: source}
+}
+
+// DXFormation is the directional movement index.
+func DXFormation(period int) Formation {
+	return Formation{Name: FormationDX, TimePeriod: period}
+}
+
+// ADXFormation is the average directional movement index.
+func ADXFormation(period int) Formation {
+	return Formation{Name: FormationADX, TimePeriod: period}
+}
+
+// BollingerFormation is the Bollinger bands of source: its moving average
+// plus and minus dev standard deviations.
+func BollingerFormation(period int, dev float64, source Source) Formation {
+	return Formation{Name: FormationBollinger, TimePeriod: period, NbDevUp: dev, NbDevDn: dev, Source: source}
+}
+
+// ATRFormation is the average true range.
+func ATRFormation(period int) Formation {
+	return Formation{Name: FormationATR, TimePeriod: period}
+}
+
+// MaxFormation is the highest source over period candles.
+func MaxFormation(period int, source Source) Formation {
+	return Formation{Name: FormationMAX, TimePeriod: period, Source: source}
+}
+
+// MinFormation is the lowest source over period candles.
+func MinFormation(period int, source Source) Formation {
+	return Formation{Name: FormationMIN, TimePeriod: period, Source: source}
+}
+
+// Validate reports a formation with an unknown name, source or a missing
+// period.
+func (f Formation) Validate() error {
+	if reason := f.invalid(); reason != "" {
+		return fmt.Errorf("sbee: formation %s: %s", f.Name, reason)
+	}
+	return nil
+}
+
+// invalid returns why f is not valid, or "".
+func (f Formation) invalid() string {
+	switch f.Source {
+	case "", SourceOpen, SourceHigh, SourceLow, SourceClose, SourceVolume, SourceHL2, SourceHLC3, SourceOHLC4:
+	default:
+		return fmt.Sprintf("unknown source %q", f.Source)
+	}
+	switch f.Name {
+	case FormationMACD:
+		if f.FastPeriod <= 0 || f.SlowPeriod <= 0 || f.SignalPeriod <= 0 {
+			return fmt.Sprintf("periods %d, %d, %d must be positive", f.FastPeriod, f.SlowPeriod, f.SignalPeriod)
+		}
+		if f.FastPeriod >= f.SlowPeriod {
+			return fmt.Sprintf("fast period %d must be below slow period %d", f.FastPeriod, f.SlowPeriod)
+		}
+	case FormationSMA, FormationEMA, FormationRSI, FormationDX, FormationADX, FormationBollinger, FormationATR, FormationMAX, FormationMIN:
+		if f.TimePeriod <= 0 {
+			return fmt.Sprintf("period %d must be positive", f.TimePeriod)
+		}
+	default:
+		return "unknown formation"
+	}
+	return ""
+}
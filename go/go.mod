@@ -0,0 +1,3 @@
+module github.com/sbeeIO/sdk/go
+
+go 1.22
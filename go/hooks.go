@@ -0,0 +1,190 @@
+package sbee
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// RequestInfo describes one attempt of an API call to the hooks set with
+// WithHooks. The hooks of an attempt all see the same *RequestInfo.
+type RequestInfo struct {
+	Method   string
+	Endpoint string
+	Exchange string
+	Trade    string
+	// Symbol is the call's symbol, or "" for calls without one and for
+	// batches spanning several symbols.
+	Symbol string
+	// Attempt counts the attempts of the call, from 1. Retries made under the
+	// client's RetryPolicy are further attempts.
+	Attempt int
+
+	// Header is sent with the request; BeforeRequest may add to it, e.g. a
+	// correlation ID. It overrides the SDK's own headers of the same name.
+	Header http.Header
+
+	// StatusCode, RequestID and Latency are set once the attempt ends.
+	// StatusCode is 0 when no response was received. Latency runs from
+	// sending the request, after any wait for the rate limiter, to reading
+	// the whole response.
+	StatusCode int
+	RequestID  string
+	Latency    time.Duration
+}
+
+// Hooks are called around every attempt of every API call, e.g. to log,
+// audit or measure them. Any of them may be nil.
+type Hooks struct {
+	// BeforeRequest is called before the request is sent. The context it
+	// returns, if not nil, is the one the request is sent with and passed to
+	// the later hooks, e.g. to carry a trace span.
+	BeforeRequest func(ctx context.Context, info *RequestInfo) context.Context
+	// AfterResponse is called after an attempt that succeeded.
+	AfterResponse func(ctx context.Context, info *RequestInfo)
+	// OnError is called after an attempt that failed, with the *APIError it
+	// failed with. Errors never hold the token or the request's keys.
+	OnError func(ctx context.Context, info *RequestInfo, err error)
+}
+
+// WithHooks adds h to the client's hooks. BeforeRequest hooks are called in
+// the order they were added, AfterResponse and OnError hooks in the reverse
+// order, so that each pair nests like middleware.
+func WithHooks(h Hooks) Option {
+	return func(s *SbeeRest) {
+		s.hooks = append(s.hooks, h)
+	}
+}
+
+// WithLogger logs every attempt of every API call to l: successful attempts
+// at debug level, failed ones at warn level, with the method, endpoint,
+// exchange, trade, symbol, attempt, status, latency and request ID. Nothing
+// secret is logged.
+func WithLogger(l *slog.Logger) Option {
+	if l == nil {
+		return func(*SbeeRest) {}
+	}
+	return WithHooks(Hooks{
+		AfterResponse: func(ctx context.Context, info *RequestInfo) {
+			l.LogAttrs(ctx, slog.LevelDebug, "sbee request", info.attrs()...)
+		},
+		OnError: func(ctx context.Context, info *RequestInfo, err error) {
+			l.LogAttrs(ctx, slog.LevelWarn, "sbee request failed", append(info.attrs(), slog.Any("error", err))...)
+		},
+	})
+}
+
+// attrs returns the log attributes of info.
+func (info *RequestInfo) attrs() []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String("method", info.Method),
+		slog.String("endpoint", info.Endpoint),
+		slog.String("exchange", info.Exchange),
+		slog.String("trade", info.Trade),
+		slog.String("symbol", info.Symbol),
+		slog.Int("attempt", info.Attempt),
+		slog.Int("status", info.StatusCode),
+		slog.Duration("latency", info.Latency),
+	}
+	if info.RequestID != "" {
+		attrs = append(attrs, slog.String("request_id", info.RequestID))
+	}
+	return attrs
+}
+
+// info returns the RequestInfo of attempt of r.
+func (r *request) info(attempt int) *RequestInfo {
+	return &RequestInfo{
+		Method:   r.method,
+		Endpoint: r.endpoint,
+		Exchange: r.exchange,
+		Trade:    r.trade,
+		Symbol:   r.symbol(),
+		Attempt:  attempt,
+		Header:   make(http.Header),
+	}
+}
+
+// beforeRequest runs the BeforeRequest hooks and returns the context to send
+// the request with.
+func (s *SbeeRest) beforeRequest(ctx context.Context, info *RequestInfo) context.Context {
+	for _, h := range s.hooks {
+		if h.BeforeRequest != nil {
+			if next := h.BeforeRequest(ctx, info); next != nil {
+				ctx = next
+			}
+		}
+	}
+	return ctx
+}
+
+// afterRequest runs the AfterResponse or OnError hooks, by err.
+func (s *SbeeRest) afterRequest(ctx context.Context, info *RequestInfo, err error) {
+	for i := len(s.hooks) - 1; i >= 0; i-- {
+		h := s.hooks[i]
+		switch {
+		case err == nil && h.AfterResponse != nil:
+			h.AfterResponse(ctx, info)
+		case err != nil && h.OnError != nil:
+			h.OnError(ctx, info, err)
+		}
+	}
+}
+
+// symbol returns the symbol r is about, or "" if it has none or several.
+func (r *request) symbol() string {
+	if v := r.query.Get("symbol"); v != "" {
+		return v
+	}
+	switch b := r.body.(type) {
+	case TradingBalancesRequest:
+		return b.Symbol
+	case OrderHistoryRequest:
+		return b.Symbol
+	case KlineFormationRequest:
+		return b.Symbol
+	case PlaceLimitOrderRequest:
+		return b.Symbol
+	case PlaceMarketOrderRequest:
+		return b.Symbol
+	case StopOrderRequest:
+		return b.Symbol
+	case SetLeverageRequest:
+		return b.Symbol
+	case CancelOrderRequest:
+		return b.Symbol
+	case CancelOrdersBySymbolRequest:
+		return b.Symbol
+	case MultiMarketRequest:
+		return b.Symbol
+	case CancelBatchOrdersRequest:
+		return commonSymbol(b.Orders, func(o BatchCancelOrder) string { return o.Symbol })
+	case PlaceBatchLimitOrdersRequest:
+		return commonSymbol(b.Orders, func(o BatchLimitOrder) string { return o.Symbol })
+	case PlaceBatchMarketOrdersRequest:
+		return commonSymbol(b.Orders, func(o BatchMarketOrder) string { return o.Symbol })
+	case []LimitOrderForPeople:
+		return commonSymbol(b, func(o LimitOrderForPeople) string { return o.Symbol })
+	case []MarketOrderForPeople:
+		return commonSymbol(b, func(o MarketOrderForPeople) string { return o.Symbol })
+	case []CancelOrderForPeople:
+		return commonSymbol(b, func(o CancelOrderForPeople) string { return o.Symbol })
+	case []BalanceForPeople:
+		return commonSymbol(b, func(o BalanceForPeople) string { return o.Symbol })
+	}
+	return ""
+}
+
+// commonSymbol returns the symbol shared by all of items, or "".
+func commonSymbol[T any](items []T, symbol func(T) string) string {
+	var out string
+	for i, item := range items {
+		v := symbol(item)
+		if i > 0 && v != out {
+			return ""
+		}
+		out = v
+	}
+	return out
+}
@@ -0,0 +1,121 @@
+package sbee
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	type ctxKey struct{}
+	calls, correlation := 0, ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		correlation = r.Header.Get("X-Correlation-Id")
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("X-Request-Id", "req-2")
+		io.WriteString(w, `{"isSuccess":true,"data":{}}`)
+	}))
+	defer srv.Close()
+
+	var events []string
+	hooks := func(name string) Hooks {
+		return Hooks{
+			BeforeRequest: func(ctx context.Context, info *RequestInfo) context.Context {
+				events = append(events, fmt.Sprintf("%s before %d", name, info.Attempt))
+				info.Header.Set("X-Correlation-Id", "corr-1")
+				return context.WithValue(ctx, ctxKey{}, name)
+			},
+			AfterResponse: func(ctx context.Context, info *RequestInfo) {
+				events = append(events, fmt.Sprintf("%s after %d %d %s %v", name, info.Attempt, info.StatusCode, info.RequestID, ctx.Value(ctxKey{})))
+			},
+			OnError: func(ctx context.Context, info *RequestInfo, err error) {
+				events = append(events, fmt.Sprintf("%s error %d %d", name, info.Attempt, info.StatusCode))
+			},
+		}
+	}
+	retry := DefaultRetryPolicy()
+	retry.InitialBackoff = 0
+	s := NewClient("token", WithBaseURL(srv.URL), WithRetryPolicy(retry), WithHooks(hooks("a")), WithHooks(hooks("b")))
+
+	var info *RequestInfo
+	WithHooks(Hooks{AfterResponse: func(ctx context.Context, i *RequestInfo) { info = i }})(s)
+	if _, err := s.RecentTrades(context.Background(), "Binance", "Spot", "BTC-USDT", "20"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"a before 1", "b before 1", "b error 1 503", "a error 1 503",
+		"a before 2", "b before 2", "b after 2 200 req-2 b", "a after 2 200 req-2 b",
+	}
+	if fmt.Sprint(events) != fmt.Sprint(want) {
+		t.Errorf("events\n%q\nwant\n%q", events, want)
+	}
+	if correlation != "corr-1" {
+		t.Errorf("X-Correlation-Id = %q", correlation)
+	}
+	if info.Method != "GET" || info.Endpoint != "RecentTrades" || info.Exchange != "Binance" || info.Trade != "Spot" ||
+		info.Symbol != "BTC-USDT" || info.Latency <= 0 {
+		t.Errorf("info = %+v", info)
+	}
+}
+
+func TestRequestSymbol(t *testing.T) {
+	for _, tc := range []struct {
+		body any
+		want string
+	}{
+		{PlaceLimitOrderRequest{Symbol: "BTC-USDT"}, "BTC-USDT"},
+		{[]LimitOrderForPeople{{Symbol: "BTC-USDT"}, {Symbol: "BTC-USDT"}}, "BTC-USDT"},
+		{PlaceBatchMarketOrdersRequest{Orders: []BatchMarketOrder{{Symbol: "BTC-USDT"}, {Symbol: "ETH-USDT"}}}, ""},
+		{nil, ""},
+	} {
+		if got := (&request{body: tc.body}).symbol(); got != tc.want {
+			t.Errorf("symbol of %T = %q, want %q", tc.body, got, tc.want)
+		}
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		io.WriteString(w, `{"isSuccess":true,"data":[]}`)
+	}))
+	defer srv.Close()
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	s := NewClient(secretToken, WithBaseURL(srv.URL), WithRetryPolicy(RetryPolicy{}), WithLogger(logger))
+
+	ctx := context.Background()
+	s.TradingBalances(ctx, "Binance", "Spot", "BTC-USDT", secretKeys.APIKey, secretKeys.APISecret, secretKeys.APIPass)
+	status = http.StatusUnauthorized
+	s.TradingBalances(ctx, "Binance", "Spot", "BTC-USDT", secretKeys.APIKey, secretKeys.APISecret, secretKeys.APIPass)
+
+	checkNoSecrets(t, "log", buf.String())
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("logged %d lines:\n%s", len(lines), buf.String())
+	}
+	for i, want := range []struct {
+		level  string
+		status float64
+	}{{"DEBUG", 200}, {"WARN", 401}} {
+		var rec map[string]any
+		json.Unmarshal([]byte(lines[i]), &rec)
+		if rec["level"] != want.level || rec["status"] != want.status || rec["method"] != "POST" ||
+			rec["endpoint"] != "TradingBalances" || rec["exchange"] != "Binance" || rec["trade"] != "Spot" ||
+			rec["symbol"] != "BTC-USDT" || rec["attempt"] != 1.0 || rec["latency"] == nil {
+			t.Errorf("line %d: %s", i, lines[i])
+		}
+	}
+}
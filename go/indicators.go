@@ -0,0 +1,540 @@
+package sbee
+
+import (
+	"math"
+	"slices"
+)
+
+// Indicator is a technical indicator computed locally, one candle at a
+// time, from KLine history, a backtest or a Klines subscription. Indicators
+// use float64 arithmetic and are not safe for concurrent use.
+type Indicator interface {
+	// Update adds the next closed candle.
+	Update(k Kline)
+	// Ready reports whether enough candles have been added for Values.
+	Ready() bool
+	// Values returns the current outputs, main output first, or nil before
+	// Ready.
+	Values() []float64
+	// Clone returns an independent copy of the indicator.
+	Clone() Indicator
+}
+
+// NewIndicator returns the local implementation of f. A formation without a
+// source reads the close, and Bollinger bands without deviations use 2.
+func NewIndicator(f Formation) (Indicator, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	src := f.Source
+	if src == "" {
+		src = SourceClose
+	}
+	switch f.Name {
+	case FormationSMA:
+		return NewSMA(f.TimePeriod, src), nil
+	case FormationEMA:
+		return NewEMA(f.TimePeriod, src), nil
+	case FormationRSI:
+		return NewRSI(f.TimePeriod, src), nil
+	case FormationMACD:
+		return NewMACD(f.FastPeriod, f.SlowPeriod, f.SignalPeriod, src), nil
+	case FormationDX:
+		return NewDX(f.TimePeriod), nil
+	case FormationADX:
+		return NewADX(f.TimePeriod), nil
+	case FormationBollinger:
+		b := NewBollinger(f.TimePeriod, 2, src)
+		if f.NbDevUp != 0 || f.NbDevDn != 0 {
+			b.up, b.down = f.NbDevUp, f.NbDevDn
+		}
+		return b, nil
+	case FormationATR:
+		return NewATR(f.TimePeriod), nil
+	case FormationMAX:
+		return NewMax(f.TimePeriod, src), nil
+	default: // FormationMIN
+		return NewMin(f.TimePeriod, src), nil
+	}
+}
+
+// ComputeFormations computes formations over ks locally, in the shape
+// KlineFormation returns: one series per formation holding its main output
+// for every candle from the first at which it is ready.
+func ComputeFormations(ks []Kline, formations ...Formation) ([]FormationSeries, error) {
+	out := make([]FormationSeries, 0, len(formations))
+	for _, f := range formations {
+		ind, err := NewIndicator(f)
+		if err != nil {
+			return nil, err
+		}
+		series := FormationSeries{Formation: f.Name, Values: []Decimal{}}
+		for _, k := range ks {
+			ind.Update(k)
+			if ind.Ready() {
+				series.Values = append(series.Values, DecimalFromFloat(ind.Values()[0]))
+			}
+		}
+		out = append(out, series)
+	}
+	return out, nil
+}
+
+func (s Source) value(k Kline) float64 {
+	switch s {
+	case SourceOpen:
+		return k.Open.Float64()
+	case SourceHigh:
+		return k.High.Float64()
+	case SourceLow:
+		return k.Low.Float64()
+	case SourceVolume:
+		return k.Volume.Float64()
+	case SourceHL2:
+		return (k.High.Float64() + k.Low.Float64()) / 2
+	case SourceHLC3:
+		return (k.High.Float64() + k.Low.Float64() + k.Close.Float64()) / 3
+	case SourceOHLC4:
+		return (k.Open.Float64() + k.High.Float64() + k.Low.Float64() + k.Close.Float64()) / 4
+	}
+	return k.Close.Float64()
+}
+
+// window holds the last n values added and their sum.
+type window struct {
+	vals  []float64
+	next  int
+	count int
+	sum   float64
+}
+
+func newWindow(n int) window {
+	return window{vals: make([]float64, n)}
+}
+
+func (w *window) add(v float64) {
+	if w.count == len(w.vals) {
+		w.sum -= w.vals[w.next]
+	} else {
+		w.count++
+	}
+	w.vals[w.next] = v
+	w.sum += v
+	w.next = (w.next + 1) % len(w.vals)
+}
+
+func (w *window) full() bool    { return w.count == len(w.vals) }
+func (w *window) mean() float64 { return w.sum / float64(len(w.vals)) }
+
+func (w window) clone() window {
+	w.vals = slices.Clone(w.vals)
+	return w
+}
+
+// smoother is a moving average seeded with the simple average of its first
+// n values, then updated as value += alpha * (v - value): 2/(n+1) for an
+// EMA, 1/n for Wilder's smoothing.
+type smoother struct {
+	n, count int
+	alpha    float64
+	value    float64
+}
+
+func newEMA(n int) smoother    { return smoother{n: n, alpha: 2 / float64(n+1)} }
+func newWilder(n int) smoother { return smoother{n: n, alpha: 1 / float64(n)} }
+
+func (s *smoother) add(v float64) {
+	s.count++
+	switch {
+	case s.count < s.n:
+		s.value += v
+	case s.count == s.n:
+		s.value = (s.value + v) / float64(s.n)
+	default:
+		s.value += s.alpha * (v - s.value)
+	}
+}
+
+func (s *smoother) ready() bool { return s.count >= s.n }
+
+// SMA is the simple moving average.
+type SMA struct {
+	source Source
+	w      window
+}
+
+// NewSMA returns the simple moving average of source over period candles.
+func NewSMA(period int, source Source) *SMA {
+	return &SMA{source: source, w: newWindow(period)}
+}
+
+func (s *SMA) Update(k Kline) { s.w.add(s.source.value(k)) }
+func (s *SMA) Ready() bool    { return s.w.full() }
+
+func (s *SMA) Values() []float64 {
+	if !s.Ready() {
+		return nil
+	}
+	return []float64{s.w.mean()}
+}
+
+func (s *SMA) Clone() Indicator {
+	c := *s
+	c.w = s.w.clone()
+	return &c
+}
+
+// EMA is the exponential moving average, seeded with the SMA of its first
+// period candles.
+type EMA struct {
+	source Source
+	s      smoother
+}
+
+// NewEMA returns the exponential moving average of source over period
+// candles.
+func NewEMA(period int, source Source) *EMA {
+	return &EMA{source: source, s: newEMA(period)}
+}
+
+func (e *EMA) Update(k Kline)   { e.s.add(e.source.value(k)) }
+func (e *EMA) Ready() bool      { return e.s.ready() }
+func (e *EMA) Clone() Indicator { c := *e; return &c }
+
+func (e *EMA) Values() []float64 {
+	if !e.Ready() {
+		return nil
+	}
+	return []float64{e.s.value}
+}
+
+// RSI is Wilder's relative strength index, from 0 to 100. It is 0 while
+// the source has not moved.
+type RSI struct {
+	source     Source
+	prev       float64
+	started    bool
+	gain, loss smoother
+}
+
+// NewRSI returns the relative strength index of source over period candles.
+// It is ready after period+1 candles.
+func NewRSI(period int, source Source) *RSI {
+	return &RSI{source: source, gain: newWilder(period), loss: newWilder(period)}
+}
+
+func (r *RSI) Update(k Kline) {
+	v := r.source.value(k)
+	if r.started {
+		r.gain.add(max(v-r.prev, 0))
+		r.loss.add(max(r.prev-v, 0))
+	}
+	r.prev, r.started = v, true
+}
+
+func (r *RSI) Ready() bool      { return r.gain.ready() }
+func (r *RSI) Clone() Indicator { c := *r; return &c }
+
+func (r *RSI) Values() []float64 {
+	if !r.Ready() {
+		return nil
+	}
+	return []float64{ratio(r.gain.value, r.gain.value+r.loss.value)}
+}
+
+// ratio returns 100*a/b, or 0 when b is 0.
+func ratio(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return 100 * a / b
+}
+
+// MACD is the moving average convergence divergence. Its values are the
+// MACD line, the signal line and their difference, the histogram.
+type MACD struct {
+	source             Source
+	fast, slow, signal smoother
+	line               float64
+}
+
+// NewMACD returns the MACD of source with the given EMA periods.
+func NewMACD(fast, slow, signal int, source Source) *MACD {
+	return &MACD{source: source, fast: newEMA(fast), slow: newEMA(slow), signal: newEMA(signal)}
+}
+
+func (m *MACD) Update(k Kline) {
+	v := m.source.value(k)
+	m.fast.add(v)
+	m.slow.add(v)
+	if m.slow.ready() {
+		m.line = m.fast.value - m.slow.value
+		m.signal.add(m.line)
+	}
+}
+
+func (m *MACD) Ready() bool      { return m.signal.ready() }
+func (m *MACD) Clone() Indicator { c := *m; return &c }
+
+func (m *MACD) Values() []float64 {
+	if !m.Ready() {
+		return nil
+	}
+	return []float64{m.line, m.signal.value, m.line - m.signal.value}
+}
+
+// ATR is Wilder's average true range.
+type ATR struct {
+	prev    Kline
+	started bool
+	tr      smoother
+}
+
+// NewATR returns the average true range over period candles. It is ready
+// after period+1 candles.
+func NewATR(period int) *ATR {
+	return &ATR{tr: newWilder(period)}
+}
+
+func (a *ATR) Update(k Kline) {
+	if a.started {
+		a.tr.add(trueRange(a.prev, k))
+	}
+	a.prev, a.started = k, true
+}
+
+func (a *ATR) Ready() bool      { return a.tr.ready() }
+func (a *ATR) Clone() Indicator { c := *a; return &c }
+
+func (a *ATR) Values() []float64 {
+	if !a.Ready() {
+		return nil
+	}
+	return []float64{a.tr.value}
+}
+
+func trueRange(prev, k Kline) float64 {
+	h, l, c := k.High.Float64(), k.Low.Float64(), prev.Close.Float64()
+	return max(h-l, math.Abs(h-c), math.Abs(l-c))
+}
+
+// directional tracks Wilder's smoothed directional movement and true range.
+type directional struct {
+	prev            Kline
+	started         bool
+	plus, minus, tr smoother
+}
+
+func newDirectional(n int) directional {
+	return directional{plus: newWilder(n), minus: newWilder(n), tr: newWilder(n)}
+}
+
+func (d *directional) add(k Kline) {
+	if d.started {
+		up := k.High.Float64() - d.prev.High.Float64()
+		down := d.prev.Low.Float64() - k.Low.Float64()
+		var plus, minus float64
+		if up > down && up > 0 {
+			plus = up
+		}
+		if down > up && down > 0 {
+			minus = down
+		}
+		d.plus.add(plus)
+		d.minus.add(minus)
+		d.tr.add(trueRange(d.prev, k))
+	}
+	d.prev, d.started = k, true
+}
+
+// dx returns DX, +DI and -DI.
+func (d *directional) dx() (dx, plus, minus float64) {
+	plus, minus = ratio(d.plus.value, d.tr.value), ratio(d.minus.value, d.tr.value)
+	return ratio(math.Abs(plus-minus), plus+minus), plus, minus
+}
+
+// DX is the directional movement index. Its values are DX, +DI and -DI.
+type DX struct {
+	d directional
+}
+
+// NewDX returns the directional movement index over period candles. It is
+// ready after period+1 candles.
+func NewDX(period int) *DX {
+	return &DX{d: newDirectional(period)}
+}
+
+func (x *DX) Update(k Kline)   { x.d.add(k) }
+func (x *DX) Ready() bool      { return x.d.tr.ready() }
+func (x *DX) Clone() Indicator { c := *x; return &c }
+
+func (x *DX) Values() []float64 {
+	if !x.Ready() {
+		return nil
+	}
+	dx, plus, minus := x.d.dx()
+	return []float64{dx, plus, minus}
+}
+
+// ADX is the average directional movement index: DX smoothed by Wilder's
+// method over the same period.
+type ADX struct {
+	d   directional
+	adx smoother
+}
+
+// NewADX returns the average directional movement index over period
+// candles. It is ready after 2*period candles.
+func NewADX(period int) *ADX {
+	return &ADX{d: newDirectional(period), adx: newWilder(period)}
+}
+
+func (x *ADX) Update(k Kline) {
+	x.d.add(k)
+	if x.d.tr.ready() {
+		dx, _, _ := x.d.dx()
+		x.adx.add(dx)
+	}
+}
+
+func (x *ADX) Ready() bool      { return x.adx.ready() }
+func (x *ADX) Clone() Indicator { c := *x; return &c }
+
+func (x *ADX) Values() []float64 {
+	if !x.Ready() {
+		return nil
+	}
+	return []float64{x.adx.value}
+}
+
+// Bollinger is the Bollinger bands. Its values are the middle band, the
+// SMA, then the upper and lower bands, offset by a multiple of the
+// population standard deviation.
+type Bollinger struct {
+	source   Source
+	up, down float64
+	w        window
+}
+
+// NewBollinger returns the Bollinger bands of source over period candles,
+// dev standard deviations either side.
+func NewBollinger(period int, dev float64, source Source) *Bollinger {
+	return &Bollinger{source: source, up: dev, down: dev, w: newWindow(period)}
+}
+
+func (b *Bollinger) Update(k Kline) { b.w.add(b.source.value(k)) }
+func (b *Bollinger) Ready() bool    { return b.w.full() }
+
+func (b *Bollinger) Values() []float64 {
+	if !b.Ready() {
+		return nil
+	}
+	mean := b.w.mean()
+	var variance float64
+	for _, v := range b.w.vals {
+		variance += (v - mean) * (v - mean)
+	}
+	std := math.Sqrt(variance / float64(len(b.w.vals)))
+	return []float64{mean, mean + b.up*std, mean - b.down*std}
+}
+
+func (b *Bollinger) Clone() Indicator {
+	c := *b
+	c.w = b.w.clone()
+	return &c
+}
+
+// Extreme is the highest or lowest source over a rolling window.
+type Extreme struct {
+	source  Source
+	n, seen int
+	highest bool
+	// queue holds the candidates for the extreme, oldest first, with the
+	// index of the candle they came from.
+	queue []extremeEntry
+}
+
+type extremeEntry struct {
+	i int
+	v float64
+}
+
+// NewMax returns the highest source over period candles.
+func NewMax(period int, source Source) *Extreme {
+	return &Extreme{source: source, n: period, highest: true}
+}
+
+// NewMin returns the lowest source over period candles.
+func NewMin(period int, source Source) *Extreme {
+	return &Extreme{source: source, n: period}
+}
+
+func (e *Extreme) Update(k Kline) {
+	v := e.source.value(k)
+	for len(e.queue) > 0 {
+		last := e.queue[len(e.queue)-1].v
+		if e.highest && last > v || !e.highest && last < v {
+			break
+		}
+		e.queue = e.queue[:len(e.queue)-1]
+	}
+	e.queue = append(e.queue, extremeEntry{e.seen, v})
+	e.seen++
+	if e.queue[0].i <= e.seen-1-e.n {
+		e.queue = e.queue[1:]
+	}
+}
+
+func (e *Extreme) Ready() bool { return e.seen >= e.n }
+
+func (e *Extreme) Values() []float64 {
+	if !e.Ready() {
+		return nil
+	}
+	return []float64{e.queue[0].v}
+}
+
+func (e *Extreme) Clone() Indicator {
+	c := *e
+	c.queue = slices.Clone(e.queue)
+	return &c
+}
+
+// LiveIndicator follows a candle still open, as delivered by
+// Stream.Klines: an update with the same open time as the previous one
+// replaces it instead of adding a candle. Older candles are ignored.
+type LiveIndicator struct {
+	ind, closed Indicator
+	open        int64
+	started     bool
+}
+
+// NewLiveIndicator wraps ind, which must not be used directly afterwards.
+func NewLiveIndicator(ind Indicator) *LiveIndicator {
+	return &LiveIndicator{ind: ind}
+}
+
+func (l *LiveIndicator) Update(k Kline) {
+	t := openMilli(k)
+	switch {
+	case l.started && t < l.open:
+		return
+	case l.started && t == l.open:
+		l.ind = l.closed.Clone()
+	default:
+		l.closed, l.open, l.started = l.ind.Clone(), t, true
+	}
+	l.ind.Update(k)
+}
+
+func (l *LiveIndicator) Ready() bool       { return l.ind.Ready() }
+func (l *LiveIndicator) Values() []float64 { return l.ind.Values() }
+
+func (l *LiveIndicator) Clone() Indicator {
+	c := *l
+	c.ind = l.ind.Clone()
+	if l.closed != nil {
+		c.closed = l.closed.Clone()
+	}
+	return &c
+}
@@ -0,0 +1,160 @@
+package sbee
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func closes(vs ...float64) []Kline {
+	ks := make([]Kline, len(vs))
+	for i, v := range vs {
+		p := DecimalFromFloat(v)
+		ks[i] = Kline{OpenTime: Timestamp((klineEpoch + int64(i)) * 60000), Open: p, High: p, Low: p, Close: p}
+	}
+	return ks
+}
+
+func ramp(n int) []Kline {
+	vs := make([]float64, n)
+	for i := range vs {
+		vs[i] = float64(i + 1)
+	}
+	return closes(vs...)
+}
+
+func last(t *testing.T, ind Indicator, ks []Kline) []float64 {
+	t.Helper()
+	for _, k := range ks {
+		ind.Update(k)
+	}
+	if !ind.Ready() {
+		t.Fatalf("%T not ready after %d candles", ind, len(ks))
+	}
+	return ind.Values()
+}
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestIndicators(t *testing.T) {
+	// Wilder's RSI on the usual textbook series.
+	rsi := NewRSI(14, SourceClose)
+	ks := closes(44.34, 44.09, 44.15, 43.61, 44.33, 44.83, 45.10, 45.42, 45.84, 46.08, 45.89, 46.03, 45.61, 46.28, 46.28, 46.00)
+	for i, k := range ks[:14] {
+		if rsi.Update(k); rsi.Ready() {
+			t.Fatalf("RSI ready after %d candles", i+1)
+		}
+	}
+	if v := last(t, rsi, ks[14:15]); math.Abs(v[0]-70.4641) > 1e-4 {
+		t.Errorf("RSI = %v, want 70.4641", v)
+	}
+	if v := last(t, rsi, ks[15:]); math.Abs(v[0]-66.2496) > 1e-4 {
+		t.Errorf("RSI = %v, want 66.2496", v)
+	}
+
+	// On a ramp an SMA-seeded EMA lags by (n-1)/2, so the MACD line is the
+	// difference of the lags and the histogram is zero.
+	if v := last(t, NewEMA(3, SourceClose), ramp(10)); !near(v[0], 9) {
+		t.Errorf("EMA = %v, want 9", v)
+	}
+	if v := last(t, NewMACD(12, 26, 9, SourceClose), ramp(60)); !near(v[0], 7) || !near(v[1], 7) || !near(v[2], 0) {
+		t.Errorf("MACD = %v, want 7, 7, 0", v)
+	}
+
+	// Rising candles two wide: the range is 2 and all movement is up.
+	var rising []Kline
+	for i := range 30 {
+		rising = append(rising, candle(int64(i), strconv.Itoa(i+1), strconv.Itoa(i+2), strconv.Itoa(i), strconv.Itoa(i+1), "1"))
+	}
+	if v := last(t, NewATR(14), rising); !near(v[0], 2) {
+		t.Errorf("ATR = %v, want 2", v)
+	}
+	if v := last(t, NewDX(14), rising); !near(v[0], 100) || !near(v[1], 50) || !near(v[2], 0) {
+		t.Errorf("DX = %v, want 100, 50, 0", v)
+	}
+	if v := last(t, NewADX(14), rising[:28]); !near(v[0], 100) {
+		t.Errorf("ADX = %v, want 100", v)
+	}
+
+	// Window indicators against a direct computation.
+	r := rand.New(rand.NewSource(1))
+	vs := make([]float64, 200)
+	for i := range vs {
+		vs[i] = 100 + r.Float64()*10
+	}
+	ks = closes(vs...)
+	sma, bb, hi, lo := NewSMA(20, SourceClose), NewBollinger(20, 2, SourceClose), NewMax(20, SourceClose), NewMin(20, SourceClose)
+	for i, k := range ks {
+		for _, ind := range []Indicator{sma, bb, hi, lo} {
+			ind.Update(k)
+		}
+		if i < 19 {
+			continue
+		}
+		w := vs[i-19 : i+1]
+		var sum, sq float64
+		mx, mn := w[0], w[0]
+		for _, v := range w {
+			sum += v
+			mx, mn = max(mx, v), min(mn, v)
+		}
+		mean := sum / 20
+		for _, v := range w {
+			sq += (v - mean) * (v - mean)
+		}
+		std := math.Sqrt(sq / 20)
+		b := bb.Values()
+		if math.Abs(sma.Values()[0]-mean) > 1e-9 || math.Abs(b[1]-mean-2*std) > 1e-9 || math.Abs(b[2]-mean+2*std) > 1e-9 ||
+			hi.Values()[0] != mx || lo.Values()[0] != mn {
+			t.Fatalf("candle %d: SMA %v, BB %v, MAX %v, MIN %v; want %v ± %v, %v, %v", i, sma.Values(), b, hi.Values(), lo.Values(), mean, 2*std, mx, mn)
+		}
+	}
+}
+
+func TestComputeFormations(t *testing.T) {
+	series, err := ComputeFormations(ramp(40), MaxFormation(30, SourceClose), DXFormation(14), MACDFormation(12, 26, 9, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 3 || series[0].Formation != "MAX" || len(series[0].Values) != 11 || !series[0].Values[10].Equal(d("40")) {
+		t.Errorf("MAX series = %+v", series[0])
+	}
+	if len(series[2].Values) != 40-33 || !series[2].Values[0].Equal(d("7")) {
+		t.Errorf("MACD series = %+v", series[2])
+	}
+	for _, f := range []Formation{{Name: "VWAP", TimePeriod: 3}, SMAFormation(0, SourceClose), MACDFormation(26, 12, 9, SourceClose), EMAFormation(3, "median")} {
+		if _, err := NewIndicator(f); err == nil {
+			t.Errorf("NewIndicator(%+v) succeeded", f)
+		}
+	}
+
+	// KlineFormation refuses them before sending.
+	s, got := newTestServer(t, `{"isSuccess":true,"data":[]}`)
+	_, err = s.KlineFormation(context.Background(), "Binance", "Spot", "BTC-USDT", "1h", 100, []Formation{DXFormation(14), SMAFormation(0, SourceClose)}, nil, nil)
+	var orderErr *OrderError
+	if !errors.Is(err, ErrInvalidOrder) || !errors.As(err, &orderErr) || orderErr.Endpoint != "KlineFormation" || orderErr.Field != "formations[1]" || got.path != "" {
+		t.Errorf("KlineFormation with SMA(0): err = %v, sent to %q", err, got.path)
+	}
+}
+
+func TestLiveIndicator(t *testing.T) {
+	ks := closes(1, 2, 3, 4, 5, 6)
+	live := NewLiveIndicator(NewEMA(3, SourceClose))
+	for _, k := range ks[:5] {
+		live.Update(k)
+	}
+	// Ticks of the open sixth candle replace each other.
+	for _, v := range []float64{10, 2, 6} {
+		k := ks[5]
+		k.Close = DecimalFromFloat(v)
+		live.Update(k)
+	}
+	live.Update(ks[0])
+	want := last(t, NewEMA(3, SourceClose), ks)
+	if got := live.Values(); !near(got[0], want[0]) {
+		t.Errorf("live EMA = %v, want %v", got, want)
+	}
+}
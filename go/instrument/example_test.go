@@ -0,0 +1,62 @@
+package instrument_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	sbee "github.com/sbeeIO/sdk/go"
+	"github.com/sbeeIO/sdk/go/instrument"
+)
+
+// printTracer stands in for a tracing library. An OpenTelemetry adapter has
+// the same shape: Start converts the attributes and starts a client span,
+// and the span type forwards the three calls.
+type printTracer struct{}
+
+func (printTracer) Start(ctx context.Context, name string, attrs []instrument.Attr) (context.Context, instrument.Span) {
+	fmt.Println("start", name, formatAttrs(attrs))
+	return ctx, printSpan{name}
+}
+
+type printSpan struct{ name string }
+
+func (s printSpan) SetAttributes(attrs []instrument.Attr) {
+	fmt.Println("set", s.name, formatAttrs(attrs))
+}
+
+func (s printSpan) RecordError(err error) {
+	fmt.Println("error", s.name, err)
+}
+
+func (s printSpan) End() {
+	fmt.Println("end", s.name)
+}
+
+func formatAttrs(attrs []instrument.Attr) string {
+	kvs := make([]string, len(attrs))
+	for i, a := range attrs {
+		kvs[i] = fmt.Sprintf("%s=%v", a.Key, a.Value)
+	}
+	return strings.Join(kvs, " ")
+}
+
+func ExampleTracing() {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"isSuccess":true,"data":{}}`)
+	}))
+	defer srv.Close()
+
+	client := sbee.NewClient("token",
+		sbee.WithBaseURL(srv.URL),
+		sbee.WithHooks(instrument.Tracing(printTracer{})),
+	)
+	client.OrderBook(context.Background(), "Binance", "Spot", "BTC-USDT", 5)
+	// Output:
+	// start sbee.OrderBook http.request.method=GET sbee.endpoint=OrderBook sbee.exchange=Binance sbee.trade=Spot sbee.attempt=1 sbee.symbol=BTC-USDT
+	// set sbee.OrderBook http.response.status_code=200
+	// end sbee.OrderBook
+}
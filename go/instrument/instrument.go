@@ -0,0 +1,145 @@
+// Package instrument traces and measures the API calls of an sbee client
+// through its hooks:
+//
+//	metrics := instrument.NewMetrics()
+//	client := sbee.NewClient(token,
+//		sbee.WithHooks(metrics.Hooks()),
+//		sbee.WithHooks(instrument.Tracing(tracer)),
+//	)
+//	http.Handle("/metrics", metrics)
+//
+// Every attempt of every call gets a span carrying its exchange, trade and
+// symbol, and is counted in the request counters and latency histograms
+// labelled by exchange, trade and endpoint.
+//
+// The package depends on the standard library only. Tracer is shaped after
+// OpenTelemetry's so that an adapter is a few lines; ExampleTracing shows
+// the same shape with a tracer that prints:
+//
+//	type otelTracer struct{ trace.Tracer }
+//
+//	func (t otelTracer) Start(ctx context.Context, name string, attrs []instrument.Attr) (context.Context, instrument.Span) {
+//		kvs := make([]attribute.KeyValue, len(attrs))
+//		for i, a := range attrs {
+//			kvs[i] = attribute.String(a.Key, fmt.Sprint(a.Value))
+//		}
+//		ctx, span := t.Tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(kvs...))
+//		return ctx, otelSpan{span}
+//	}
+//
+//	type otelSpan struct{ trace.Span }
+//
+//	func (s otelSpan) SetAttributes(attrs []instrument.Attr) {
+//		for _, a := range attrs {
+//			s.Span.SetAttributes(attribute.String(a.Key, fmt.Sprint(a.Value)))
+//		}
+//	}
+//
+//	func (s otelSpan) RecordError(err error) {
+//		s.Span.RecordError(err)
+//		s.Span.SetStatus(codes.Error, err.Error())
+//	}
+//
+//	func (s otelSpan) End() { s.Span.End() }
+package instrument
+
+import (
+	"context"
+	"errors"
+
+	sbee "github.com/sbeeIO/sdk/go"
+)
+
+// Span attribute keys.
+const (
+	AttrExchange   = "sbee.exchange"
+	AttrTrade      = "sbee.trade"
+	AttrSymbol     = "sbee.symbol"
+	AttrEndpoint   = "sbee.endpoint"
+	AttrAttempt    = "sbee.attempt"
+	AttrRequestID  = "sbee.request_id"
+	AttrMethod     = "http.request.method"
+	AttrStatusCode = "http.response.status_code"
+)
+
+// Attr is a span attribute. Its Value is a string, an int or a bool.
+type Attr struct {
+	Key   string
+	Value any
+}
+
+// Tracer starts the span of one attempt of an API call.
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs []Attr) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	SetAttributes(attrs []Attr)
+	// RecordError marks the span as failed with err.
+	RecordError(err error)
+	End()
+}
+
+// spanKey is the context key of the span of an attempt.
+type spanKey struct{}
+
+// Tracing returns hooks that trace every attempt of every API call with t,
+// in a span named "sbee.<endpoint>". The span is in the context the request
+// is sent with, so it is the parent of the HTTP client's own spans.
+func Tracing(t Tracer) sbee.Hooks {
+	return sbee.Hooks{
+		BeforeRequest: func(ctx context.Context, info *sbee.RequestInfo) context.Context {
+			attrs := []Attr{
+				{AttrMethod, info.Method},
+				{AttrEndpoint, info.Endpoint},
+				{AttrExchange, info.Exchange},
+				{AttrTrade, info.Trade},
+				{AttrAttempt, info.Attempt},
+			}
+			if info.Symbol != "" {
+				attrs = append(attrs, Attr{AttrSymbol, info.Symbol})
+			}
+			ctx, span := t.Start(ctx, "sbee."+info.Endpoint, attrs)
+			return context.WithValue(ctx, spanKey{}, span)
+		},
+		AfterResponse: func(ctx context.Context, info *sbee.RequestInfo) {
+			endSpan(ctx, info, nil)
+		},
+		OnError: func(ctx context.Context, info *sbee.RequestInfo, err error) {
+			endSpan(ctx, info, err)
+		},
+	}
+}
+
+// endSpan ends the span of the attempt info, if any.
+func endSpan(ctx context.Context, info *sbee.RequestInfo, err error) {
+	span, ok := ctx.Value(spanKey{}).(Span)
+	if !ok {
+		return
+	}
+	var attrs []Attr
+	if info.StatusCode != 0 {
+		attrs = append(attrs, Attr{AttrStatusCode, info.StatusCode})
+	}
+	if info.RequestID != "" {
+		attrs = append(attrs, Attr{AttrRequestID, info.RequestID})
+	}
+	if len(attrs) > 0 {
+		span.SetAttributes(attrs)
+	}
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+// errorKind returns the label value of the kind of err: that of its
+// *sbee.APIError, or "other".
+func errorKind(err error) string {
+	var apiErr *sbee.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Kind.String()
+	}
+	return "other"
+}
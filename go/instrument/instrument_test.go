@@ -0,0 +1,93 @@
+package instrument
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	sbee "github.com/sbeeIO/sdk/go"
+)
+
+type testSpan struct {
+	name  string
+	attrs map[string]any
+	err   error
+	ended bool
+}
+
+func (s *testSpan) SetAttributes(attrs []Attr) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+func (s *testSpan) RecordError(err error) { s.err = err }
+func (s *testSpan) End()                  { s.ended = true }
+
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, name string, attrs []Attr) (context.Context, Span) {
+	span := &testSpan{name: name, attrs: make(map[string]any)}
+	span.SetAttributes(attrs)
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+	return ctx, span
+}
+
+// newServer returns a client of a server that fails the first failures
+// calls with 503 and answers the others with reply.
+func newServer(t *testing.T, failures int, reply string, opts ...sbee.Option) *sbee.SbeeRest {
+	t.Helper()
+	var mu sync.Mutex
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		failed := calls <= failures
+		mu.Unlock()
+		if failed {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("X-Request-Id", "req-1")
+		io.WriteString(w, reply)
+	}))
+	t.Cleanup(srv.Close)
+	retry := sbee.DefaultRetryPolicy()
+	retry.InitialBackoff = 0
+	return sbee.NewClient("token", append([]sbee.Option{sbee.WithBaseURL(srv.URL), sbee.WithRetryPolicy(retry)}, opts...)...)
+}
+
+func TestTracing(t *testing.T) {
+	tracer := &testTracer{}
+	s := newServer(t, 1, `{"isSuccess":true,"data":{}}`, sbee.WithHooks(Tracing(tracer)))
+	if _, err := s.OrderBook(context.Background(), "Binance", "Spot", "BTC-USDT", 5); err != nil {
+		t.Fatal(err)
+	}
+	if len(tracer.spans) != 2 {
+		t.Fatalf("%d spans", len(tracer.spans))
+	}
+	for i, span := range tracer.spans {
+		attrs := fmt.Sprint(span.attrs)
+		if span.name != "sbee.OrderBook" || !span.ended || span.attrs[AttrExchange] != "Binance" || span.attrs[AttrTrade] != "Spot" ||
+			span.attrs[AttrSymbol] != "BTC-USDT" || span.attrs[AttrAttempt] != i+1 || span.attrs[AttrMethod] != "GET" {
+			t.Errorf("span %d: %s %s ended %v", i, span.name, attrs, span.ended)
+		}
+	}
+	var apiErr *sbee.APIError
+	if first := tracer.spans[0]; first.attrs[AttrStatusCode] != 503 || !errors.As(first.err, &apiErr) {
+		t.Errorf("failed attempt: %v, err %v", first.attrs, first.err)
+	}
+	if second := tracer.spans[1]; second.attrs[AttrStatusCode] != 200 || second.attrs[AttrRequestID] != "req-1" || second.err != nil {
+		t.Errorf("second attempt: %v, err %v", second.attrs, second.err)
+	}
+}
@@ -0,0 +1,237 @@
+package instrument
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	sbee "github.com/sbeeIO/sdk/go"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the latency histogram
+// buckets of a Metrics created without WithBuckets.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics counts and times the API calls of the clients it is hooked into,
+// and serves them in the Prometheus text format:
+//
+//	sbee_requests_total{endpoint,exchange,status,trade}
+//	sbee_request_errors_total{endpoint,exchange,kind,trade}
+//	sbee_request_retries_total{endpoint,exchange,trade}
+//	sbee_request_duration_seconds{endpoint,exchange,trade}
+//
+// Every attempt is a request; retries also count the attempts after the
+// first. status is the HTTP status, or 0 when no response was received, and
+// kind is the sbee.ErrorKind of a failure. Symbols are left out of the
+// labels to bound the number of series. A Metrics is safe for concurrent
+// use.
+type Metrics struct {
+	buckets []float64
+
+	mu        sync.Mutex
+	requests  map[series]uint64
+	errors    map[series]uint64
+	retries   map[series]uint64
+	durations map[series]*histogram
+}
+
+// series identifies one labelled series. extra is the status of requests
+// and the kind of errors.
+type series struct {
+	exchange, trade, endpoint, extra string
+}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// MetricsOption configures a Metrics created by NewMetrics.
+type MetricsOption func(*Metrics)
+
+// WithBuckets sets the upper bounds, in seconds, of the latency histogram
+// buckets.
+func WithBuckets(buckets []float64) MetricsOption {
+	return func(m *Metrics) {
+		m.buckets = append([]float64(nil), buckets...)
+		sort.Float64s(m.buckets)
+	}
+}
+
+// NewMetrics returns an empty Metrics.
+func NewMetrics(opts ...MetricsOption) *Metrics {
+	m := &Metrics{
+		buckets:   DefaultBuckets,
+		requests:  make(map[series]uint64),
+		errors:    make(map[series]uint64),
+		retries:   make(map[series]uint64),
+		durations: make(map[series]*histogram),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Hooks returns the hooks that feed m, for sbee.WithHooks.
+func (m *Metrics) Hooks() sbee.Hooks {
+	return sbee.Hooks{
+		AfterResponse: func(ctx context.Context, info *sbee.RequestInfo) {
+			m.observe(info, nil)
+		},
+		OnError: func(ctx context.Context, info *sbee.RequestInfo, err error) {
+			m.observe(info, err)
+		},
+	}
+}
+
+// observe records the attempt info, which failed with err if not nil.
+func (m *Metrics) observe(info *sbee.RequestInfo, err error) {
+	key := series{exchange: info.Exchange, trade: info.Trade, endpoint: info.Endpoint}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status := key
+	status.extra = strconv.Itoa(info.StatusCode)
+	m.requests[status]++
+	if err != nil {
+		kind := key
+		kind.extra = errorKind(err)
+		m.errors[kind]++
+	}
+	if info.Attempt > 1 {
+		m.retries[key]++
+	}
+	if info.StatusCode == 0 {
+		// No response: the latency is that of a failure to connect.
+		return
+	}
+	h := m.durations[key]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(m.buckets))}
+		m.durations[key] = h
+	}
+	v := info.Latency.Seconds()
+	if i := sort.SearchFloat64s(m.buckets, v); i < len(m.buckets) {
+		h.counts[i]++
+	}
+	h.sum += v
+	h.count++
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics to w in the Prometheus text format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	b := bufio.NewWriter(cw)
+
+	m.mu.Lock()
+	writeCounter(b, "sbee_requests_total", "Attempts of sbee API calls.", "status", m.requests)
+	writeCounter(b, "sbee_request_errors_total", "Failed attempts of sbee API calls.", "kind", m.errors)
+	writeCounter(b, "sbee_request_retries_total", "Attempts of sbee API calls after the first.", "", m.retries)
+	m.writeDurations(b)
+	m.mu.Unlock()
+
+	err := b.Flush()
+	return cw.n, err
+}
+
+func writeCounter(w io.Writer, name, help, extra string, values map[series]uint64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	for _, s := range sortedSeries(values) {
+		fmt.Fprintf(w, "%s{%s} %d\n", name, s.labels(extra), values[s])
+	}
+}
+
+func (m *Metrics) writeDurations(w io.Writer) {
+	const name = "sbee_request_duration_seconds"
+	fmt.Fprintf(w, "# HELP %s Latency of sbee API calls that got a response.\n# TYPE %s histogram\n", name, name)
+	for _, s := range sortedSeries(m.durations) {
+		h := m.durations[s]
+		labels := s.labels("")
+		var cumulative uint64
+		for i, le := range m.buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(w, "%s_bucket{%s,le=%q} %d\n", name, labels, formatFloat(le), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+		fmt.Fprintf(w, "%s_sum{%s} %s\n", name, labels, formatFloat(h.sum))
+		fmt.Fprintf(w, "%s_count{%s} %d\n", name, labels, h.count)
+	}
+}
+
+// labels formats the labels of s, in name order. extra names s.extra, or is
+// "" when s has no extra label.
+func (s series) labels(extra string) string {
+	pairs := [][2]string{{"endpoint", s.endpoint}, {"exchange", s.exchange}, {"trade", s.trade}}
+	if extra != "" {
+		pairs = append(pairs, [2]string{extra, s.extra})
+		sort.Slice(pairs, func(i, j int) bool { return pairs[i][0] < pairs[j][0] })
+	}
+	var b strings.Builder
+	for i, p := range pairs {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(p[0])
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(p[1]))
+		b.WriteByte('"')
+	}
+	return b.String()
+}
+
+// labelEscaper escapes label values as the text format requires.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func sortedSeries[V any](m map[series]V) []series {
+	out := make([]series, 0, len(m))
+	for s := range m {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.endpoint != b.endpoint {
+			return a.endpoint < b.endpoint
+		}
+		if a.exchange != b.exchange {
+			return a.exchange < b.exchange
+		}
+		if a.trade != b.trade {
+			return a.trade < b.trade
+		}
+		return a.extra < b.extra
+	})
+	return out
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, +1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,57 @@
+package instrument
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	sbee "github.com/sbeeIO/sdk/go"
+)
+
+func TestMetrics(t *testing.T) {
+	m := NewMetrics(WithBuckets([]float64{1, 0.5}))
+	s := newServer(t, 1, `{"isSuccess":true,"data":{}}`, sbee.WithHooks(m.Hooks()))
+	ctx := context.Background()
+	if _, err := s.MultiOrderBook(ctx, "Spot", sbee.MultiMarketRequest{Symbol: "BTC-USDT"}); err != nil {
+		t.Fatal(err)
+	}
+	s.OrderBook(ctx, "Binance", "Spot", "BTC-USDT", 5)
+	hooks := m.Hooks()
+	hooks.AfterResponse(ctx, &sbee.RequestInfo{Endpoint: "OrderBook", Exchange: "Binance", Trade: "Spot", Attempt: 1, StatusCode: 200, Latency: 700 * time.Millisecond})
+	hooks.OnError(ctx, &sbee.RequestInfo{Endpoint: "OrderBook", Exchange: `Bin"ance`, Trade: "Spot", Attempt: 1}, context.DeadlineExceeded)
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type %q", ct)
+	}
+	out := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE sbee_requests_total counter\n",
+		`sbee_requests_total{endpoint="MultiOrderBook",exchange="",status="503",trade="Spot"} 1` + "\n",
+		`sbee_requests_total{endpoint="MultiOrderBook",exchange="",status="200",trade="Spot"} 1` + "\n",
+		`sbee_requests_total{endpoint="OrderBook",exchange="Binance",status="200",trade="Spot"} 2` + "\n",
+		`sbee_requests_total{endpoint="OrderBook",exchange="Bin\"ance",status="0",trade="Spot"} 1` + "\n",
+		`sbee_request_errors_total{endpoint="MultiOrderBook",exchange="",kind="api",trade="Spot"} 1` + "\n",
+		`sbee_request_errors_total{endpoint="OrderBook",exchange="Bin\"ance",kind="other",trade="Spot"} 1` + "\n",
+		`sbee_request_retries_total{endpoint="MultiOrderBook",exchange="",trade="Spot"} 1` + "\n",
+		"# TYPE sbee_request_duration_seconds histogram\n",
+		`sbee_request_duration_seconds_bucket{endpoint="OrderBook",exchange="Binance",trade="Spot",le="0.5"} 1` + "\n",
+		`sbee_request_duration_seconds_bucket{endpoint="OrderBook",exchange="Binance",trade="Spot",le="1"} 2` + "\n",
+		`sbee_request_duration_seconds_bucket{endpoint="OrderBook",exchange="Binance",trade="Spot",le="+Inf"} 2` + "\n",
+		`sbee_request_duration_seconds_count{endpoint="OrderBook",exchange="Binance",trade="Spot"} 2` + "\n",
+		`sbee_request_duration_seconds_count{endpoint="MultiOrderBook",exchange="",trade="Spot"} 2` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+	if strings.Contains(out, `exchange="Bin\"ance",trade="Spot",le=`) {
+		t.Error("attempt without a response has a latency")
+	}
+	if t.Failed() {
+		t.Log(out)
+	}
+}
@@ -0,0 +1,476 @@
+package sbee
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Keystore errors.
+var (
+	ErrKeystoreExists  = errors.New("sbee: keystore already exists")
+	ErrWrongPassphrase = errors.New("sbee: wrong keystore passphrase")
+	ErrKeyExists       = errors.New("sbee: keys already stored")
+)
+
+// DefaultKeystoreIterations is the PBKDF2-HMAC-SHA256 iteration count of new
+// keystores.
+const DefaultKeystoreIterations = 600_000
+
+// MaxKeystoreIterations bounds the iteration count a keystore may use, so
+// that a damaged file cannot stall OpenKeystore in PBKDF2.
+const MaxKeystoreIterations = 10 * DefaultKeystoreIterations
+
+// keystoreCheck is encrypted with the key of a keystore to tell a wrong
+// passphrase from a damaged entry.
+const keystoreCheck = "sbee keystore"
+
+// Keystore stores the API keys of accounts on exchanges in a file,
+// encrypted with AES-256-GCM under a key derived from a passphrase with
+// PBKDF2-HMAC-SHA256. Each entry is sealed separately, bound to its account
+// and exchange, and only decrypted when its keys are asked for; account and
+// exchange names are stored in the clear. The file is rewritten atomically,
+// readable by its owner only, on every change, and read again whenever it
+// changed since, so keys rotated by another process, such as
+// sbee-keystore, are seen by the next call.
+//
+// Keystore implements CredentialProvider, so it plugs into
+// WithCredentialProvider and, through the Account field of Credentials,
+// into any private call. A Keystore is safe for concurrent use.
+type Keystore struct {
+	path string
+	key  []byte
+
+	mu   sync.Mutex
+	file keystoreFile
+	info fs.FileInfo // of the file as last read or written
+}
+
+type keystoreFile struct {
+	Version    int             `json:"version"`
+	KDF        string          `json:"kdf"`
+	Iterations int             `json:"iterations"`
+	Salt       []byte          `json:"salt"`
+	Check      sealed          `json:"check"`
+	Entries    []keystoreEntry `json:"entries"`
+}
+
+type keystoreEntry struct {
+	Account  string    `json:"account"`
+	Exchange string    `json:"exchange"`
+	Created  time.Time `json:"created"`
+	Rotated  time.Time `json:"rotated"`
+	Keys     sealed    `json:"keys"`
+}
+
+type sealed struct {
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// KeystoreEntry describes stored keys without revealing them.
+type KeystoreEntry struct {
+	Account  string
+	Exchange string
+	Created  time.Time
+	// Rotated is when the keys were last replaced, or Created.
+	Rotated time.Time
+}
+
+// KeystoreOption configures CreateKeystore.
+type KeystoreOption func(*keystoreFile)
+
+// WithKeystoreIterations sets the PBKDF2 iteration count, at most
+// MaxKeystoreIterations. The default is DefaultKeystoreIterations; lower
+// counts make passphrases easier to guess.
+func WithKeystoreIterations(n int) KeystoreOption {
+	return func(f *keystoreFile) {
+		f.Iterations = n
+	}
+}
+
+// CreateKeystore creates an empty keystore at path protected by passphrase.
+// It fails with ErrKeystoreExists if the file exists.
+func CreateKeystore(path string, passphrase []byte, opts ...KeystoreOption) (*Keystore, error) {
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrKeystoreExists, path)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if len(passphrase) == 0 {
+		return nil, errors.New("sbee: empty keystore passphrase")
+	}
+	ks := &Keystore{path: path, file: keystoreFile{Version: 1, KDF: "pbkdf2-sha256", Iterations: DefaultKeystoreIterations}}
+	for _, opt := range opts {
+		opt(&ks.file)
+	}
+	if err := ks.setPassphrase(passphrase); err != nil {
+		return nil, err
+	}
+	return ks, ks.save()
+}
+
+// OpenKeystore opens the keystore at path. It fails with ErrWrongPassphrase
+// when passphrase does not match.
+func OpenKeystore(path string, passphrase []byte) (*Keystore, error) {
+	ks := &Keystore{path: path}
+	var err error
+	if ks.file, ks.info, err = readKeystore(path); err != nil {
+		return nil, err
+	}
+	ks.key = pbkdf2SHA256(passphrase, ks.file.Salt, ks.file.Iterations, 32)
+	if !ks.checks(ks.file) {
+		return nil, ErrWrongPassphrase
+	}
+	return ks, nil
+}
+
+// readKeystore reads and checks the keystore file at path.
+func readKeystore(path string) (keystoreFile, fs.FileInfo, error) {
+	var f keystoreFile
+	r, err := os.Open(path)
+	if err != nil {
+		return f, nil, err
+	}
+	defer r.Close()
+	info, err := r.Stat()
+	if err != nil {
+		return f, nil, err
+	}
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return f, nil, fmt.Errorf("sbee: keystore %s: %w", path, err)
+	}
+	if f.Version != 1 || f.KDF != "pbkdf2-sha256" {
+		return f, nil, fmt.Errorf("sbee: keystore %s: unsupported version %d, kdf %q", path, f.Version, f.KDF)
+	}
+	if err := checkIterations(f.Iterations); err != nil {
+		return f, nil, fmt.Errorf("sbee: keystore %s: %w", path, err)
+	}
+	return f, info, nil
+}
+
+// checks reports whether f is encrypted under the key of ks.
+func (ks *Keystore) checks(f keystoreFile) bool {
+	if !bytes.Equal(f.Salt, ks.file.Salt) || f.Iterations != ks.file.Iterations {
+		return false
+	}
+	check, err := ks.open(f.Check, nil)
+	return err == nil && string(check) == keystoreCheck
+}
+
+// refresh reads the file again if it was replaced since ks last read or
+// wrote it. A file whose passphrase was changed elsewhere fails with
+// ErrWrongPassphrase: the keystore must be opened again. The caller holds
+// ks.mu.
+func (ks *Keystore) refresh() error {
+	info, err := os.Stat(ks.path)
+	if err != nil {
+		return err
+	}
+	if ks.info != nil && os.SameFile(info, ks.info) && info.ModTime().Equal(ks.info.ModTime()) && info.Size() == ks.info.Size() {
+		return nil
+	}
+	f, info, err := readKeystore(ks.path)
+	if err != nil {
+		return err
+	}
+	if !ks.checks(f) {
+		return fmt.Errorf("sbee: keystore %s changed: %w", ks.path, ErrWrongPassphrase)
+	}
+	ks.file, ks.info = f, info
+	return nil
+}
+
+// DefaultKeystorePath returns $SBEE_KEYSTORE, or sbee/keystore.json under
+// the user's configuration directory.
+func DefaultKeystorePath() (string, error) {
+	if p := os.Getenv("SBEE_KEYSTORE"); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sbee", "keystore.json"), nil
+}
+
+// Path returns the file of the keystore.
+func (ks *Keystore) Path() string { return ks.path }
+
+// Add stores the keys of account on exchange. It fails with ErrKeyExists if
+// there are keys already; use Rotate to replace them.
+func (ks *Keystore) Add(account, exchange string, c Credentials) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if err := ks.refresh(); err != nil {
+		return err
+	}
+	if ks.find(account, exchange) >= 0 {
+		return fmt.Errorf("%w for account %q on %s", ErrKeyExists, account, exchange)
+	}
+	e := keystoreEntry{Account: account, Exchange: exchange, Created: time.Now().UTC()}
+	e.Rotated = e.Created
+	if err := ks.sealKeys(&e, c); err != nil {
+		return err
+	}
+	ks.file.Entries = append(ks.file.Entries, e)
+	return ks.save()
+}
+
+// Rotate replaces the keys of account on exchange. It fails with an error
+// matching ErrNoCredentials if there are none.
+func (ks *Keystore) Rotate(account, exchange string, c Credentials) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if err := ks.refresh(); err != nil {
+		return err
+	}
+	i := ks.find(account, exchange)
+	if i < 0 {
+		return noCredentials(account, exchange)
+	}
+	e := ks.file.Entries[i]
+	e.Rotated = time.Now().UTC()
+	if err := ks.sealKeys(&e, c); err != nil {
+		return err
+	}
+	ks.file.Entries[i] = e
+	return ks.save()
+}
+
+// Remove deletes the keys of account on exchange. It fails with an error
+// matching ErrNoCredentials if there are none.
+func (ks *Keystore) Remove(account, exchange string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if err := ks.refresh(); err != nil {
+		return err
+	}
+	i := ks.find(account, exchange)
+	if i < 0 {
+		return noCredentials(account, exchange)
+	}
+	ks.file.Entries = append(ks.file.Entries[:i], ks.file.Entries[i+1:]...)
+	return ks.save()
+}
+
+// List returns the stored entries sorted by account and exchange.
+func (ks *Keystore) List() ([]KeystoreEntry, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if err := ks.refresh(); err != nil {
+		return nil, err
+	}
+	out := make([]KeystoreEntry, 0, len(ks.file.Entries))
+	for _, e := range ks.file.Entries {
+		out = append(out, KeystoreEntry{Account: e.Account, Exchange: e.Exchange, Created: e.Created, Rotated: e.Rotated})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Account != out[j].Account {
+			return out[i].Account < out[j].Account
+		}
+		return out[i].Exchange < out[j].Exchange
+	})
+	return out, nil
+}
+
+// ChangePassphrase re-encrypts every entry under a key derived from
+// passphrase and a new salt.
+func (ks *Keystore) ChangePassphrase(passphrase []byte) error {
+	if len(passphrase) == 0 {
+		return errors.New("sbee: empty keystore passphrase")
+	}
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if err := ks.refresh(); err != nil {
+		return err
+	}
+	keys := make([]Credentials, len(ks.file.Entries))
+	for i, e := range ks.file.Entries {
+		c, err := ks.openKeys(e)
+		if err != nil {
+			return err
+		}
+		keys[i] = c
+	}
+	old := ks.file
+	old.Entries = append([]keystoreEntry(nil), ks.file.Entries...)
+	oldKey := ks.key
+	if err := ks.setPassphrase(passphrase); err != nil {
+		return err
+	}
+	for i := range ks.file.Entries {
+		if err := ks.sealKeys(&ks.file.Entries[i], keys[i]); err != nil {
+			ks.file, ks.key = old, oldKey
+			return err
+		}
+	}
+	if err := ks.save(); err != nil {
+		ks.file, ks.key = old, oldKey
+		return err
+	}
+	return nil
+}
+
+// Credentials decrypts the keys of account on exchange, as stored in the
+// file at the time of the call. Exchanges match regardless of case.
+func (ks *Keystore) Credentials(ctx context.Context, account, exchange string) (Credentials, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if err := ks.refresh(); err != nil {
+		return Credentials{}, err
+	}
+	i := ks.find(account, exchange)
+	if i < 0 {
+		return Credentials{}, noCredentials(account, exchange)
+	}
+	return ks.openKeys(ks.file.Entries[i])
+}
+
+func (ks *Keystore) find(account, exchange string) int {
+	for i, e := range ks.file.Entries {
+		if e.Account == account && strings.EqualFold(e.Exchange, exchange) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (ks *Keystore) setPassphrase(passphrase []byte) error {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	if err := checkIterations(ks.file.Iterations); err != nil {
+		return fmt.Errorf("sbee: keystore %w", err)
+	}
+	ks.file.Salt = salt
+	ks.key = pbkdf2SHA256(passphrase, salt, ks.file.Iterations, 32)
+	check, err := ks.seal([]byte(keystoreCheck), nil)
+	if err != nil {
+		return err
+	}
+	ks.file.Check = check
+	return nil
+}
+
+// entryData binds a sealed entry to its account and exchange, so entries
+// cannot be swapped in the file.
+func entryData(e keystoreEntry) []byte {
+	return []byte(e.Account + "\x00" + strings.ToLower(e.Exchange))
+}
+
+func (ks *Keystore) sealKeys(e *keystoreEntry, c Credentials) error {
+	plain, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	defer clear(plain)
+	e.Keys, err = ks.seal(plain, entryData(*e))
+	return err
+}
+
+func (ks *Keystore) openKeys(e keystoreEntry) (Credentials, error) {
+	plain, err := ks.open(e.Keys, entryData(e))
+	if err != nil {
+		return Credentials{}, fmt.Errorf("sbee: keystore entry %q on %s: %w", e.Account, e.Exchange, err)
+	}
+	defer clear(plain)
+	var c Credentials
+	err = json.Unmarshal(plain, &c)
+	return c, err
+}
+
+func (ks *Keystore) gcm() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(ks.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (ks *Keystore) seal(plain, data []byte) (sealed, error) {
+	aead, err := ks.gcm()
+	if err != nil {
+		return sealed{}, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return sealed{}, err
+	}
+	return sealed{Nonce: nonce, Ciphertext: aead.Seal(nil, nonce, plain, data)}, nil
+}
+
+func (ks *Keystore) open(s sealed, data []byte) ([]byte, error) {
+	aead, err := ks.gcm()
+	if err != nil {
+		return nil, err
+	}
+	if len(s.Nonce) != aead.NonceSize() {
+		return nil, errors.New("bad nonce")
+	}
+	return aead.Open(nil, s.Nonce, s.Ciphertext, data)
+}
+
+func (ks *Keystore) save() error {
+	if err := os.MkdirAll(filepath.Dir(ks.path), 0o700); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(ks.path, ks.file); err != nil {
+		return err
+	}
+	info, err := os.Stat(ks.path)
+	if err != nil {
+		return err
+	}
+	ks.info = info
+	return nil
+}
+
+// pbkdf2SHA256 derives a key of keyLen bytes from password and salt as in
+// RFC 8018, section 5.2.
+func pbkdf2SHA256(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	var out []byte
+	u := make([]byte, sha256.Size)
+	t := make([]byte, sha256.Size)
+	for block := uint32(1); len(out) < keyLen; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.Write(prf, binary.BigEndian, block)
+		u = prf.Sum(u[:0])
+		copy(t, u)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		out = append(out, t...)
+	}
+	return out[:keyLen]
+}
+
+func checkIterations(n int) error {
+	if n <= 0 || n > MaxKeystoreIterations {
+		return fmt.Errorf("iterations %d outside 1 to %d", n, MaxKeystoreIterations)
+	}
+	return nil
+}
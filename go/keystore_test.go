@@ -0,0 +1,146 @@
+package sbee
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestPBKDF2(t *testing.T) {
+	// RFC 7914, section 11.
+	got := hex.EncodeToString(pbkdf2SHA256([]byte("passwd"), []byte("salt"), 1, 64))
+	want := "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"
+	if got != want {
+		t.Errorf("PBKDF2 = %s, want %s", got, want)
+	}
+}
+
+func TestKeystore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "sbee", "keystore.json")
+	ks, err := CreateKeystore(path, []byte("pass phrase"), WithKeystoreIterations(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreateKeystore(path, []byte("x")); !errors.Is(err, ErrKeystoreExists) {
+		t.Errorf("second create: err = %v", err)
+	}
+	if err := ks.Add("main", "Binance", testKeys); err != nil {
+		t.Fatal(err)
+	}
+	ks.Add("alt", "OKX", Credentials{APIKey: "okx"})
+	if err := ks.Add("main", "binance", testKeys); !errors.Is(err, ErrKeyExists) {
+		t.Errorf("duplicate add: err = %v", err)
+	}
+
+	if runtime.GOOS != "windows" {
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+			t.Errorf("keystore mode %v", info.Mode().Perm())
+		}
+	}
+	raw, _ := os.ReadFile(path)
+	for _, secret := range []string{"secret", `"pass"`, `"okx"`} {
+		if strings.Contains(string(raw), secret) {
+			t.Errorf("keystore file holds %q in the clear", secret)
+		}
+	}
+
+	if _, err := OpenKeystore(path, []byte("wrong")); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("wrong passphrase: err = %v", err)
+	}
+	ks, err = OpenKeystore(path, []byte("pass phrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, err := ks.Credentials(ctx, "main", "BINANCE"); err != nil || c != testKeys {
+		t.Errorf("main on Binance = %+v, %v", c, err)
+	}
+	if err := ks.Rotate("main", "Binance", Credentials{APIKey: "new"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Rotate("main", "KuCoin", testKeys); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("rotate missing: err = %v", err)
+	}
+	if list, err := ks.List(); err != nil || len(list) != 2 || list[0].Account != "alt" || !list[1].Rotated.After(list[1].Created) {
+		t.Errorf("List = %+v", list)
+	}
+
+	if err := ks.ChangePassphrase([]byte("another")); err != nil {
+		t.Fatal(err)
+	}
+	ks, err = OpenKeystore(path, []byte("another"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := ks.Credentials(ctx, "main", "Binance"); c.APIKey != "new" {
+		t.Errorf("after passphrase change: %+v", c)
+	}
+	if err := ks.Remove("alt", "OKX"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.Credentials(ctx, "alt", "OKX"); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("removed entry: err = %v", err)
+	}
+
+	// An entry moved to another account no longer decrypts.
+	ks.Add("other", "Binance", Credentials{APIKey: "other"})
+	var f keystoreFile
+	raw, _ = os.ReadFile(path)
+	json.Unmarshal(raw, &f)
+	f.Entries[0].Keys, f.Entries[1].Keys = f.Entries[1].Keys, f.Entries[0].Keys
+	writeFileAtomic(path, f)
+	ks, _ = OpenKeystore(path, []byte("another"))
+	if _, err := ks.Credentials(ctx, "main", "Binance"); err == nil {
+		t.Error("swapped entry decrypted")
+	}
+
+	// Iteration counts beyond the bound are refused before deriving a key.
+	f.Iterations = MaxKeystoreIterations + 1
+	writeFileAtomic(path, f)
+	if _, err := OpenKeystore(path, []byte("another")); err == nil || !strings.Contains(err.Error(), "iterations") {
+		t.Errorf("open with %d iterations: err = %v", f.Iterations, err)
+	}
+	if _, err := CreateKeystore(filepath.Join(t.TempDir(), "k.json"), []byte("x"), WithKeystoreIterations(MaxKeystoreIterations+1)); err == nil {
+		t.Error("created with too many iterations")
+	}
+}
+
+func TestKeystoreReload(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keystore.json")
+	client, err := CreateKeystore(path, []byte("pw"), WithKeystoreIterations(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Add("main", "Binance", testKeys)
+
+	// Another process rotates the keys and adds an account.
+	admin, err := OpenKeystore(path, []byte("pw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin.Rotate("main", "Binance", Credentials{APIKey: "rotated"})
+	admin.Add("alt", "OKX", Credentials{APIKey: "okx"})
+	if c, err := client.Credentials(ctx, "main", "Binance"); err != nil || c.APIKey != "rotated" {
+		t.Errorf("after rotation elsewhere = %+v, %v", c, err)
+	}
+
+	// Changes made here keep those made there.
+	if err := client.Add("other", "Binance", Credentials{APIKey: "other"}); err != nil {
+		t.Fatal(err)
+	}
+	if list, err := admin.List(); err != nil || len(list) != 3 {
+		t.Errorf("List = %+v, %v", list, err)
+	}
+
+	admin.ChangePassphrase([]byte("new"))
+	if _, err := client.Credentials(ctx, "main", "Binance"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("after passphrase change elsewhere: err = %v", err)
+	}
+}
@@ -0,0 +1,496 @@
+package sbee
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultKLinePageSize and DefaultKLineConcurrency are the defaults of
+// KLineRange and KLineIter.
+const (
+	DefaultKLinePageSize    = 1000
+	DefaultKLineConcurrency = 4
+)
+
+// KLineOption configures KLineRange and KLineIter.
+type KLineOption func(*klineOptions)
+
+type klineOptions struct {
+	pageSize    int
+	concurrency int
+	onGap       func(KlineGap)
+}
+
+// WithKLinePageSize sets the number of candles requested per KLine call.
+// The default is DefaultKLinePageSize. Exchanges returning fewer candles
+// than asked are paged further as needed.
+func WithKLinePageSize(n int) KLineOption {
+	return func(o *klineOptions) {
+		o.pageSize = n
+	}
+}
+
+// WithKLineConcurrency sets how many pages are fetched at once. The default
+// is DefaultKLineConcurrency. Calls still wait for the client's rate limits.
+func WithKLineConcurrency(n int) KLineOption {
+	return func(o *klineOptions) {
+		o.concurrency = n
+	}
+}
+
+// WithKLineGapHandler calls fn, in time order, for every gap between two
+// consecutive candles of the range. fn is called from Next, or from
+// KLineRange before it returns.
+func WithKLineGapHandler(fn func(KlineGap)) KLineOption {
+	return func(o *klineOptions) {
+		o.onGap = fn
+	}
+}
+
+// KlineGap is a span where candles were expected but none were returned:
+// From is the open time of the first missing candle and To that of the
+// candle after the gap.
+type KlineGap struct {
+	From, To time.Time
+	Missing  int
+}
+
+// IntervalDuration returns the length of a fixed KLine interval such as
+// "1m", "4h", "1d" or "1w". It reports false for "1M", whose months vary in
+// length, and for intervals it does not know.
+func IntervalDuration(interval string) (time.Duration, bool) {
+	if len(interval) < 2 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(interval[:len(interval)-1])
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	unit := map[byte]time.Duration{'s': time.Second, 'm': time.Minute, 'h': time.Hour, 'd': 24 * time.Hour, 'w': 7 * 24 * time.Hour}[interval[len(interval)-1]]
+	if unit == 0 {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+// intervalMonths returns n for an "nM" interval.
+func intervalMonths(interval string) (int, bool) {
+	if !strings.HasSuffix(interval, "M") {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(interval, "M"))
+	return n, err == nil && n > 0
+}
+
+// advance returns t moved forward by n intervals, and false when interval
+// is not known.
+func advance(t time.Time, interval string, n int) (time.Time, bool) {
+	if d, ok := IntervalDuration(interval); ok {
+		return t.Add(time.Duration(n) * d), true
+	}
+	if m, ok := intervalMonths(interval); ok {
+		return t.UTC().AddDate(0, n*m, 0), true
+	}
+	return time.Time{}, false
+}
+
+// KlineGaps returns the gaps between consecutive candles of ks, which must
+// be sorted by open time. It returns nil for unknown intervals.
+func KlineGaps(ks []Kline, interval string) []KlineGap {
+	var gaps []KlineGap
+	for i := 1; i < len(ks); i++ {
+		if g, ok := klineGap(ks[i-1], ks[i], interval); ok {
+			gaps = append(gaps, g)
+		}
+	}
+	return gaps
+}
+
+func klineGap(prev, next Kline, interval string) (KlineGap, bool) {
+	want, ok := advance(prev.OpenTime.Time(), interval, 1)
+	got := next.OpenTime.Time()
+	if !ok || !got.After(want) {
+		return KlineGap{}, false
+	}
+	g := KlineGap{From: want, To: got}
+	for t := want; t.Before(got); t, _ = advance(t, interval, 1) {
+		g.Missing++
+	}
+	return g, true
+}
+
+// KLineRange returns the candles of symbol opening in [start, end), oldest
+// first. It reads them through KLineIter.
+func (s *SbeeRest) KLineRange(ctx context.Context, Exchange, Trade, symbol, interval string, start, end time.Time, opts ...KLineOption) ([]Kline, error) {
+	it := s.KLineIter(ctx, Exchange, Trade, symbol, interval, start, end, opts...)
+	defer it.Close()
+	var out []Kline
+	for it.Next() {
+		out = append(out, it.Kline())
+	}
+	return out, it.Err()
+}
+
+// KLineIter streams the candles of symbol opening in [start, end) in time
+// order, however many KLine calls the range takes:
+//
+//	it := client.KLineIter(ctx, "Binance", "Spot", "BTC-USDT", "1m", start, end)
+//	defer it.Close()
+//	for it.Next() {
+//		k := it.Kline()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+//
+// The range is split into windows of one page of the interval, fetched
+// concurrently a few pages ahead of the reader. Candles repeated across
+// page boundaries are dropped. Intervals KLineIter does not know are paged
+// one call after another instead.
+type KLineIter struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	opts   klineOptions
+	fetch  func(ctx context.Context, from, to int64) ([]Kline, error)
+
+	interval string
+	pages    chan chan klinePage
+	sem      chan struct{}
+
+	page []Kline
+	cur  Kline
+	prev *Kline
+	err  error
+	done bool
+}
+
+type klinePage struct {
+	klines []Kline
+	err    error
+}
+
+// KLineIter returns an iterator over the candles of symbol opening in
+// [start, end). It must be closed when the caller stops early.
+func (s *SbeeRest) KLineIter(ctx context.Context, Exchange, Trade, symbol, interval string, start, end time.Time, opts ...KLineOption) *KLineIter {
+	o := klineOptions{pageSize: DefaultKLinePageSize, concurrency: DefaultKLineConcurrency}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.pageSize, o.concurrency = max(o.pageSize, 1), max(o.concurrency, 1)
+	exchange, trade := s.exchange(Exchange), s.trade(Trade)
+
+	ctx, cancel := context.WithCancel(ctx)
+	it := &KLineIter{
+		ctx:      ctx,
+		cancel:   cancel,
+		opts:     o,
+		interval: interval,
+		pages:    make(chan chan klinePage, o.concurrency),
+		sem:      make(chan struct{}, o.concurrency),
+		fetch: func(ctx context.Context, from, to int64) ([]Kline, error) {
+			return s.klinePages(ctx, exchange, trade, symbol, interval, from, to, o.pageSize)
+		},
+	}
+	go it.run(start.UnixMilli(), end.UnixMilli())
+	return it
+}
+
+// run starts one fetch per window, in order, at most concurrency ahead of
+// the reader, and hands their results to Next through it.pages.
+func (it *KLineIter) run(from, to int64) {
+	defer close(it.pages)
+	for from < to {
+		next := to
+		if t, ok := advance(time.UnixMilli(from), it.interval, it.opts.pageSize); ok {
+			next = min(to, t.UnixMilli())
+		}
+		select {
+		case it.sem <- struct{}{}:
+		case <-it.ctx.Done():
+			return
+		}
+		result := make(chan klinePage, 1)
+		it.pages <- result
+		go func(from, to int64) {
+			ks, err := it.fetch(it.ctx, from, to)
+			result <- klinePage{ks, err}
+		}(from, next)
+		from = next
+	}
+}
+
+// Next advances to the next candle and reports whether there is one. It
+// returns false at the end of the range or on the first error.
+func (it *KLineIter) Next() bool {
+	for !it.done {
+		if len(it.page) == 0 {
+			result, ok := <-it.pages
+			if !ok {
+				it.finish(nil)
+				break
+			}
+			page := <-result
+			<-it.sem
+			if page.err != nil {
+				it.finish(page.err)
+				break
+			}
+			it.page = page.klines
+			continue
+		}
+		k := it.page[0]
+		it.page = it.page[1:]
+		if it.prev != nil {
+			if openMilli(k) <= openMilli(*it.prev) {
+				continue
+			}
+			if g, ok := klineGap(*it.prev, k, it.interval); ok && it.opts.onGap != nil {
+				it.opts.onGap(g)
+			}
+		}
+		it.cur, it.prev = k, &k
+		return true
+	}
+	return false
+}
+
+func (it *KLineIter) finish(err error) {
+	it.done, it.err, it.page = true, err, nil
+	it.cancel()
+}
+
+// Kline returns the candle Next advanced to.
+func (it *KLineIter) Kline() Kline { return it.cur }
+
+// Err returns the error that ended the iteration, if any.
+func (it *KLineIter) Err() error { return it.err }
+
+// Close stops the fetches still running. Next returns false afterwards.
+func (it *KLineIter) Close() {
+	if !it.done {
+		it.finish(nil)
+	}
+}
+
+// klinePages returns the candles opening in [from, to), calling KLine again
+// from the last candle returned until the window is covered or a call adds
+// nothing.
+func (s *SbeeRest) klinePages(ctx context.Context, exchange, trade, symbol, interval string, from, to int64, limit int) ([]Kline, error) {
+	var out []Kline
+	for from < to {
+		resp, err := s.KLine(ctx, exchange, trade, symbol, interval, strconv.FormatInt(from, 10), strconv.FormatInt(to-1, 10), limit)
+		if err != nil {
+			return nil, err
+		}
+		page := resp.Data
+		sort.SliceStable(page, func(i, j int) bool { return openMilli(page[i]) < openMilli(page[j]) })
+		n := 0
+		for _, k := range page {
+			t := openMilli(k)
+			if t < from || t >= to || len(out) > 0 && t <= openMilli(out[len(out)-1]) {
+				continue
+			}
+			out = append(out, k)
+			n++
+		}
+		if n == 0 {
+			break
+		}
+		last := out[len(out)-1]
+		if last.CloseTime != 0 && last.CloseTime.Time().UnixMilli() >= to-1 {
+			break
+		}
+		if next, ok := advance(last.OpenTime.Time(), interval, 1); ok && next.UnixMilli() >= to {
+			break
+		}
+		from = openMilli(last) + 1
+	}
+	return out, nil
+}
+
+func openMilli(k Kline) int64 {
+	return k.OpenTime.Time().UnixMilli()
+}
+
+// KlineCache keeps the candles loaded through KLineRange in files under a
+// directory, one per exchange, trade, symbol and interval, so repeated
+// backtests only fetch what they have not seen. Candles still open when they
+// were fetched are not kept. A KlineCache is safe for concurrent use within
+// one process.
+type KlineCache struct {
+	client *SbeeRest
+	dir    string
+	mu     sync.Mutex
+}
+
+// NewKlineCache returns a cache stored in dir that loads missing candles
+// through client.
+func NewKlineCache(client *SbeeRest, dir string) *KlineCache {
+	return &KlineCache{client: client, dir: dir}
+}
+
+// klineCacheFile is the content of one cache file. Ranges are the merged
+// [from, to) spans, in milliseconds, known to be complete.
+type klineCacheFile struct {
+	Ranges [][2]int64 `json:"ranges"`
+	Klines []Kline    `json:"klines"`
+}
+
+// KLineRange returns the candles opening in [start, end), like
+// SbeeRest.KLineRange, fetching only the spans the cache does not cover.
+func (c *KlineCache) KLineRange(ctx context.Context, Exchange, Trade, symbol, interval string, start, end time.Time) ([]Kline, error) {
+	exchange, trade := c.client.exchange(Exchange), c.client.trade(Trade)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := filepath.Join(c.dir, cacheName(exchange, trade, symbol, interval))
+	var file klineCacheFile
+	if b, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(b, &file); err != nil {
+			return nil, fmt.Errorf("sbee: read kline cache %s: %w", path, err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	from, to := start.UnixMilli(), end.UnixMilli()
+	var fetched []Kline
+	now := time.Now().UnixMilli()
+	changed := false
+	for _, gap := range missingRanges(file.Ranges, from, to) {
+		ks, err := c.client.KLineRange(ctx, exchange, trade, symbol, interval, time.UnixMilli(gap[0]), time.UnixMilli(gap[1]))
+		if err != nil {
+			return nil, err
+		}
+		fetched = append(fetched, ks...)
+		complete := min(gap[1], now)
+		for _, k := range ks {
+			if k.CloseTime != 0 && k.CloseTime.Time().UnixMilli() >= now {
+				complete = min(complete, openMilli(k))
+				continue
+			}
+			file.Klines = append(file.Klines, k)
+		}
+		if complete > gap[0] {
+			file.Ranges = append(file.Ranges, [2]int64{gap[0], complete})
+			changed = true
+		}
+	}
+
+	if changed {
+		file.Klines = dedupeKlines(file.Klines)
+		file.Ranges = mergeRanges(file.Ranges)
+		if err := writeFileAtomic(path, file); err != nil {
+			return nil, err
+		}
+	}
+
+	var out []Kline
+	for _, k := range dedupeKlines(append(file.Klines, fetched...)) {
+		if t := openMilli(k); t >= from && t < to {
+			out = append(out, k)
+		}
+	}
+	return out, nil
+}
+
+// cacheName is the file name of one series. Upper-case letters are escaped
+// so "1m" and "1M" stay apart on case-insensitive file systems.
+func cacheName(exchange, trade, symbol, interval string) string {
+	var b strings.Builder
+	for _, r := range strings.Join([]string{exchange, trade, symbol, interval}, "_") {
+		switch {
+		case 'A' <= r && r <= 'Z':
+			b.WriteByte('^')
+			b.WriteRune(r + 'a' - 'A')
+		case 'a' <= r && r <= 'z', '0' <= r && r <= '9', r == '_', r == '-':
+			b.WriteRune(r)
+		default:
+			fmt.Fprintf(&b, "%%%02x", r)
+		}
+	}
+	return b.String() + ".json"
+}
+
+// missingRanges returns the parts of [from, to) not in the sorted, merged
+// ranges.
+func missingRanges(ranges [][2]int64, from, to int64) [][2]int64 {
+	var out [][2]int64
+	for _, r := range ranges {
+		if r[1] <= from {
+			continue
+		}
+		if r[0] >= to {
+			break
+		}
+		if r[0] > from {
+			out = append(out, [2]int64{from, r[0]})
+		}
+		from = max(from, r[1])
+	}
+	if from < to {
+		out = append(out, [2]int64{from, to})
+	}
+	return out
+}
+
+func mergeRanges(ranges [][2]int64) [][2]int64 {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+	var out [][2]int64
+	for _, r := range ranges {
+		if n := len(out); n > 0 && r[0] <= out[n-1][1] {
+			out[n-1][1] = max(out[n-1][1], r[1])
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// dedupeKlines sorts candles by open time, keeping the last of duplicates.
+func dedupeKlines(ks []Kline) []Kline {
+	sort.SliceStable(ks, func(i, j int) bool { return openMilli(ks[i]) < openMilli(ks[j]) })
+	out := ks[:0]
+	for _, k := range ks {
+		if n := len(out); n > 0 && openMilli(out[n-1]) == openMilli(k) {
+			out[n-1] = k
+			continue
+		}
+		out = append(out, k)
+	}
+	return out
+}
+
+func writeFileAtomic(path string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
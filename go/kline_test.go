@@ -0,0 +1,181 @@
+package sbee
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// klineEpoch is the open time, in minutes, of the first candle served.
+const klineEpoch = 28_000_000
+
+// klineServer serves one-minute candles opening every minute from klineEpoch,
+// honouring startTime, endTime and limit, and counts the calls.
+type klineServer struct {
+	mu       sync.Mutex
+	calls    int
+	limit    int
+	missing  map[int64]bool // minutes after klineEpoch not served
+	delay    time.Duration
+	inflight int
+	peak     int
+}
+
+func newKlineClient(t *testing.T, limit int) (*SbeeRest, *klineServer) {
+	t.Helper()
+	ks := &klineServer{limit: limit}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ks.mu.Lock()
+		ks.calls++
+		ks.inflight++
+		ks.peak = max(ks.peak, ks.inflight)
+		ks.mu.Unlock()
+		time.Sleep(ks.delay)
+		defer func() {
+			ks.mu.Lock()
+			ks.inflight--
+			ks.mu.Unlock()
+		}()
+		q := r.URL.Query()
+		from, _ := strconv.ParseInt(q.Get("startTime"), 10, 64)
+		to, _ := strconv.ParseInt(q.Get("endTime"), 10, 64)
+		n, _ := strconv.Atoi(q.Get("limit"))
+		n = min(n, ks.limit)
+		var out []string
+		// Start a minute early so pages overlap the previous one, as some
+		// exchanges do.
+		for t := max(klineEpoch*60000, from/60000*60000-60000); t <= to && len(out) < n; t += 60000 {
+			if ks.missing[t/60000-klineEpoch] {
+				continue
+			}
+			out = append(out, fmt.Sprintf(`{"openTime":%d,"open":"1","high":"1","low":"1","close":"%d","volume":"1","closeTime":%d}`, t, t/60000, t+59999))
+		}
+		fmt.Fprintf(w, `{"isSuccess":true,"data":[%s]}`, strings.Join(out, ","))
+	}))
+	t.Cleanup(srv.Close)
+	return NewClient("token", WithBaseURL(srv.URL), WithDefaultExchange("Binance"), WithDefaultTrade("Spot")), ks
+}
+
+func checkMinutes(t *testing.T, ks []Kline, from, to int64) {
+	t.Helper()
+	if len(ks) != int(to-from) {
+		t.Fatalf("got %d candles, want %d", len(ks), to-from)
+	}
+	for i, k := range ks {
+		if want := (klineEpoch + from + int64(i)) * 60000; openMilli(k) != want {
+			t.Fatalf("candle %d opens at %d, want %d", i, openMilli(k), want)
+		}
+	}
+}
+
+func TestKLineRange(t *testing.T) {
+	client, server := newKlineClient(t, 7)
+	ks, err := client.KLineRange(context.Background(), "", "", "BTC-USDT", "1m", time.UnixMilli((klineEpoch+10)*60000), time.UnixMilli((klineEpoch+30)*60000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkMinutes(t, ks, 10, 30)
+	if server.calls < 3 {
+		t.Errorf("%d calls for 20 candles in pages of 7", server.calls)
+	}
+}
+
+func TestKLineIter(t *testing.T) {
+	client, server := newKlineClient(t, 1000)
+	server.missing = map[int64]bool{22: true, 23: true}
+	server.delay = 10 * time.Millisecond
+	var gaps []KlineGap
+	it := client.KLineIter(context.Background(), "", "", "BTC-USDT", "1m", time.UnixMilli((klineEpoch+10)*60000), time.UnixMilli((klineEpoch+50)*60000),
+		WithKLinePageSize(5), WithKLineConcurrency(3), WithKLineGapHandler(func(g KlineGap) { gaps = append(gaps, g) }))
+	defer it.Close()
+	var ks []Kline
+	for it.Next() {
+		ks = append(ks, it.Kline())
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	checkMinutes(t, ks[:12], 10, 22)
+	checkMinutes(t, ks[12:], 24, 50)
+	if len(gaps) != 1 || gaps[0].Missing != 2 || gaps[0].From.UnixMilli() != (klineEpoch+22)*60000 || gaps[0].To.UnixMilli() != (klineEpoch+24)*60000 {
+		t.Errorf("gaps = %+v", gaps)
+	}
+	server.mu.Lock()
+	if server.peak < 2 || server.peak > 3 {
+		t.Errorf("%d calls in flight, want 2 or 3", server.peak)
+	}
+	server.mu.Unlock()
+
+	// Stopping early ends the iteration.
+	it = client.KLineIter(context.Background(), "", "", "BTC-USDT", "1m", time.UnixMilli(klineEpoch*60000), time.UnixMilli((klineEpoch+1000)*60000), WithKLinePageSize(5))
+	it.Next()
+	it.Close()
+	if it.Next() || it.Err() != nil {
+		t.Errorf("Next after Close = true or err %v", it.Err())
+	}
+}
+
+func TestIntervals(t *testing.T) {
+	for interval, want := range map[string]time.Duration{"1s": time.Second, "15m": 15 * time.Minute, "4h": 4 * time.Hour, "1d": 24 * time.Hour, "1w": 7 * 24 * time.Hour} {
+		if got, ok := IntervalDuration(interval); !ok || got != want {
+			t.Errorf("IntervalDuration(%q) = %v, %v", interval, got, ok)
+		}
+	}
+	for _, interval := range []string{"1M", "m", "0m", "1x", ""} {
+		if _, ok := IntervalDuration(interval); ok {
+			t.Errorf("IntervalDuration(%q) ok", interval)
+		}
+	}
+
+	month := func(m time.Month) Kline {
+		return Kline{OpenTime: Timestamp(time.Date(2024, m, 1, 0, 0, 0, 0, time.UTC).UnixMilli())}
+	}
+	gaps := KlineGaps([]Kline{month(1), month(2), month(5), month(6)}, "1M")
+	if len(gaps) != 1 || gaps[0].Missing != 2 || gaps[0].From.Month() != 3 {
+		t.Errorf("monthly gaps = %+v", gaps)
+	}
+}
+
+func TestKlineCache(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	client, server := newKlineClient(t, 1000)
+	minute := func(m int64) time.Time { return time.UnixMilli((klineEpoch + m) * 60000) }
+
+	cache := NewKlineCache(client, dir)
+	ks, err := cache.KLineRange(ctx, "", "", "BTC-USDT", "1m", minute(10), minute(20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkMinutes(t, ks, 10, 20)
+
+	// A new cache over the same directory fetches only the uncovered
+	// spans either side.
+	server.calls = 0
+	cache = NewKlineCache(client, dir)
+	ks, err = cache.KLineRange(ctx, "", "", "BTC-USDT", "1m", minute(5), minute(25))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkMinutes(t, ks, 5, 25)
+	if server.calls != 2 {
+		t.Errorf("%d calls to extend the cache, want 2", server.calls)
+	}
+
+	server.calls = 0
+	ks, _ = cache.KLineRange(ctx, "Binance", "Spot", "BTC-USDT", "1m", minute(6), minute(24))
+	checkMinutes(t, ks, 6, 24)
+	if server.calls != 0 {
+		t.Errorf("%d calls for a cached range", server.calls)
+	}
+
+	if cacheName("Binance", "Spot", "BTC-USDT", "1M") == cacheName("Binance", "Spot", "BTC-USDT", "1m") {
+		t.Error("1M and 1m share a cache file")
+	}
+}
@@ -0,0 +1,256 @@
+package sbee
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// Response is the envelope every sbee endpoint wraps its payload in. Raw keeps
+// the undecoded body so fields this SDK does not model yet stay reachable.
+type Response[T any] struct {
+	IsSuccess  bool   `json:"isSuccess"`
+	ResultType int    `json:"resultType"`
+	Message    string `json:"message"`
+	ErrorCode  string `json:"errorCode"`
+	TotalCount int    `json:"totalCount"`
+	Data       T      `json:"data"`
+
+	Raw json.RawMessage `json:"-"`
+}
+
+// Timestamp is a Unix time as sent by sbee, in milliseconds or, for some
+// exchanges, seconds. It decodes from both JSON numbers and numeric strings.
+type Timestamp int64
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *Timestamp) UnmarshalJSON(b []byte) error {
+	b = bytes.Trim(b, `"`)
+	if len(b) == 0 || string(b) == "null" {
+		*t = 0
+		return nil
+	}
+	n, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return err
+	}
+	*t = Timestamp(n)
+	return nil
+}
+
+// Time converts t to a time.Time, treating values below 1e12 as seconds.
+func (t Timestamp) Time() time.Time {
+	if t < 1e12 {
+		return time.Unix(int64(t), 0)
+	}
+	return time.UnixMilli(int64(t))
+}
+
+// Trade is a single fulfilled trade.
+type Trade struct {
+	Symbol    string    `json:"symbol"`
+	Amount    Decimal   `json:"amount"`
+	Price     Decimal   `json:"price"`
+	Side      string    `json:"side"`
+	Timestamp Timestamp `json:"timestamp"`
+}
+
+// RecentTrades is the payload of RecentTrades: the latest trades and the
+// buy/sell volume balance between them.
+type RecentTrades struct {
+	TotalBuyVolume  Decimal `json:"totalBuyVolume"`
+	TotalSellVolume Decimal `json:"totalSellVolume"`
+	BiggerVolume    string  `json:"biggerVolume"`
+	Percentage      Decimal `json:"percentage"`
+	Trades          []Trade `json:"recentTrades"`
+}
+
+// Currency is a tradable pair and its precision rules.
+type Currency struct {
+	Symbol        string  `json:"symbol"`
+	BaseCurrency  string  `json:"baseCurrency"`
+	QuoteCurrency string  `json:"quoteCurrency"`
+	IsTradable    bool    `json:"isTradable"`
+	Logo          string  `json:"logo"`
+	PriceScale    int     `json:"priceScale"`
+	QuantityScale int     `json:"quantityScale"`
+	MinQuantity   Decimal `json:"minQuantity"`
+	MinNotional   Decimal `json:"minNotional"`
+}
+
+// Balance is the wallet balance of one asset.
+type Balance struct {
+	Symbol string  `json:"symbol"`
+	Free   Decimal `json:"free"`
+	Locked Decimal `json:"locked"`
+	Total  Decimal `json:"total"`
+}
+
+// AccountBalances is one account's result of TradingBalancesForPeople.
+type AccountBalances struct {
+	IsSuccess    bool      `json:"isSuccess"`
+	ErrorMessage string    `json:"errorMessage"`
+	ErrorCode    string    `json:"errorCode"`
+	APIKey       string    `json:"apiKey"`
+	Balances     []Balance `json:"balances"`
+}
+
+// Order is an order as reported by the exchange.
+type Order struct {
+	Symbol           string    `json:"symbol"`
+	OrderID          string    `json:"orderId"`
+	ClientOrderID    string    `json:"clientOrderId"`
+	Side             string    `json:"side"`
+	Type             string    `json:"type"`
+	Status           string    `json:"status"`
+	Price            Decimal   `json:"price"`
+	BaseQuantity     Decimal   `json:"baseQuantity"`
+	QuoteQuantity    Decimal   `json:"quoteQuantity"`
+	ExecutedQuantity Decimal   `json:"executedQuantity"`
+	Timestamp        Timestamp `json:"timestamp"`
+}
+
+// OrderResult is the per-order outcome of the batch and ForPeople calls.
+type OrderResult struct {
+	Order
+	IsSuccess    bool   `json:"isSuccess"`
+	ErrorMessage string `json:"errorMessage"`
+	ErrorCode    string `json:"errorCode"`
+	APIKey       string `json:"apiKey"`
+}
+
+// Kline is one candlestick.
+type Kline struct {
+	OpenTime    Timestamp `json:"openTime"`
+	Open        Decimal   `json:"open"`
+	High        Decimal   `json:"high"`
+	Low         Decimal   `json:"low"`
+	Close       Decimal   `json:"close"`
+	Volume      Decimal   `json:"volume"`
+	CloseTime   Timestamp `json:"closeTime"`
+	QuoteVolume Decimal   `json:"quoteVolume"`
+}
+
+// FormationSeries is the output of one formation of a KlineFormation call.
+type FormationSeries struct {
+	Formation string    `json:"formation"`
+	Values    []Decimal `json:"values"`
+}
+
+// PriceLevel is one price of an order book side.
+type PriceLevel struct {
+	Price         Decimal `json:"price"`
+	Size          Decimal `json:"size"`
+	CumulativeSum Decimal `json:"cumulativeSum"`
+}
+
+// OrderBook is a depth snapshot. Price is the mid price and BiggerVolume names
+// the heavier side ("Bids" or "Asks"). LastUpdateID is the exchange sequence
+// number of the snapshot, or zero when the exchange does not report one.
+type OrderBook struct {
+	Price        Decimal      `json:"price"`
+	TotalBidVol  Decimal      `json:"totalBidVol"`
+	TotalAskVol  Decimal      `json:"totalAskVol"`
+	Percentage   Decimal      `json:"percentage"`
+	BiggerVolume string       `json:"biggerVolume"`
+	LastUpdateID int64        `json:"lastUpdateId"`
+	Asks         []PriceLevel `json:"asks"`
+	Bids         []PriceLevel `json:"bids"`
+}
+
+// Ticker is the 24h summary of a symbol.
+type Ticker struct {
+	Symbol      string  `json:"symbol"`
+	BaseSymbol  string  `json:"baseSymbol"`
+	QuoteSymbol string  `json:"quoteSymbol"`
+	Open24h     Decimal `json:"open24h"`
+	High24h     Decimal `json:"high24h"`
+	Low24h      Decimal `json:"low24h"`
+	Vol24h      Decimal `json:"vol24h"`
+	Last        Decimal `json:"last"`
+	Change      Decimal `json:"change"`
+	Logo        string  `json:"logo"`
+}
+
+// Leverage is the result of SetLeverage.
+type Leverage struct {
+	Symbol   string  `json:"symbol"`
+	Leverage Decimal `json:"leverage"`
+}
+
+// MarketEndPoint is an endpoint an exchange supports through sbee.
+type MarketEndPoint struct {
+	EndPoint string `json:"endPoint"`
+	Note     string `json:"note"`
+	IsActive bool   `json:"isActive"`
+}
+
+// Market describes an exchange reachable through sbee.
+type Market struct {
+	Name                string           `json:"name"`
+	IsInDevelopment     bool             `json:"isInDevelopment"`
+	Logo                string           `json:"logo"`
+	MainThemeColor      string           `json:"mainThemeColor"`
+	SecondaryThemeColor string           `json:"secondaryThemeColor"`
+	TertiaryThemeColor  string           `json:"tertiaryThemeColor"`
+	MarketEndPoints     []MarketEndPoint `json:"marketEndPoints"`
+}
+
+// MoneyPair is the value of one unit of BaseCurrency in QuoteCurrency.
+type MoneyPair struct {
+	BaseCurrency  string  `json:"baseCurrency"`
+	QuoteCurrency string  `json:"quoteCurrency"`
+	Value         Decimal `json:"value"`
+}
+
+// ExchangeStatus reports whether one exchange of a MultiMarket call answered.
+type ExchangeStatus struct {
+	ExchangeName string `json:"exchangeName"`
+	IsSuccess    bool   `json:"isSuccess"`
+	ErrorMessage string `json:"errorMessage"`
+	ErrorCode    string `json:"errorCode"`
+}
+
+// MultiOrderBook is the payload of MultiOrderBook: one book merged from every
+// exchange that answered.
+type MultiOrderBook struct {
+	Exchanges []ExchangeStatus `json:"exchanges"`
+	OrderBook OrderBook        `json:"orderBook"`
+}
+
+// MultiRecentTrades is the payload of MultiRecentTrades.
+type MultiRecentTrades struct {
+	Exchanges    []ExchangeStatus `json:"exchanges"`
+	RecentTrades RecentTrades     `json:"multiRecentTrades"`
+}
+
+// SteppedBook is the merged book at one price step.
+type SteppedBook struct {
+	Step      int              `json:"step"`
+	Exchanges []ExchangeStatus `json:"exchanges"`
+	OrderBook OrderBook        `json:"orderBook"`
+}
+
+// SteppedOrderBook is the payload of SteppedOrderBook.
+type SteppedOrderBook struct {
+	OrderBooks []SteppedBook `json:"orderBooks"`
+}
+
+// NewsItem is one article of the news feed.
+type NewsItem struct {
+	Language    string `json:"language"`
+	Title       string `json:"title"`
+	PubDate     string `json:"pubDate"`
+	Description string `json:"description"`
+	Detail      string `json:"detail"`
+	ImageLink   string `json:"imageLink"`
+	SourceURL   string `json:"sourceUrl"`
+}
+
+// Country is an entry of the country list.
+type Country struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Code string `json:"code"`
+}
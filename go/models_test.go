@@ -0,0 +1,66 @@
+package sbee
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestTypedResponses(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OrderBook", func(t *testing.T) {
+		s, _ := newTestServer(t, `{"isSuccess":true,"resultType":0,"message":"","errorCode":"","totalCount":10,
+			"data":{"price":"41992.00","totalBidVol":"48319","totalAskVol":"300168","percentage":521.22,"biggerVolume":"Asks",
+			"asks":[{"price":"41994.11000000","size":"0.29393000","cumulativeSum":"300168.11"}],
+			"bids":[{"price":"41992.00000000","size":"0.79370000","cumulativeSum":"33329.05040000"}],
+			"sequence":991}}`)
+		resp, err := s.OrderBook(ctx, "Binance", "Spot", "BTC-USDT", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		book := resp.Data
+		if book.BiggerVolume != "Asks" || len(book.Asks) != 1 || len(book.Bids) != 1 {
+			t.Fatalf("book = %+v", book)
+		}
+		if book.Asks[0].Price.String() != "41994.11000000" || book.Bids[0].Size.String() != "0.79370000" {
+			t.Errorf("levels = %+v / %+v", book.Asks[0], book.Bids[0])
+		}
+		if resp.TotalCount != 10 {
+			t.Errorf("TotalCount = %d", resp.TotalCount)
+		}
+		if !strings.Contains(string(resp.Raw), `"sequence":991`) {
+			t.Errorf("Raw lost unmodelled fields: %s", resp.Raw)
+		}
+	})
+
+	t.Run("RecentTrades", func(t *testing.T) {
+		s, _ := newTestServer(t, `{"isSuccess":true,"data":{"totalBuyVolume":"4856","totalSellVolume":"15224","biggerVolume":"Sell",
+			"percentage":213.51,"recentTrades":[{"symbol":"BTC-USDT","amount":"0.00013","price":"41987.53","side":"buy","timestamp":"1702303550847"}]}}`)
+		resp, err := s.RecentTrades(ctx, "Binance", "Spot", "BTC-USDT", "10")
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr := resp.Data.Trades
+		if len(tr) != 1 || tr[0].Price.String() != "41987.53" || tr[0].Timestamp.Time().UnixMilli() != 1702303550847 {
+			t.Errorf("trades = %+v", tr)
+		}
+	})
+
+	t.Run("SystemTime", func(t *testing.T) {
+		s, _ := newTestServer(t, `{"isSuccess":true,"data":1702303552236}`)
+		resp, err := s.SystemTime(ctx, "Binance")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Data != 1702303552236 {
+			t.Errorf("SystemTime = %d", resp.Data)
+		}
+	})
+}
+
+func TestTimestampSeconds(t *testing.T) {
+	if got := Timestamp(1702285765).Time().Unix(); got != 1702285765 {
+		t.Errorf("seconds timestamp decoded as %d", got)
+	}
+}
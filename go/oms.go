@@ -0,0 +1,519 @@
+package sbee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OrderState is where an order is in its lifecycle.
+type OrderState int
+
+const (
+	// OrderPending is an order sent whose outcome is not known yet.
+	OrderPending OrderState = iota
+	// OrderAcknowledged is an order the exchange accepted and that has not
+	// filled.
+	OrderAcknowledged
+	OrderPartiallyFilled
+	OrderFilled
+	// OrderCancelled is an order cancelled or expired, possibly after a
+	// partial fill.
+	OrderCancelled
+	// OrderRejected is an order the API or the instrument rules refused.
+	OrderRejected
+	// OrderUnknown is an order whose placement failed without an answer
+	// and that has neither an orderId nor a ClientOrderId to reconcile it
+	// by, see WithClientOrderIDs. It may have been placed.
+	OrderUnknown
+)
+
+func (s OrderState) String() string {
+	switch s {
+	case OrderPending:
+		return "pending"
+	case OrderAcknowledged:
+		return "acknowledged"
+	case OrderPartiallyFilled:
+		return "partially filled"
+	case OrderFilled:
+		return "filled"
+	case OrderCancelled:
+		return "cancelled"
+	case OrderRejected:
+		return "rejected"
+	case OrderUnknown:
+		return "unknown"
+	}
+	return fmt.Sprintf("OrderState(%d)", int(s))
+}
+
+// Terminal reports whether no further state can follow s.
+func (s OrderState) Terminal() bool {
+	return s >= OrderFilled
+}
+
+// StateOf maps the status reported by the exchange to an OrderState. An
+// unknown status is acknowledged, or partially filled once something
+// executed.
+func StateOf(o Order) OrderState {
+	status := strings.NewReplacer("_", "", " ", "", "-", "").Replace(strings.ToUpper(o.Status))
+	switch status {
+	case "FILLED":
+		return OrderFilled
+	case "PARTIALLYFILLED":
+		return OrderPartiallyFilled
+	case "CANCELED", "CANCELLED", "EXPIRED", "PARTIALLYCANCELED", "PARTIALLYCANCELLED":
+		return OrderCancelled
+	case "REJECTED", "FAILED":
+		return OrderRejected
+	}
+	if o.ExecutedQuantity.Sign() > 0 {
+		return OrderPartiallyFilled
+	}
+	return OrderAcknowledged
+}
+
+// TrackedOrder is the OMS view of one order.
+type TrackedOrder struct {
+	Exchange      string
+	Trade         string
+	Symbol        string
+	ClientOrderID string
+	// OrderID is the exchange's id, empty until the order is acknowledged.
+	OrderID string
+	State   OrderState
+	// Order is the last report of the order by the exchange.
+	Order Order
+	// Err is why the order was rejected, or the last error placing or
+	// cancelling it.
+	Err     error
+	Created time.Time
+	Updated time.Time
+}
+
+// OrderEvent reports a change of state. The first event of an order has
+// State OrderPending and Previous OrderPending.
+type OrderEvent struct {
+	Order    TrackedOrder
+	Previous OrderState
+	// Seq numbers the events of the OMS from 1, dropped ones included, so a
+	// gap tells the consumer it missed some.
+	Seq uint64
+}
+
+// OMSOption configures an OMS.
+type OMSOption func(*OMS)
+
+// WithOMSPollInterval sets how often Run reconciles open orders through
+// OrderHistory. The default is two seconds.
+func WithOMSPollInterval(d time.Duration) OMSOption {
+	return func(m *OMS) {
+		m.pollInterval = d
+	}
+}
+
+// WithOMSEventBuffer sets the capacity of the Events channel. The default is
+// 256.
+func WithOMSEventBuffer(n int) OMSOption {
+	return func(m *OMS) {
+		m.events = make(chan OrderEvent, n)
+	}
+}
+
+// OMS tracks the orders of one account from placement to a terminal state.
+// Orders are keyed by ClientOrderId and by exchange orderId; their state is
+// advanced by the placement and cancel responses and by reconciling against
+// OrderHistory, and every change is reported on Events. An OMS is safe for
+// concurrent use.
+type OMS struct {
+	client       *SbeeRest
+	credentials  Credentials
+	pollInterval time.Duration
+	events       chan OrderEvent
+
+	mu       sync.Mutex
+	orders   []*TrackedOrder
+	byClient map[string]*TrackedOrder
+	byID     map[string]*TrackedOrder // exchange/orderId
+	seq      uint64
+	dropped  int64
+}
+
+// NewOMS returns an OMS placing and tracking orders with credentials, which
+// may name an Account whose keys are resolved on every request.
+func NewOMS(client *SbeeRest, credentials Credentials, opts ...OMSOption) *OMS {
+	m := &OMS{
+		client:       client,
+		credentials:  credentials,
+		pollInterval: 2 * time.Second,
+		events:       make(chan OrderEvent, 256),
+		byClient:     map[string]*TrackedOrder{},
+		byID:         map[string]*TrackedOrder{},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Events returns the channel on which state changes are reported. The OMS
+// never blocks on it: events that find the channel full are dropped and
+// counted by Dropped, and leave a gap in Seq. The orders themselves stay
+// current, so a consumer that misses events resynchronizes from Orders.
+func (m *OMS) Events() <-chan OrderEvent {
+	return m.events
+}
+
+// Dropped returns the number of events dropped because the Events channel
+// was full.
+func (m *OMS) Dropped() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.dropped
+}
+
+// PlaceLimitOrder places a limit order through SbeeRest.PlaceLimitOrder and
+// tracks it. An empty ClientOrderId is replaced by one from the client's
+// generator before the order is sent; one that an open order already holds
+// is refused with an *OrderError. The returned order is rejected when the
+// API refused it, and still pending when the outcome is unknown, e.g. after
+// a network failure; reconciling settles it later.
+func (m *OMS) PlaceLimitOrder(ctx context.Context, Exchange, Trade, symbol, ClientOrderId string, price, quoteQuantity, baseQuantity Decimal, side string) (TrackedOrder, error) {
+	t, err := m.track("PlaceLimitOrder", Exchange, Trade, symbol, m.clientOrderID(Exchange, ClientOrderId))
+	if err != nil {
+		return TrackedOrder{}, err
+	}
+	resp, err := m.client.placeLimitOrder(ctx, t.Exchange, t.Trade, symbol, t.ClientOrderID, price, quoteQuantity, baseQuantity, side, m.credentials)
+	return m.placed(t, resp, err)
+}
+
+// PlaceMarketOrder places a market order through SbeeRest.PlaceMarketOrder
+// and tracks it, like PlaceLimitOrder.
+func (m *OMS) PlaceMarketOrder(ctx context.Context, Exchange, Trade, symbol, ClientOrderId string, price, quoteQuantity, baseQuantity Decimal, leverage, contract int, side string) (TrackedOrder, error) {
+	t, err := m.track("PlaceMarketOrder", Exchange, Trade, symbol, m.clientOrderID(Exchange, ClientOrderId))
+	if err != nil {
+		return TrackedOrder{}, err
+	}
+	resp, err := m.client.placeMarketOrder(ctx, t.Exchange, t.Trade, symbol, t.ClientOrderID, price, quoteQuantity, baseQuantity, leverage, contract, side, m.credentials)
+	return m.placed(t, resp, err)
+}
+
+// Track adds an order placed elsewhere, e.g. by a batch call, or updates it
+// if it is already tracked, and returns its tracked view.
+func (m *OMS) Track(Exchange, Trade string, o Order) TrackedOrder {
+	exchange := m.client.exchange(Exchange)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t := m.lookup(exchange, o)
+	if t == nil {
+		t = m.add(exchange, m.client.trade(Trade), o.Symbol, o.ClientOrderID)
+	}
+	m.update(t, o)
+	return *t
+}
+
+// Order returns the order with clientOrderID.
+func (m *OMS) Order(clientOrderID string) (TrackedOrder, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.byClient[clientOrderID]
+	if !ok {
+		return TrackedOrder{}, false
+	}
+	return *t, true
+}
+
+// OrderByID returns the order with the exchange's orderID.
+func (m *OMS) OrderByID(Exchange, orderID string) (TrackedOrder, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.byID[idKey(m.client.exchange(Exchange), orderID)]
+	if !ok {
+		return TrackedOrder{}, false
+	}
+	return *t, true
+}
+
+// Orders returns every tracked order in placement order.
+func (m *OMS) Orders() []TrackedOrder {
+	return m.filter(func(*TrackedOrder) bool { return true })
+}
+
+// OpenOrders returns the orders not yet in a terminal state.
+func (m *OMS) OpenOrders() []TrackedOrder {
+	return m.filter(func(t *TrackedOrder) bool { return !t.State.Terminal() })
+}
+
+func (m *OMS) filter(keep func(*TrackedOrder) bool) []TrackedOrder {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []TrackedOrder
+	for _, t := range m.orders {
+		if keep(t) {
+			out = append(out, *t)
+		}
+	}
+	return out
+}
+
+// Run reconciles the open orders every poll interval until ctx is done.
+// Failed reconciliations are retried on the next tick.
+func (m *OMS) Run(ctx context.Context) error {
+	t := time.NewTicker(m.pollInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+			m.Reconcile(ctx)
+		}
+	}
+}
+
+// Reconcile fetches OrderHistory for every exchange, trade and symbol with
+// open orders and applies what it reports. Orders are matched by orderId,
+// or by ClientOrderId while the orderId is unknown. The errors of the
+// markets that could not be queried are joined.
+func (m *OMS) Reconcile(ctx context.Context) error {
+	type market struct{ exchange, trade, symbol string }
+	var markets []market
+	seen := map[market]bool{}
+	for _, t := range m.OpenOrders() {
+		k := market{t.Exchange, t.Trade, t.Symbol}
+		if !seen[k] {
+			seen[k] = true
+			markets = append(markets, k)
+		}
+	}
+
+	var errs []error
+	for _, k := range markets {
+		resp, err := m.client.orderHistory(ctx, k.exchange, k.trade, k.symbol, "ALL", m.credentials)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		m.mu.Lock()
+		for _, o := range resp.Data {
+			if t := m.lookup(k.exchange, o); t != nil {
+				m.update(t, o)
+			}
+		}
+		m.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// Cancel cancels the order with clientOrderID through CancelOrder.
+func (m *OMS) Cancel(ctx context.Context, clientOrderID string) (TrackedOrder, error) {
+	m.mu.Lock()
+	t, ok := m.byClient[clientOrderID]
+	var cur TrackedOrder
+	if ok {
+		cur = *t
+	}
+	m.mu.Unlock()
+	if !ok {
+		return TrackedOrder{}, fmt.Errorf("sbee: cancel %s: %w", clientOrderID, ErrOrderNotFound)
+	}
+	if cur.State.Terminal() {
+		return cur, nil
+	}
+
+	resp, err := m.client.cancelOrder(ctx, cur.Exchange, cur.Trade, cur.Symbol, cur.OrderID, cur.ClientOrderID, m.credentials)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		t.Err = err
+		return *t, err
+	}
+	o := resp.Data
+	if !StateOf(o).Terminal() {
+		// The cancel succeeded even if the echoed order still reads open.
+		o.Status = "CANCELED"
+	}
+	m.update(t, o)
+	return *t, nil
+}
+
+// CancelAll cancels every open order of symbol through CancelOrdersBySymbol.
+// Tracked orders the response lists are updated from it; the others settle
+// on the next Reconcile.
+func (m *OMS) CancelAll(ctx context.Context, Exchange, Trade, symbol string) ([]TrackedOrder, error) {
+	exchange := m.client.exchange(Exchange)
+	resp, err := m.client.cancelOrdersBySymbol(ctx, exchange, m.client.trade(Trade), symbol, m.credentials)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []TrackedOrder
+	for _, o := range resp.Data {
+		if o.Status == "" {
+			o.Status = "CANCELED"
+		}
+		if t := m.lookup(exchange, o); t != nil {
+			m.update(t, o)
+			out = append(out, *t)
+		}
+	}
+	return out, nil
+}
+
+func (m *OMS) clientOrderID(Exchange, id string) string {
+	if id == "" {
+		return m.client.newClientOrderID(m.client.exchange(Exchange))
+	}
+	return id
+}
+
+// track registers a new pending order to be placed through endpoint. It
+// refuses a ClientOrderId that an open order already holds, as that order
+// could no longer be cancelled or reconciled by it.
+func (m *OMS) track(endpoint, Exchange, Trade, symbol, clientOrderID string) (*TrackedOrder, error) {
+	exchange := m.client.exchange(Exchange)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if t, ok := m.byClient[clientOrderID]; ok && !t.State.Terminal() {
+		return nil, &OrderError{Endpoint: endpoint, Exchange: exchange, Symbol: symbol, Field: "clientOrderId", Value: clientOrderID, Reason: "is held by an open order"}
+	}
+	return m.add(exchange, m.client.trade(Trade), symbol, clientOrderID), nil
+}
+
+// add registers a new pending order. The caller holds m.mu.
+func (m *OMS) add(exchange, trade, symbol, clientOrderID string) *TrackedOrder {
+	now := time.Now()
+	t := &TrackedOrder{
+		Exchange:      exchange,
+		Trade:         trade,
+		Symbol:        symbol,
+		ClientOrderID: clientOrderID,
+		State:         OrderPending,
+		Created:       now,
+		Updated:       now,
+	}
+	m.orders = append(m.orders, t)
+	if clientOrderID != "" {
+		m.byClient[clientOrderID] = t
+	}
+	m.emit(t, OrderPending)
+	return t
+}
+
+// placed applies the outcome of a placement call.
+func (m *OMS) placed(t *TrackedOrder, resp *Response[Order], err error) (TrackedOrder, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		t.Err = err
+		switch {
+		case rejected(err):
+			m.setState(t, OrderRejected)
+		case t.ClientOrderID == "":
+			// Reconcile could never match it.
+			m.setState(t, OrderUnknown)
+		}
+		return *t, err
+	}
+	m.update(t, resp.Data)
+	return *t, nil
+}
+
+// rejected reports whether err means the order was refused, as opposed to an
+// outcome that is unknown: an *OrderError, an account without keys, or an
+// API answer with isSuccess false or a 4xx status. Timeouts, 408 and 425,
+// and rate limiting, 429 or an error matching ErrRateLimited, leave the order
+// pending for Reconcile to settle, as do 5xx statuses and failures without an
+// answer.
+func rejected(err error) bool {
+	var orderErr *OrderError
+	if errors.As(err, &orderErr) {
+		return true
+	}
+	var apiErr *APIError
+	switch {
+	case !errors.As(err, &apiErr):
+		return false
+	case apiErr.Kind == KindCredentials:
+		return true
+	case apiErr.Kind != KindAPI || errors.Is(apiErr, ErrRateLimited):
+		return false
+	}
+	switch code := apiErr.StatusCode; {
+	case code == http.StatusRequestTimeout, code == http.StatusTooEarly:
+		return false
+	case code == 0, code == http.StatusOK:
+		return true
+	default:
+		return code >= 400 && code < 500
+	}
+}
+
+// lookup finds the tracked order o reports. The caller holds m.mu.
+func (m *OMS) lookup(exchange string, o Order) *TrackedOrder {
+	if o.OrderID != "" {
+		if t, ok := m.byID[idKey(exchange, o.OrderID)]; ok {
+			return t
+		}
+	}
+	if o.ClientOrderID != "" {
+		if t, ok := m.byClient[o.ClientOrderID]; ok && strings.EqualFold(t.Exchange, exchange) {
+			return t
+		}
+	}
+	return nil
+}
+
+// update records a report of the order by the exchange. The caller holds
+// m.mu.
+func (m *OMS) update(t *TrackedOrder, o Order) {
+	if o.Symbol == "" {
+		o.Symbol = t.Symbol
+	}
+	if o.ClientOrderID == "" {
+		o.ClientOrderID = t.ClientOrderID
+	}
+	t.Order = o
+	if t.OrderID == "" && o.OrderID != "" {
+		t.OrderID = o.OrderID
+		m.byID[idKey(t.Exchange, o.OrderID)] = t
+	}
+	if t.ClientOrderID == "" && o.ClientOrderID != "" {
+		t.ClientOrderID = o.ClientOrderID
+		m.byClient[o.ClientOrderID] = t
+	}
+	t.Updated = time.Now()
+	m.setState(t, StateOf(o))
+}
+
+// setState moves t to state unless t is already terminal or state would go
+// back, as a stale report can. The caller holds m.mu.
+func (m *OMS) setState(t *TrackedOrder, state OrderState) {
+	if t.State.Terminal() || state <= t.State {
+		return
+	}
+	prev := t.State
+	t.State = state
+	t.Updated = time.Now()
+	m.emit(t, prev)
+}
+
+// emit reports t without blocking. The caller holds m.mu.
+func (m *OMS) emit(t *TrackedOrder, prev OrderState) {
+	m.seq++
+	select {
+	case m.events <- OrderEvent{Order: *t, Previous: prev, Seq: m.seq}:
+	default:
+		m.dropped++
+	}
+}
+
+func idKey(exchange, orderID string) string {
+	return strings.ToLower(exchange) + "/" + orderID
+}
@@ -0,0 +1,173 @@
+package sbee
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestOMSLifecycle(t *testing.T) {
+	var mu sync.Mutex
+	histories := 0
+	var cancels []CancelOrderRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		endpoint := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		mu.Lock()
+		defer mu.Unlock()
+		switch endpoint {
+		case "PlaceLimitOrder":
+			var req PlaceLimitOrderRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.ClientOrderID == "1002" {
+				io.WriteString(w, `{"isSuccess":false,"message":"Account has insufficient balance for requested action.","errorCode":"-2010"}`)
+				return
+			}
+			io.WriteString(w, `{"isSuccess":true,"data":{"symbol":"BTC-USDT","orderId":"5`+req.ClientOrderID[1:]+`","clientOrderId":"`+req.ClientOrderID+`","status":"NEW"}}`)
+		case "OrderHistory":
+			histories++
+			status := "PARTIALLY_FILLED"
+			if histories > 1 {
+				status = "FILLED"
+			}
+			io.WriteString(w, `{"isSuccess":true,"data":[{"orderId":"5001","clientOrderId":"1001","status":"`+status+`","executedQuantity":"0.5"},{"orderId":"5003","clientOrderId":"1003","status":"NEW"}]}`)
+		case "CancelOrder":
+			var req CancelOrderRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			cancels = append(cancels, req)
+			io.WriteString(w, `{"isSuccess":true,"data":{"orderId":"5003","status":"CANCELED"}}`)
+		case "CancelOrdersBySymbol":
+			io.WriteString(w, `{"isSuccess":true,"data":[{"orderId":"5004","clientOrderId":"1004","status":"CANCELED"}]}`)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	oms := NewOMS(NewClient("token", WithBaseURL(srv.URL), WithRetryPolicy(RetryPolicy{})), Credentials{APIKey: "key"})
+	place := func(id string) (TrackedOrder, error) {
+		return oms.PlaceLimitOrder(ctx, "Binance", "Spot", "BTC-USDT", id, d("100"), Decimal{}, d("1"), SideBuy)
+	}
+
+	if o, err := place("1001"); err != nil || o.State != OrderAcknowledged || o.OrderID != "5001" {
+		t.Fatalf("place 1001 = %+v, %v", o, err)
+	}
+	if o, err := place("1002"); !errors.Is(err, ErrInsufficientBalance) || o.State != OrderRejected {
+		t.Errorf("place 1002 = %v, %v", o.State, err)
+	}
+	place("1003")
+
+	if err := oms.Reconcile(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if o, _ := oms.OrderByID("Binance", "5001"); o.State != OrderPartiallyFilled || o.Order.ExecutedQuantity.String() != "0.5" {
+		t.Errorf("after first reconcile: %v, executed %s", o.State, o.Order.ExecutedQuantity)
+	}
+
+	if o, err := oms.Cancel(ctx, "1003"); err != nil || o.State != OrderCancelled {
+		t.Errorf("cancel 1003 = %v, %v", o.State, err)
+	}
+	if len(cancels) != 1 || cancels[0].OrderID != "5003" || cancels[0].ClientOrderID != "1003" || cancels[0].APIKey != "key" {
+		t.Errorf("CancelOrder requests = %+v", cancels)
+	}
+	if _, err := oms.Cancel(ctx, "nope"); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("cancel unknown: err = %v", err)
+	}
+
+	// The stale NEW for 1003 does not reopen it.
+	oms.Reconcile(ctx)
+	if o, _ := oms.Order("1001"); o.State != OrderFilled {
+		t.Errorf("1001 = %v after second reconcile", o.State)
+	}
+	if o, _ := oms.Order("1003"); o.State != OrderCancelled {
+		t.Errorf("1003 = %v after second reconcile", o.State)
+	}
+
+	place("1004")
+	if got, err := oms.CancelAll(ctx, "Binance", "Spot", "BTC-USDT"); err != nil || len(got) != 1 || got[0].ClientOrderID != "1004" || got[0].State != OrderCancelled {
+		t.Errorf("CancelAll = %+v, %v", got, err)
+	}
+	if open := oms.OpenOrders(); len(open) != 0 {
+		t.Errorf("open orders = %+v", open)
+	}
+
+	var states []OrderState
+	for len(oms.Events()) > 0 {
+		if e := <-oms.Events(); e.Order.ClientOrderID == "1001" {
+			states = append(states, e.Order.State)
+		}
+	}
+	want := []OrderState{OrderPending, OrderAcknowledged, OrderPartiallyFilled, OrderFilled}
+	if len(states) != len(want) {
+		t.Fatalf("1001 events = %v, want %v", states, want)
+	}
+	for i := range want {
+		if states[i] != want[i] {
+			t.Errorf("1001 events = %v, want %v", states, want)
+			break
+		}
+	}
+}
+
+func TestOMSRejects(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req PlaceLimitOrderRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		switch req.ClientOrderID {
+		case "429":
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "408":
+			w.WriteHeader(http.StatusRequestTimeout)
+		case "503", "":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "400":
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		io.WriteString(w, `{"isSuccess":false,"message":"failed"}`)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	oms := NewOMS(NewClient("token", WithBaseURL(srv.URL), WithRetryPolicy(RetryPolicy{})), Credentials{APIKey: "key"}, WithOMSEventBuffer(2))
+	for id, want := range map[string]OrderState{"429": OrderPending, "408": OrderPending, "503": OrderPending, "400": OrderRejected} {
+		if o, err := oms.PlaceLimitOrder(ctx, "Binance", "Spot", "BTC-USDT", id, d("100"), Decimal{}, d("1"), SideBuy); err == nil || o.State != want {
+			t.Errorf("place %s = %v, %v; want %v", id, o.State, err, want)
+		}
+	}
+
+	// Four pending events and one rejection do not fit in two slots.
+	if n := oms.Dropped(); n != 3 {
+		t.Errorf("Dropped = %d, want 3", n)
+	}
+	first, second := <-oms.Events(), <-oms.Events()
+	if first.Seq != 1 || second.Seq != 2 {
+		t.Errorf("Seq = %d, %d", first.Seq, second.Seq)
+	}
+	if open := oms.OpenOrders(); len(open) != 3 {
+		t.Errorf("open orders = %d, want 3", len(open))
+	}
+
+	// An open order's ClientOrderId is not reused; a rejected one's is.
+	if _, err := oms.PlaceLimitOrder(ctx, "Binance", "Spot", "BTC-USDT", "429", d("100"), Decimal{}, d("1"), SideBuy); !errors.Is(err, ErrInvalidOrder) {
+		t.Errorf("reused open id: err = %v", err)
+	}
+	if o, err := oms.PlaceLimitOrder(ctx, "Binance", "Spot", "BTC-USDT", "400", d("100"), Decimal{}, d("1"), SideBuy); o.State != OrderRejected || errors.Is(err, ErrInvalidOrder) {
+		t.Errorf("reused rejected id = %v, %v", o.State, err)
+	}
+	if o, _ := oms.Order("429"); o.State != OrderPending {
+		t.Errorf("429 = %v after its id was reused", o.State)
+	}
+
+	// Without a ClientOrderId an unanswered order cannot be reconciled.
+	oms = NewOMS(NewClient("token", WithBaseURL(srv.URL), WithRetryPolicy(RetryPolicy{}), WithClientOrderIDs(nil)), Credentials{APIKey: "key"})
+	if o, _ := oms.PlaceLimitOrder(ctx, "Binance", "Spot", "BTC-USDT", "", d("100"), Decimal{}, d("1"), SideBuy); o.State != OrderUnknown {
+		t.Errorf("unanswered order without ids = %v", o.State)
+	}
+	if open := oms.OpenOrders(); len(open) != 0 {
+		t.Errorf("open orders = %+v", open)
+	}
+}
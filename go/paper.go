@@ -0,0 +1,679 @@
+package sbee
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Trader is the trading surface shared by SbeeRest and PaperExchange. Code
+// written against it runs unchanged against the live API or a simulation.
+type Trader interface {
+	OrderBook(ctx context.Context, Exchange, Trade, symbol string, depth int) (*Response[OrderBook], error)
+	RecentTrades(ctx context.Context, Exchange, Trade, symbol, depth string) (*Response[RecentTrades], error)
+	TradingBalances(ctx context.Context, Exchange, Trade, symbol, apiKey, apiSecret, apiPass string) (*Response[[]Balance], error)
+	OrderHistory(ctx context.Context, Exchange, Trade, symbol, state, apiKey, apiSecret, apiPass string) (*Response[[]Order], error)
+	PlaceLimitOrder(ctx context.Context, Exchange, Trade, symbol, ClientOrderId string, price, quoteQuantity, baseQuantity Decimal, side, apiKey, apiSecret, apiPass string) (*Response[Order], error)
+	PlaceMarketOrder(ctx context.Context, Exchange, Trade, symbol, ClientOrderId string, price, quoteQuantity, baseQuantity Decimal, leverage, contract int, side, apiKey, apiSecret, apiPass string) (*Response[Order], error)
+	PlaceLimitStopLossOrder(ctx context.Context, Exchange, Trade, symbol string, quantity Decimal, ClientOrderId string, stopPrice, orderPrice, price, trailingDelta Decimal, side, apiKey, apiSecret, apiPass string) (*Response[Order], error)
+	PlaceLimitTakeProfitOrder(ctx context.Context, Exchange, Trade, symbol string, quantity Decimal, ClientOrderId string, stopPrice, orderPrice, price, trailingDelta Decimal, side, apiKey, apiSecret, apiPass string) (*Response[Order], error)
+	CancelOrder(ctx context.Context, Exchange, Trade, symbol, apiKey, apiSecret, apiPass, orderId, clientOrderId string) (*Response[Order], error)
+	CancelOrdersBySymbol(ctx context.Context, Exchange, Trade, symbol, apiKey, apiSecret, apiPass string) (*Response[[]Order], error)
+}
+
+var (
+	_ Trader = (*SbeeRest)(nil)
+	_ Trader = (*PaperExchange)(nil)
+)
+
+// DefaultPaperFee is the maker and taker fee rate of a PaperExchange: 0.1%.
+var DefaultPaperFee = NewDecimal(1, 3)
+
+// PaperOption configures a PaperExchange.
+type PaperOption func(*PaperExchange)
+
+// WithPaperBalance credits amount of asset on exchange before trading starts.
+func WithPaperBalance(exchange, asset string, amount Decimal) PaperOption {
+	return func(p *PaperExchange) {
+		p.Deposit(exchange, asset, amount)
+	}
+}
+
+// WithPaperFees sets the fee rates charged on fills, in the quote asset:
+// maker for resting limit orders, taker for everything that trades on
+// arrival. Both default to DefaultPaperFee.
+func WithPaperFees(maker, taker Decimal) PaperOption {
+	return func(p *PaperExchange) {
+		p.makerFee, p.takerFee = maker, taker
+	}
+}
+
+// WithPaperBookDepth sets the depth of the OrderBook used to fill orders on
+// arrival. The default is 100.
+func WithPaperBookDepth(depth int) PaperOption {
+	return func(p *PaperExchange) {
+		p.depth = depth
+	}
+}
+
+// PaperExchange is a simulated exchange. It implements Trader with virtual
+// balances and fills orders against live market data read through a real
+// client:
+//
+//   - market orders, and the part of a limit order that crosses the book,
+//     fill at once against OrderBook as taker;
+//   - resting limit orders fill at their limit price, as maker, from the
+//     RecentTrades printed at or through it after they were placed;
+//   - stop-loss and take-profit orders trigger when a trade reaches their
+//     stop price, then behave as a limit order at price, or orderPrice, or
+//     as a market order filled at the trade price when both are zero.
+//     trailingDelta is not simulated.
+//
+// Resting orders advance on Sync, which OrderHistory and TradingBalances call
+// and Run repeats. There is one account per exchange; API keys are ignored.
+// Symbols are BASE-QUOTE. A PaperExchange is safe for concurrent use.
+type PaperExchange struct {
+	market             *SbeeRest
+	makerFee, takerFee Decimal
+	depth              int
+
+	mu       sync.Mutex
+	balances map[string]map[string]*paperBalance // exchange, asset
+	orders   []*paperOrder
+	nextID   int64
+	cursors  map[string]*tradeCursor // exchange/trade/symbol
+}
+
+type paperBalance struct {
+	free, locked Decimal
+}
+
+type paperOrder struct {
+	Order
+	exchange, trade string
+	base, quote     string
+	sell            bool
+	// stop is the trigger price of a stop order that has not triggered.
+	stop       Decimal
+	takeProfit bool
+	// locked is what the order still holds of its reserve asset: the base
+	// asset for a sell, the quote asset for a buy. A buy reserves at
+	// reservePrice, or by cost when it is zero.
+	locked       Decimal
+	reservePrice Decimal
+	notional     Decimal
+}
+
+// tradeCursor is the newest trade processed for one market.
+type tradeCursor struct {
+	last Timestamp
+	seen map[[3]string]bool
+}
+
+// NewPaperExchange returns a simulated exchange reading market data through
+// market.
+func NewPaperExchange(market *SbeeRest, opts ...PaperOption) *PaperExchange {
+	p := &PaperExchange{
+		market:   market,
+		makerFee: DefaultPaperFee,
+		takerFee: DefaultPaperFee,
+		depth:    100,
+		balances: map[string]map[string]*paperBalance{},
+		cursors:  map[string]*tradeCursor{},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Deposit credits amount of asset on exchange.
+func (p *PaperExchange) Deposit(exchange, asset string, amount Decimal) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	b := p.balance(p.market.exchange(exchange), asset)
+	b.free = b.free.Add(amount)
+}
+
+// OrderBook returns the live book.
+func (p *PaperExchange) OrderBook(ctx context.Context, Exchange, Trade, symbol string, depth int) (*Response[OrderBook], error) {
+	return p.market.OrderBook(ctx, Exchange, Trade, symbol, depth)
+}
+
+// RecentTrades returns the live trades.
+func (p *PaperExchange) RecentTrades(ctx context.Context, Exchange, Trade, symbol, depth string) (*Response[RecentTrades], error) {
+	return p.market.RecentTrades(ctx, Exchange, Trade, symbol, depth)
+}
+
+// TradingBalances returns the virtual balances of Exchange, or only that of
+// asset symbol when it is set.
+func (p *PaperExchange) TradingBalances(ctx context.Context, Exchange, Trade, symbol, apiKey, apiSecret, apiPass string) (*Response[[]Balance], error) {
+	if err := p.Sync(ctx); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := []Balance{}
+	for asset, b := range p.balances[strings.ToLower(p.market.exchange(Exchange))] {
+		if symbol == "" || strings.EqualFold(symbol, asset) {
+			out = append(out, Balance{Symbol: asset, Free: b.free, Locked: b.locked, Total: b.free.Add(b.locked)})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Symbol < out[j].Symbol })
+	return &Response[[]Balance]{IsSuccess: true, Data: out}, nil
+}
+
+// OrderHistory returns the simulated orders of symbol in state: NEW for open
+// orders, FILLED, CANCELED, or ALL.
+func (p *PaperExchange) OrderHistory(ctx context.Context, Exchange, Trade, symbol, state, apiKey, apiSecret, apiPass string) (*Response[[]Order], error) {
+	if err := p.Sync(ctx); err != nil {
+		return nil, err
+	}
+	exchange, trade := p.market.exchange(Exchange), p.market.trade(Trade)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := []Order{}
+	for _, o := range p.orders {
+		if !o.in(exchange, trade, symbol) {
+			continue
+		}
+		st := StateOf(o.Order)
+		switch strings.ToUpper(state) {
+		case "NEW":
+			if st.Terminal() {
+				continue
+			}
+		case "FILLED":
+			if st != OrderFilled {
+				continue
+			}
+		case "CANCELED", "CANCELLED":
+			if st != OrderCancelled {
+				continue
+			}
+		}
+		out = append(out, o.Order)
+	}
+	return &Response[[]Order]{IsSuccess: true, Data: out}, nil
+}
+
+// PlaceLimitOrder places a simulated limit order. The part that crosses the
+// current book fills at once; the rest rests until trades reach its price.
+func (p *PaperExchange) PlaceLimitOrder(ctx context.Context, Exchange, Trade, symbol, ClientOrderId string, price, quoteQuantity, baseQuantity Decimal, side, apiKey, apiSecret, apiPass string) (*Response[Order], error) {
+	const endpoint = "PlaceLimitOrder"
+	if price.Sign() <= 0 {
+		return nil, &OrderError{Endpoint: endpoint, Symbol: symbol, Field: "price", Value: price.String(), Reason: "must be positive"}
+	}
+	qty := baseQuantity
+	if qty.IsZero() && quoteQuantity.Sign() > 0 {
+		qty = quoteQuantity.Div(price)
+	}
+	o, err := p.newOrder(endpoint, Exchange, Trade, symbol, ClientOrderId, side, "LIMIT", qty)
+	if err != nil {
+		return nil, err
+	}
+	o.Price = price
+	book, err := p.market.OrderBook(ctx, o.exchange, o.trade, symbol, p.depth)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.reserve(endpoint, o, price); err != nil {
+		return nil, err
+	}
+	p.add(o)
+	for _, f := range walkLevels(takerLevels(book.Data, o.sell), qty, Decimal{}, price, o.sell) {
+		p.fill(o, f.Size, f.Price, p.takerFee)
+	}
+	p.settle(o)
+	return &Response[Order]{IsSuccess: true, Data: o.Order}, nil
+}
+
+// PlaceMarketOrder fills a simulated market order against the current book.
+// What the book cannot fill expires. leverage and contract are ignored.
+func (p *PaperExchange) PlaceMarketOrder(ctx context.Context, Exchange, Trade, symbol, ClientOrderId string, price, quoteQuantity, baseQuantity Decimal, leverage, contract int, side, apiKey, apiSecret, apiPass string) (*Response[Order], error) {
+	const endpoint = "PlaceMarketOrder"
+	// A market order is sized in base, or in quote when base is zero.
+	qty := baseQuantity
+	if qty.IsZero() {
+		qty = quoteQuantity
+	}
+	o, err := p.newOrder(endpoint, Exchange, Trade, symbol, ClientOrderId, side, "MARKET", qty)
+	if err != nil {
+		return nil, err
+	}
+	if baseQuantity.IsZero() {
+		o.BaseQuantity, o.QuoteQuantity = Decimal{}, quoteQuantity
+	}
+	book, err := p.market.OrderBook(ctx, o.exchange, o.trade, symbol, p.depth)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fills := walkLevels(takerLevels(book.Data, o.sell), baseQuantity, quoteQuantity, Decimal{}, o.sell)
+	var need Decimal
+	for _, f := range fills {
+		if o.sell {
+			need = need.Add(f.Size)
+		} else {
+			need = need.Add(f.Size.Mul(f.Price).Mul(one.Add(p.takerFee)))
+		}
+	}
+	if err := p.lock(endpoint, o, need); err != nil {
+		return nil, err
+	}
+	p.add(o)
+	for _, f := range fills {
+		p.fill(o, f.Size, f.Price, p.takerFee)
+	}
+	if o.QuoteQuantity.Sign() > 0 {
+		// A quote-sized order is filled once its quote amount is spent, but
+		// for what walkLevels rounded off. Anything short of that expired,
+		// like an unfilled base-sized order.
+		if len(fills) > 0 && o.QuoteQuantity.Sub(o.notional).LessThanOrEqual(fills[len(fills)-1].Price.Mul(quoteDust)) {
+			o.BaseQuantity = o.ExecutedQuantity
+		}
+	}
+	if !o.BaseQuantity.IsZero() {
+		p.settle(o)
+	}
+	if StateOf(o.Order) != OrderFilled {
+		p.finish(o, "EXPIRED")
+	}
+	return &Response[Order]{IsSuccess: true, Data: o.Order}, nil
+}
+
+// PlaceLimitStopLossOrder places a simulated stop-loss order: a sell that
+// triggers when trades fall to stopPrice, or a buy when they rise to it.
+func (p *PaperExchange) PlaceLimitStopLossOrder(ctx context.Context, Exchange, Trade, symbol string, quantity Decimal, ClientOrderId string, stopPrice, orderPrice, price, trailingDelta Decimal, side, apiKey, apiSecret, apiPass string) (*Response[Order], error) {
+	return p.placeStop("PlaceLimitStopLossOrder", false, Exchange, Trade, symbol, quantity, ClientOrderId, stopPrice, orderPrice, price, side)
+}
+
+// PlaceLimitTakeProfitOrder places a simulated take-profit order: a sell
+// that triggers when trades rise to stopPrice, or a buy when they fall to it.
+func (p *PaperExchange) PlaceLimitTakeProfitOrder(ctx context.Context, Exchange, Trade, symbol string, quantity Decimal, ClientOrderId string, stopPrice, orderPrice, price, trailingDelta Decimal, side, apiKey, apiSecret, apiPass string) (*Response[Order], error) {
+	return p.placeStop("PlaceLimitTakeProfitOrder", true, Exchange, Trade, symbol, quantity, ClientOrderId, stopPrice, orderPrice, price, side)
+}
+
+func (p *PaperExchange) placeStop(endpoint string, takeProfit bool, Exchange, Trade, symbol string, quantity Decimal, ClientOrderId string, stopPrice, orderPrice, price Decimal, side string) (*Response[Order], error) {
+	if stopPrice.Sign() <= 0 {
+		return nil, &OrderError{Endpoint: endpoint, Symbol: symbol, Field: "stopPrice", Value: stopPrice.String(), Reason: "must be positive"}
+	}
+	typ := "STOP_LOSS_LIMIT"
+	if takeProfit {
+		typ = "TAKE_PROFIT_LIMIT"
+	}
+	o, err := p.newOrder(endpoint, Exchange, Trade, symbol, ClientOrderId, side, typ, quantity)
+	if err != nil {
+		return nil, err
+	}
+	o.stop, o.takeProfit = stopPrice, takeProfit
+	o.Price = price
+	if o.Price.IsZero() {
+		o.Price = orderPrice
+	}
+	reserveAt := o.Price
+	if reserveAt.IsZero() {
+		reserveAt = stopPrice
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.reserve(endpoint, o, reserveAt); err != nil {
+		return nil, err
+	}
+	p.add(o)
+	return &Response[Order]{IsSuccess: true, Data: o.Order}, nil
+}
+
+// CancelOrder cancels a simulated order by orderId or, when it is empty, by
+// clientOrderId, releasing what it reserved.
+func (p *PaperExchange) CancelOrder(ctx context.Context, Exchange, Trade, symbol, apiKey, apiSecret, apiPass, orderId, clientOrderId string) (*Response[Order], error) {
+	exchange, trade := p.market.exchange(Exchange), p.market.trade(Trade)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, o := range p.orders {
+		if !o.in(exchange, trade, symbol) || StateOf(o.Order).Terminal() {
+			continue
+		}
+		if orderId != "" && o.OrderID == orderId || orderId == "" && clientOrderId != "" && o.ClientOrderID == clientOrderId {
+			p.finish(o, "CANCELED")
+			return &Response[Order]{IsSuccess: true, Data: o.Order}, nil
+		}
+	}
+	return nil, paperError("CancelOrder", exchange, "-2011", "Unknown order sent.")
+}
+
+// CancelOrdersBySymbol cancels every open simulated order of symbol.
+func (p *PaperExchange) CancelOrdersBySymbol(ctx context.Context, Exchange, Trade, symbol, apiKey, apiSecret, apiPass string) (*Response[[]Order], error) {
+	exchange, trade := p.market.exchange(Exchange), p.market.trade(Trade)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := []Order{}
+	for _, o := range p.orders {
+		if o.in(exchange, trade, symbol) && !StateOf(o.Order).Terminal() {
+			p.finish(o, "CANCELED")
+			out = append(out, o.Order)
+		}
+	}
+	return &Response[[]Order]{IsSuccess: true, Data: out}, nil
+}
+
+// Run calls Sync every interval until ctx is done. Failed syncs are retried
+// on the next tick.
+func (p *PaperExchange) Run(ctx context.Context, interval time.Duration) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+			p.Sync(ctx)
+		}
+	}
+}
+
+// Sync reads RecentTrades for every market with open orders and applies the
+// trades printed since the last Sync: stop orders trigger and resting limit
+// orders fill. Trades older than the 100 most recent ones are missed, so
+// busy markets need frequent syncs.
+func (p *PaperExchange) Sync(ctx context.Context) error {
+	type market struct{ exchange, trade, symbol string }
+	var markets []market
+	seen := map[market]bool{}
+	p.mu.Lock()
+	for _, o := range p.orders {
+		k := market{o.exchange, o.trade, o.Symbol}
+		if !StateOf(o.Order).Terminal() && !seen[k] {
+			seen[k] = true
+			markets = append(markets, k)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, k := range markets {
+		resp, err := p.market.RecentTrades(ctx, k.exchange, k.trade, k.symbol, "100")
+		if err != nil {
+			return err
+		}
+		trades := resp.Data.Trades
+		sort.SliceStable(trades, func(i, j int) bool { return trades[i].Timestamp < trades[j].Timestamp })
+		p.mu.Lock()
+		c := p.cursors[k.exchange+"/"+k.trade+"/"+k.symbol]
+		for _, tr := range trades {
+			key := [3]string{tr.Price.String(), tr.Amount.String(), tr.Side}
+			if tr.Timestamp < c.last || tr.Timestamp == c.last && c.seen[key] {
+				continue
+			}
+			if tr.Timestamp > c.last {
+				c.last, c.seen = tr.Timestamp, map[[3]string]bool{}
+			}
+			c.seen[key] = true
+			p.trade(k.exchange, k.trade, k.symbol, tr)
+		}
+		p.mu.Unlock()
+	}
+	return nil
+}
+
+// trade applies one trade to the open orders of its market, oldest first.
+// The caller holds p.mu.
+func (p *PaperExchange) trade(exchange, trade, symbol string, tr Trade) {
+	avail := tr.Amount
+	for _, o := range p.orders {
+		if avail.Sign() <= 0 {
+			return
+		}
+		if !o.in(exchange, trade, symbol) || StateOf(o.Order).Terminal() || tr.Timestamp < o.Timestamp {
+			continue
+		}
+		if o.stop.Sign() > 0 {
+			// A sell stop-loss and a buy take-profit trigger on the way
+			// down, the others on the way up.
+			down := o.sell != o.takeProfit
+			if down && tr.Price.GreaterThan(o.stop) || !down && tr.Price.LessThan(o.stop) {
+				continue
+			}
+			o.stop = Decimal{}
+			if o.Price.IsZero() {
+				qty := MinDecimal(o.BaseQuantity.Sub(o.ExecutedQuantity), avail)
+				if !o.sell {
+					// The buy reserved at stopPrice but fills at the trade
+					// price, which may be worse: from here it is charged at
+					// cost, and expires if the balance cannot cover that.
+					o.reservePrice = Decimal{}
+					if !p.relock(o, qty.Mul(tr.Price).Mul(one.Add(p.takerFee))) {
+						p.finish(o, "EXPIRED")
+						continue
+					}
+				}
+				p.fill(o, qty, tr.Price, p.takerFee)
+				avail = avail.Sub(qty)
+				p.settle(o)
+				continue
+			}
+		}
+		if o.Price.IsZero() || o.sell && tr.Price.LessThan(o.Price) || !o.sell && tr.Price.GreaterThan(o.Price) {
+			continue
+		}
+		qty := MinDecimal(o.BaseQuantity.Sub(o.ExecutedQuantity), avail)
+		p.fill(o, qty, o.Price, p.makerFee)
+		avail = avail.Sub(qty)
+		p.settle(o)
+	}
+}
+
+// newOrder validates and builds an order; it is not yet tracked.
+func (p *PaperExchange) newOrder(endpoint, Exchange, Trade, symbol, clientOrderID, side, typ string, qty Decimal) (*paperOrder, error) {
+	sell := strings.EqualFold(side, SideSell)
+	if !sell && !strings.EqualFold(side, SideBuy) {
+		return nil, &OrderError{Endpoint: endpoint, Symbol: symbol, Field: "side", Value: side, Reason: "must be BUY or SELL"}
+	}
+	if qty.Sign() <= 0 {
+		return nil, &OrderError{Endpoint: endpoint, Symbol: symbol, Field: "baseQuantity", Value: qty.String(), Reason: "must be positive"}
+	}
+	base, quote, ok := strings.Cut(symbol, "-")
+	if !ok {
+		return nil, &OrderError{Endpoint: endpoint, Symbol: symbol, Field: "symbol", Reason: "must be BASE-QUOTE"}
+	}
+	exchange := p.market.exchange(Exchange)
+	if clientOrderID == "" {
+		clientOrderID = p.market.newClientOrderID(exchange)
+	}
+	return &paperOrder{
+		Order: Order{
+			Symbol:        symbol,
+			ClientOrderID: clientOrderID,
+			Side:          strings.ToUpper(side),
+			Type:          typ,
+			Status:        "NEW",
+			BaseQuantity:  qty,
+			Timestamp:     Timestamp(time.Now().UnixMilli()),
+		},
+		exchange: exchange,
+		trade:    p.market.trade(Trade),
+		base:     base,
+		quote:    quote,
+		sell:     sell,
+	}, nil
+}
+
+// add assigns o an id and tracks it. The caller holds p.mu.
+func (p *PaperExchange) add(o *paperOrder) {
+	p.nextID++
+	o.OrderID = strconv.FormatInt(p.nextID, 10)
+	p.orders = append(p.orders, o)
+	key := o.exchange + "/" + o.trade + "/" + o.Symbol
+	if p.cursors[key] == nil {
+		p.cursors[key] = &tradeCursor{last: o.Timestamp, seen: map[[3]string]bool{}}
+	}
+}
+
+// reserve locks what o needs at price: its base quantity for a sell, the
+// quote cost plus the higher fee for a buy. The caller holds p.mu.
+func (p *PaperExchange) reserve(endpoint string, o *paperOrder, price Decimal) error {
+	if o.sell {
+		return p.lock(endpoint, o, o.BaseQuantity)
+	}
+	o.reservePrice = price
+	return p.lock(endpoint, o, o.BaseQuantity.Mul(price).Mul(one.Add(MaxDecimal(p.makerFee, p.takerFee))))
+}
+
+// lock moves amount of o's reserve asset from free to locked, failing like
+// the exchange when the balance is short. The caller holds p.mu.
+func (p *PaperExchange) lock(endpoint string, o *paperOrder, amount Decimal) error {
+	asset := o.quote
+	if o.sell {
+		asset = o.base
+	}
+	b := p.balance(o.exchange, asset)
+	if b.free.LessThan(amount) {
+		return paperError(endpoint, o.exchange, "-2010", "Account has insufficient balance for requested action.")
+	}
+	b.free, b.locked = b.free.Sub(amount), b.locked.Add(amount)
+	o.locked = amount
+	return nil
+}
+
+// relock tops the quote o holds up to need from the free balance, reporting
+// false when the balance is short. The caller holds p.mu.
+func (p *PaperExchange) relock(o *paperOrder, need Decimal) bool {
+	extra := need.Sub(o.locked)
+	if extra.Sign() <= 0 {
+		return true
+	}
+	b := p.balance(o.exchange, o.quote)
+	if b.free.LessThan(extra) {
+		return false
+	}
+	b.free, b.locked = b.free.Sub(extra), b.locked.Add(extra)
+	o.locked = o.locked.Add(extra)
+	return true
+}
+
+// fill executes qty of o at price, charging fee. The caller holds p.mu.
+func (p *PaperExchange) fill(o *paperOrder, qty, price, fee Decimal) {
+	if qty.Sign() <= 0 {
+		return
+	}
+	base, quote := p.balance(o.exchange, o.base), p.balance(o.exchange, o.quote)
+	cost := qty.Mul(price)
+	if o.sell {
+		release := MinDecimal(qty, o.locked)
+		o.locked, base.locked = o.locked.Sub(release), base.locked.Sub(release)
+		quote.free = quote.free.Add(cost.Sub(cost.Mul(fee)))
+	} else {
+		spent := cost.Add(cost.Mul(fee))
+		release := spent
+		if o.reservePrice.Sign() > 0 {
+			release = qty.Mul(o.reservePrice).Mul(one.Add(MaxDecimal(p.makerFee, p.takerFee)))
+		}
+		release = MinDecimal(release, o.locked)
+		o.locked, quote.locked = o.locked.Sub(release), quote.locked.Sub(release)
+		quote.free = quote.free.Add(release).Sub(spent)
+		base.free = base.free.Add(qty)
+	}
+	o.ExecutedQuantity = o.ExecutedQuantity.Add(qty)
+	o.notional = o.notional.Add(cost)
+}
+
+// settle updates o's status after fills. The caller holds p.mu.
+func (p *PaperExchange) settle(o *paperOrder) {
+	switch {
+	case o.ExecutedQuantity.GreaterThanOrEqual(o.BaseQuantity):
+		p.finish(o, "FILLED")
+	case o.ExecutedQuantity.Sign() > 0:
+		o.Status = "PARTIALLY_FILLED"
+	}
+}
+
+// finish puts o in a terminal status and releases what it still holds. The
+// caller holds p.mu.
+func (p *PaperExchange) finish(o *paperOrder, status string) {
+	o.Status = status
+	if o.Type == "MARKET" && o.ExecutedQuantity.Sign() > 0 {
+		o.Price = o.notional.Div(o.ExecutedQuantity)
+	}
+	if o.locked.Sign() > 0 {
+		asset := o.quote
+		if o.sell {
+			asset = o.base
+		}
+		b := p.balance(o.exchange, asset)
+		b.free, b.locked = b.free.Add(o.locked), b.locked.Sub(o.locked)
+		o.locked = Decimal{}
+	}
+}
+
+// balance returns the balance of asset on exchange. The caller holds p.mu.
+func (p *PaperExchange) balance(exchange, asset string) *paperBalance {
+	exchange, asset = strings.ToLower(exchange), strings.ToUpper(asset)
+	if p.balances[exchange] == nil {
+		p.balances[exchange] = map[string]*paperBalance{}
+	}
+	b := p.balances[exchange][asset]
+	if b == nil {
+		b = &paperBalance{}
+		p.balances[exchange][asset] = b
+	}
+	return b
+}
+
+func (o *paperOrder) in(exchange, trade, symbol string) bool {
+	return strings.EqualFold(o.exchange, exchange) && strings.EqualFold(o.trade, trade) && (symbol == "" || strings.EqualFold(o.Symbol, symbol))
+}
+
+func paperError(endpoint, exchange, code, message string) *APIError {
+	return &APIError{Kind: KindAPI, StatusCode: http.StatusOK, Code: code, Message: message, Exchange: exchange, Endpoint: endpoint}
+}
+
+var one = DecimalFromInt(1)
+
+// quoteDust is the base quantity walkLevels may leave unbought of a
+// quote-sized order, by rounding down at DivisionPrecision places.
+var quoteDust = NewDecimal(1, DivisionPrecision)
+
+// takerLevels returns the side of book a taker walks: asks for a buy.
+func takerLevels(book OrderBook, sell bool) []PriceLevel {
+	levels := book.Asks
+	if sell {
+		levels = book.Bids
+	}
+	return sortedLevels(levels, bookSide(sell))
+}
+
+// walkLevels takes base, or quote worth of base when base is zero, from
+// levels best first, stopping at limit when it is set.
+func walkLevels(levels []PriceLevel, base, quote, limit Decimal, sell bool) []PriceLevel {
+	byQuote := base.IsZero()
+	var fills []PriceLevel
+	for _, l := range levels {
+		if byQuote && quote.Sign() <= 0 || !byQuote && base.Sign() <= 0 {
+			break
+		}
+		if limit.Sign() > 0 && beyond(l.Price, limit, bookSide(sell)) {
+			break
+		}
+		var take Decimal
+		if byQuote {
+			take = MinDecimal(l.Size, quote.DivRound(l.Price, DivisionPrecision, RoundDown))
+			quote = quote.Sub(take.Mul(l.Price))
+		} else {
+			take = MinDecimal(l.Size, base)
+			base = base.Sub(take)
+		}
+		if take.Sign() > 0 {
+			fills = append(fills, PriceLevel{Price: l.Price, Size: take})
+		}
+	}
+	return fills
+}
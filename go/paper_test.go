@@ -0,0 +1,186 @@
+package sbee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// paperMarket serves a fixed BTC-USDT book and the trades pushed to it.
+type paperMarket struct {
+	mu     sync.Mutex
+	trades []string
+}
+
+func (m *paperMarket) push(price, amount string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ts := time.Now().Add(time.Duration(len(m.trades)+1) * time.Second).UnixMilli()
+	m.trades = append(m.trades, fmt.Sprintf(`{"price":"%s","amount":"%s","side":"SELL","timestamp":"%d"}`, price, amount, ts))
+}
+
+func newPaperExchange(t *testing.T) (*PaperExchange, *paperMarket) {
+	t.Helper()
+	m := &paperMarket{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/OrderBook"):
+			io.WriteString(w, `{"isSuccess":true,"data":{"bids":[{"price":"99","size":"1"},{"price":"98","size":"5"}],"asks":[{"price":"102","size":"2"},{"price":"101","size":"1"}]}}`)
+		case strings.HasSuffix(r.URL.Path, "/RecentTrades"):
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			io.WriteString(w, `{"isSuccess":true,"data":{"recentTrades":[`+strings.Join(m.trades, ",")+`]}}`)
+		default:
+			t.Errorf("unexpected live call %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	client := NewClient("token", WithBaseURL(srv.URL), WithDefaultExchange("Binance"), WithDefaultTrade("Spot"))
+	return NewPaperExchange(client,
+		WithPaperBalance("Binance", "USDT", d("1000")),
+		WithPaperBalance("Binance", "BTC", d("1")),
+		WithPaperFees(Decimal{}, d("0.01")),
+	), m
+}
+
+func balances(t *testing.T, tr Trader) map[string]Balance {
+	t.Helper()
+	resp, err := tr.TradingBalances(context.Background(), "", "", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := map[string]Balance{}
+	for _, b := range resp.Data {
+		out[b.Symbol] = b
+	}
+	return out
+}
+
+func TestPaperExchange(t *testing.T) {
+	ctx := context.Background()
+	paper, market := newPaperExchange(t)
+	var tr Trader = paper
+
+	// 1 at 101 and 0.5 at 102, plus 1% taker fee.
+	mkt, err := tr.PlaceMarketOrder(ctx, "", "", "BTC-USDT", "", Decimal{}, Decimal{}, d("1.5"), 0, 0, SideBuy, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mkt.Data.Status != "FILLED" || mkt.Data.ClientOrderID == "" || !mkt.Data.Price.Equal(d("101.3333333333333333")) {
+		t.Errorf("market order = %+v", mkt.Data)
+	}
+	if b := balances(t, tr); !b["USDT"].Free.Equal(d("846.48")) || !b["BTC"].Free.Equal(d("2.5")) {
+		t.Errorf("after market buy: %+v", b)
+	}
+
+	buy, err := tr.PlaceLimitOrder(ctx, "", "", "BTC-USDT", "buy-1", d("100"), Decimal{}, d("1"), SideBuy, "", "", "")
+	if err != nil || buy.Data.Status != "NEW" {
+		t.Fatalf("limit buy = %+v, %v", buy, err)
+	}
+	if _, err := tr.PlaceLimitStopLossOrder(ctx, "", "", "BTC-USDT", d("1"), "stop-1", d("97"), Decimal{}, d("96.5"), Decimal{}, SideSell, "", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if b := balances(t, tr); !b["USDT"].Locked.Equal(d("101")) || !b["BTC"].Locked.Equal(d("1")) {
+		t.Errorf("reserved: %+v", b)
+	}
+
+	market.push("100.5", "1")
+	market.push("100", "0.4")
+	open, err := tr.OrderHistory(ctx, "", "", "BTC-USDT", "NEW", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(open.Data) != 2 || open.Data[0].Status != "PARTIALLY_FILLED" || !open.Data[0].ExecutedQuantity.Equal(d("0.4")) {
+		t.Errorf("open orders = %+v", open.Data)
+	}
+
+	// The drop to 96 fills the buy and triggers the stop, whose limit of
+	// 96.5 fills on the next trade.
+	market.push("96", "3")
+	market.push("96.5", "1")
+	filled, _ := tr.OrderHistory(ctx, "", "", "BTC-USDT", "FILLED", "", "", "")
+	if len(filled.Data) != 3 {
+		t.Errorf("filled orders = %+v", filled.Data)
+	}
+	if b := balances(t, tr); !b["USDT"].Free.Equal(d("842.98")) || !b["USDT"].Locked.IsZero() || !b["BTC"].Total.Equal(d("2.5")) {
+		t.Errorf("after resting fills: %+v", b)
+	}
+
+	// A sell limit below the best bid takes it at once.
+	if sell, err := tr.PlaceLimitOrder(ctx, "", "", "BTC-USDT", "", d("98.5"), Decimal{}, d("1"), SideSell, "", "", ""); err != nil || sell.Data.Status != "FILLED" {
+		t.Errorf("crossing sell = %+v, %v", sell, err)
+	}
+	if b := balances(t, tr); !b["USDT"].Free.Equal(d("940.99")) {
+		t.Errorf("after crossing sell: %+v", b)
+	}
+
+	if _, err := tr.PlaceLimitOrder(ctx, "", "", "BTC-USDT", "", d("50"), Decimal{}, d("100"), SideBuy, "", "", ""); !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("oversized buy: err = %v", err)
+	}
+	tr.PlaceLimitOrder(ctx, "", "", "BTC-USDT", "rest", d("50"), Decimal{}, d("2"), SideBuy, "", "", "")
+	if c, err := tr.CancelOrder(ctx, "", "", "BTC-USDT", "", "", "", "", "rest"); err != nil || c.Data.Status != "CANCELED" {
+		t.Errorf("cancel = %+v, %v", c, err)
+	}
+	if _, err := tr.CancelOrder(ctx, "", "", "BTC-USDT", "", "", "", "", "rest"); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("second cancel: err = %v", err)
+	}
+	if b := balances(t, tr); !b["USDT"].Free.Equal(d("940.99")) || !b["USDT"].Locked.IsZero() {
+		t.Errorf("after cancel: %+v", b)
+	}
+}
+
+func TestPaperQuoteMarketOrder(t *testing.T) {
+	ctx := context.Background()
+	paper, _ := newPaperExchange(t)
+
+	// The asks hold 305 USDT worth.
+	big, err := paper.PlaceMarketOrder(ctx, "", "", "BTC-USDT", "", Decimal{}, d("500"), Decimal{}, 0, 0, SideBuy, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if big.Data.Status != "EXPIRED" || !big.Data.ExecutedQuantity.Equal(d("3")) || StateOf(big.Data) != OrderCancelled {
+		t.Errorf("oversized quote order = %+v", big.Data)
+	}
+
+	small, err := paper.PlaceMarketOrder(ctx, "", "", "BTC-USDT", "", Decimal{}, d("50"), Decimal{}, 0, 0, SideBuy, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if small.Data.Status != "FILLED" || !small.Data.BaseQuantity.Equal(small.Data.ExecutedQuantity) || small.Data.ExecutedQuantity.Sign() <= 0 {
+		t.Errorf("quote order = %+v", small.Data)
+	}
+}
+
+func TestPaperStopMarketRecheck(t *testing.T) {
+	ctx := context.Background()
+	paper, market := newPaperExchange(t)
+
+	// Reserved at 100 with fees, 909, but the trigger trades at 120.
+	stop, err := paper.PlaceLimitStopLossOrder(ctx, "", "", "BTC-USDT", d("9"), "", d("100"), Decimal{}, Decimal{}, Decimal{}, SideBuy, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	market.push("120", "9")
+	if b := balances(t, paper); !b["USDT"].Free.Equal(d("1000")) || !b["USDT"].Locked.IsZero() {
+		t.Errorf("after short trigger: %+v", b)
+	}
+	if o, _ := paper.OrderHistory(ctx, "", "", "BTC-USDT", "EXPIRED", "", "", ""); len(o.Data) != 1 || o.Data[0].ClientOrderID != stop.Data.ClientOrderID {
+		t.Errorf("expired orders = %+v", o.Data)
+	}
+
+	// At 105 the 954.45 it costs is covered.
+	if _, err := paper.PlaceLimitStopLossOrder(ctx, "", "", "BTC-USDT", d("9"), "", d("100"), Decimal{}, Decimal{}, Decimal{}, SideBuy, "", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	market.push("105", "9")
+	if b := balances(t, paper); !b["USDT"].Free.Equal(d("45.55")) || !b["USDT"].Locked.IsZero() || !b["BTC"].Free.Equal(d("10")) {
+		t.Errorf("after trigger: %+v", b)
+	}
+}
@@ -0,0 +1,256 @@
+package sbee
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EndpointClass groups the endpoints that share a rate limit.
+type EndpointClass int
+
+const (
+	// ClassMarketData is public market data: books, trades, tickers,
+	// klines and the info endpoints.
+	ClassMarketData EndpointClass = iota
+	// ClassTrading is single-account private calls: balances, order
+	// history, placing and cancelling single orders.
+	ClassTrading
+	// ClassBatch is the batch and ForPeople calls. They cost one token per
+	// order or account they carry.
+	ClassBatch
+	// ClassMultiMarket is the MultiMarket endpoints, which fan out to
+	// several exchanges.
+	ClassMultiMarket
+)
+
+func (c EndpointClass) String() string {
+	switch c {
+	case ClassMarketData:
+		return "market data"
+	case ClassTrading:
+		return "trading"
+	case ClassBatch:
+		return "batch"
+	case ClassMultiMarket:
+		return "multimarket"
+	}
+	return fmt.Sprintf("EndpointClass(%d)", int(c))
+}
+
+// RateLimitWait describes a call held back by the rate limiter.
+type RateLimitWait struct {
+	Exchange string
+	Class    EndpointClass
+	Endpoint string
+	// Wait is how long the call is held before it is sent.
+	Wait time.Duration
+	// ServerImposed is set when the wait comes from a Retry-After or
+	// rate-limit header rather than from the configured limit.
+	ServerImposed bool
+}
+
+// WithRateLimit limits calls of class on exchange to perSecond on average,
+// with bursts of up to burst calls. An empty exchange sets the limit for
+// every exchange without one of its own; MultiMarket and the info endpoints
+// have no exchange and only use that default. Calls wait for a token before
+// they are sent, or fail when their context ends first.
+//
+// Independently of these limits, a Retry-After or exhausted rate-limit
+// header on any response pauses its exchange and class until the time the
+// API asked for.
+func WithRateLimit(exchange string, class EndpointClass, perSecond float64, burst int) Option {
+	return func(s *SbeeRest) {
+		if s.limiter.limits == nil {
+			s.limiter.limits = make(map[limitKey]rateLimit)
+		}
+		s.limiter.limits[limitKey{strings.ToLower(exchange), class}] = rateLimit{perSecond, float64(burst)}
+	}
+}
+
+// WithRateLimitHook calls fn every time a call has to wait for the rate
+// limiter, before it waits. fn must not block.
+func WithRateLimitHook(fn func(RateLimitWait)) Option {
+	return func(s *SbeeRest) {
+		s.limiter.hook = fn
+	}
+}
+
+type limitKey struct {
+	exchange string
+	class    EndpointClass
+}
+
+type rateLimit struct {
+	perSecond float64
+	burst     float64
+}
+
+// rateLimiter keeps one token bucket per exchange and endpoint class.
+type rateLimiter struct {
+	limits map[limitKey]rateLimit
+	hook   func(RateLimitWait)
+
+	mu      sync.Mutex
+	buckets map[limitKey]*bucket
+}
+
+// bucket is a token bucket whose tokens may go negative: a caller takes its
+// tokens at once and waits until the bucket has refilled past zero, so
+// callers are served in order.
+type bucket struct {
+	limit  rateLimit // zero when only server pauses apply
+	tokens float64
+	last   time.Time
+	// pausedUntil is set from Retry-After and rate-limit headers.
+	pausedUntil time.Time
+}
+
+func (l *rateLimiter) bucket(key limitKey) *bucket {
+	b := l.buckets[key]
+	if b == nil {
+		limit, ok := l.limits[key]
+		if !ok {
+			limit = l.limits[limitKey{"", key.class}]
+		}
+		b = &bucket{limit: limit, tokens: limit.burst, last: time.Now()}
+		if l.buckets == nil {
+			l.buckets = make(map[limitKey]*bucket)
+		}
+		l.buckets[key] = b
+	}
+	return b
+}
+
+// wait blocks until r may be sent under its bucket, or until ctx is done.
+func (l *rateLimiter) wait(ctx context.Context, r *request) error {
+	key := limitKey{strings.ToLower(r.exchange), r.class()}
+	cost := float64(r.weight())
+
+	l.mu.Lock()
+	b := l.bucket(key)
+	now := time.Now()
+	var wait time.Duration
+	if b.limit.perSecond > 0 {
+		b.tokens += now.Sub(b.last).Seconds() * b.limit.perSecond
+		if b.tokens > b.limit.burst {
+			b.tokens = b.limit.burst
+		}
+		b.last = now
+		b.tokens -= cost
+		if b.tokens < 0 {
+			wait = time.Duration(-b.tokens / b.limit.perSecond * float64(time.Second))
+		}
+	}
+	serverWait := b.pausedUntil.Sub(now)
+	server := serverWait > wait
+	if server {
+		wait = serverWait
+	}
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	if l.hook != nil {
+		l.hook(RateLimitWait{Exchange: r.exchange, Class: key.class, Endpoint: r.endpoint, Wait: wait, ServerImposed: server})
+	}
+	if err := sleep(ctx, wait); err != nil {
+		// The call is abandoned: give its tokens back.
+		l.mu.Lock()
+		if b.limit.perSecond > 0 {
+			b.tokens += cost
+		}
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// observe pauses r's bucket when resp asks the client to slow down.
+func (l *rateLimiter) observe(r *request, resp *http.Response) {
+	until, ok := pauseUntil(resp, time.Now())
+	if !ok {
+		return
+	}
+	key := limitKey{strings.ToLower(r.exchange), r.class()}
+	l.mu.Lock()
+	if b := l.bucket(key); until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+	l.mu.Unlock()
+}
+
+// pauseUntil reads Retry-After, or an exhausted X-RateLimit / RateLimit
+// remaining count with its reset, from resp.
+func pauseUntil(resp *http.Response, now time.Time) (time.Time, bool) {
+	h := resp.Header
+	if v := h.Get("Retry-After"); v != "" {
+		if secs, err := strconv.ParseFloat(v, 64); err == nil {
+			return now.Add(time.Duration(secs * float64(time.Second))), true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return t, true
+		}
+	}
+	for _, prefix := range []string{"X-RateLimit-", "RateLimit-"} {
+		if h.Get(prefix+"Remaining") != "0" {
+			continue
+		}
+		reset, err := strconv.ParseFloat(h.Get(prefix+"Reset"), 64)
+		if err != nil {
+			continue
+		}
+		// Resets are either a delay in seconds or a Unix time, in seconds
+		// or milliseconds.
+		switch {
+		case reset > 1e12:
+			return time.UnixMilli(int64(reset)), true
+		case reset > 1e9:
+			return time.Unix(int64(reset), 0), true
+		default:
+			return now.Add(time.Duration(reset * float64(time.Second))), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// class returns the rate-limit class of r.
+func (r *request) class() EndpointClass {
+	switch {
+	case strings.HasPrefix(r.path, "/Crypto/MultiMarket/"):
+		return ClassMultiMarket
+	case strings.Contains(r.endpoint, "Batch") || strings.HasSuffix(r.endpoint, "ForPeople"):
+		return ClassBatch
+	case r.method == http.MethodGet || r.endpoint == "KlineFormation":
+		return ClassMarketData
+	}
+	return ClassTrading
+}
+
+// weight returns the number of tokens r costs: one per order or account of
+// a batch call, one for anything else.
+func (r *request) weight() int {
+	n := 1
+	switch b := r.body.(type) {
+	case PlaceBatchLimitOrdersRequest:
+		n = len(b.Orders)
+	case PlaceBatchMarketOrdersRequest:
+		n = len(b.Orders)
+	case CancelBatchOrdersRequest:
+		n = len(b.Orders)
+	case []LimitOrderForPeople:
+		n = len(b)
+	case []MarketOrderForPeople:
+		n = len(b)
+	case []CancelOrderForPeople:
+		n = len(b)
+	case []BalanceForPeople:
+		n = len(b)
+	}
+	return max(n, 1)
+}
@@ -0,0 +1,118 @@
+package sbee
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimitSpacesCalls(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"isSuccess":true}`)
+	}))
+	defer srv.Close()
+
+	var mu sync.Mutex
+	var waits []RateLimitWait
+	s := NewClient("token", WithBaseURL(srv.URL),
+		WithRateLimit("Binance", ClassMarketData, 20, 1),
+		WithRateLimitHook(func(w RateLimitWait) {
+			mu.Lock()
+			waits = append(waits, w)
+			mu.Unlock()
+		}))
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := s.Tickers(ctx, "Binance", "Spot", "BTC-USDT"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("3 calls at 20/s with burst 1 took %v, want at least 100ms", elapsed)
+	}
+	// Other exchanges and classes have no limit.
+	for i := 0; i < 3; i++ {
+		if _, err := s.Tickers(ctx, "OKX", "Spot", "BTC-USDT"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.TradingBalances(ctx, "Binance", "Spot", "", "key", "secret", "pass"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(waits) != 2 {
+		t.Fatalf("hook called %d times, want 2: %+v", len(waits), waits)
+	}
+	for _, w := range waits {
+		if w.Exchange != "Binance" || w.Class != ClassMarketData || w.Endpoint != "Tickers" || w.Wait <= 0 || w.ServerImposed {
+			t.Errorf("wait = %+v", w)
+		}
+	}
+
+	// A call that cannot get a token before its deadline fails without
+	// being sent.
+	s = NewClient("token", WithBaseURL(srv.URL), WithRateLimit("", ClassMarketData, 0.1, 1))
+	s.Tickers(ctx, "Binance", "Spot", "BTC-USDT")
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := s.Tickers(short, "Binance", "Spot", "BTC-USDT"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestRateLimitHonorsServerHeaders(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", "0.15")
+		}
+		io.WriteString(w, `{"isSuccess":true}`)
+	}))
+	defer srv.Close()
+
+	var hooked RateLimitWait
+	s := NewClient("token", WithBaseURL(srv.URL), WithRateLimitHook(func(w RateLimitWait) { hooked = w }))
+	ctx := context.Background()
+	s.OrderBook(ctx, "Binance", "Spot", "BTC-USDT", 20)
+	start := time.Now()
+	if _, err := s.OrderBook(ctx, "Binance", "Spot", "BTC-USDT", 20); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("second call waited %v, want about 150ms", elapsed)
+	}
+	if !hooked.ServerImposed || hooked.Endpoint != "OrderBook" {
+		t.Errorf("hook got %+v", hooked)
+	}
+}
+
+func TestPauseUntil(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		header http.Header
+		want   time.Time
+	}{
+		{http.Header{"Retry-After": {"2"}}, now.Add(2 * time.Second)},
+		{http.Header{"Retry-After": {"Tue, 02 Jan 2024 03:04:15 GMT"}}, now.Add(10 * time.Second)},
+		{http.Header{"Ratelimit-Remaining": {"0"}, "Ratelimit-Reset": {"3"}}, now.Add(3 * time.Second)},
+		{http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {"1704164650"}}, time.Unix(1704164650, 0)},
+		{http.Header{"X-Ratelimit-Remaining": {"5"}, "X-Ratelimit-Reset": {"3"}}, time.Time{}},
+	}
+	for _, tt := range tests {
+		got, _ := pauseUntil(&http.Response{Header: tt.header}, now)
+		if !got.Equal(tt.want) {
+			t.Errorf("pauseUntil(%v) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
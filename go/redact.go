@@ -0,0 +1,254 @@
+package sbee
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Redacted is what secrets are replaced with in formatted values, logs and
+// errors.
+const Redacted = "[REDACTED]"
+
+// secretNames are the header, JSON and struct field names whose values are
+// secret, lower-cased.
+var secretNames = map[string]bool{
+	"authorization": true,
+	"apikey":        true,
+	"apisecret":     true,
+	"apipass":       true,
+}
+
+// secretPattern finds a secret name followed by its value, as a JSON member
+// ("apiKey": "…"), a query or form parameter (apiSecret=…) or a header line
+// (Authorization: Bearer …).
+var secretPattern = regexp.MustCompile(`(?i)("?\b(?:authorization|apikey|apisecret|apipass)"?\s*[:=]\s*)("(?:[^"\\]|\\.)*"|(?:(?:bearer|basic)\s+)?[^\s"&,;}\]]+)`)
+
+// minScrubLength is the shortest key or token that Redact masks by value
+// wherever it appears. Shorter values are too likely to be ordinary words.
+const minScrubLength = 8
+
+// Redact returns s with the values of Authorization, apiKey, apiSecret and
+// apiPass masked, whether s is a JSON body, a query string or header text.
+// Use it and RedactHeader when logging requests sent through a custom
+// http.Client given to WithHTTPClient.
+func Redact(s string) string {
+	return secretPattern.ReplaceAllStringFunc(s, func(m string) string {
+		sub := secretPattern.FindStringSubmatch(m)
+		switch value := sub[2]; {
+		case value == `""`:
+			return m
+		case strings.HasPrefix(value, `"`):
+			return sub[1] + `"` + Redacted + `"`
+		}
+		return sub[1] + Redacted
+	})
+}
+
+// RedactHeader returns a copy of h with the Authorization header, and any
+// apiKey, apiSecret or apiPass header, masked.
+func RedactHeader(h http.Header) http.Header {
+	out := h.Clone()
+	for k, vs := range out {
+		if secretNames[strings.ToLower(k)] {
+			for i := range vs {
+				vs[i] = Redacted
+			}
+		}
+	}
+	return out
+}
+
+// mask returns Redacted for a set secret and "" for an unset one, so that
+// formatted credentials still tell which keys were given.
+func mask(s string) string {
+	if s == "" {
+		return ""
+	}
+	return Redacted
+}
+
+// scrub masks every value of secrets that occurs in s, besides what Redact
+// finds by name.
+func scrub(s string, secrets []string) string {
+	s = Redact(s)
+	for _, v := range secrets {
+		if len(v) >= minScrubLength {
+			s = strings.ReplaceAll(s, v, Redacted)
+		}
+	}
+	return s
+}
+
+// secrets returns the token and the keys sent with r, for scrub.
+func (s *SbeeRest) secrets(r *request) []string {
+	out := []string{s.auth}
+	if r.body == nil {
+		return out
+	}
+	payload, err := json.Marshal(r.body)
+	if err != nil {
+		return out
+	}
+	for _, sub := range secretPattern.FindAllStringSubmatch(string(payload), -1) {
+		var v string
+		if json.Unmarshal([]byte(sub[2]), &v) == nil && v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// redactError masks the token and r's keys wherever err echoes them, e.g. a
+// gateway error that repeats the request body.
+func (s *SbeeRest) redactError(r *request, err error) error {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	secrets := s.secrets(r)
+	apiErr.Message = scrub(apiErr.Message, secrets)
+	if apiErr.Err != nil {
+		if msg := scrub(apiErr.Err.Error(), secrets); msg != apiErr.Err.Error() {
+			apiErr.Err = &redactedError{msg: msg, err: apiErr.Err}
+		}
+	}
+	return err
+}
+
+// redactedError replaces the message of an error that held a secret, while
+// keeping it available to errors.Is and errors.As.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
+
+// formatRedacted prints the struct v as fmt prints structs, but with string
+// fields named like a secret, by field name or JSON name, masked, and raw JSON
+// passed through Redact. Every type holding keys formats itself with it; the
+// types embedding Credentials must, or Credentials' own Format would stand in
+// for theirs.
+func formatRedacted(f fmt.State, verb rune, v any) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	t := rv.Type()
+	goSyntax := verb == 'v' && f.Flag('#')
+	names := goSyntax || verb == 'v' && f.Flag('+')
+	sep := " "
+	if goSyntax {
+		io.WriteString(f, t.String())
+		sep = ", "
+	}
+	io.WriteString(f, "{")
+	n := 0
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		if n++; n > 1 {
+			io.WriteString(f, sep)
+		}
+		if names {
+			io.WriteString(f, sf.Name+":")
+		}
+		fv := rv.Field(i).Interface()
+		switch field := fv.(type) {
+		case string:
+			if secretNames[strings.ToLower(sf.Name)] || secretNames[strings.ToLower(jsonName(sf))] {
+				fv = mask(field)
+			}
+		case json.RawMessage:
+			fv = Redact(string(field))
+		}
+		fmt.Fprintf(f, fmt.FormatString(f, verb), fv)
+	}
+	io.WriteString(f, "}")
+}
+
+// jsonName returns the name sf is encoded under.
+func jsonName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "" {
+		return sf.Name
+	}
+	return name
+}
+
+// logRedacted is the slog value of a type holding keys: its redacted %+v
+// form, since slog's JSON handler would otherwise marshal the keys. That
+// handler marshals slices and maps without consulting their elements, so log
+// the elements of a []LimitOrderForPeople and the like one by one.
+func logRedacted(v any) slog.Value {
+	return slog.StringValue(fmt.Sprintf("%+v", v))
+}
+
+// Format prints c with its keys masked, for every verb.
+func (c Credentials) Format(f fmt.State, verb rune) { formatRedacted(f, verb, c) }
+
+// LogValue logs c with its keys masked.
+func (c Credentials) LogValue() slog.Value { return logRedacted(c) }
+
+func (r TradingBalancesRequest) Format(f fmt.State, verb rune)        { formatRedacted(f, verb, r) }
+func (r OrderHistoryRequest) Format(f fmt.State, verb rune)           { formatRedacted(f, verb, r) }
+func (r PlaceLimitOrderRequest) Format(f fmt.State, verb rune)        { formatRedacted(f, verb, r) }
+func (r PlaceMarketOrderRequest) Format(f fmt.State, verb rune)       { formatRedacted(f, verb, r) }
+func (r StopOrderRequest) Format(f fmt.State, verb rune)              { formatRedacted(f, verb, r) }
+func (r SetLeverageRequest) Format(f fmt.State, verb rune)            { formatRedacted(f, verb, r) }
+func (r CancelOrderRequest) Format(f fmt.State, verb rune)            { formatRedacted(f, verb, r) }
+func (r CancelOrdersBySymbolRequest) Format(f fmt.State, verb rune)   { formatRedacted(f, verb, r) }
+func (r CancelBatchOrdersRequest) Format(f fmt.State, verb rune)      { formatRedacted(f, verb, r) }
+func (r CancelOrderForPeople) Format(f fmt.State, verb rune)          { formatRedacted(f, verb, r) }
+func (r PlaceBatchMarketOrdersRequest) Format(f fmt.State, verb rune) { formatRedacted(f, verb, r) }
+func (r BalanceForPeople) Format(f fmt.State, verb rune)              { formatRedacted(f, verb, r) }
+func (r PlaceBatchLimitOrdersRequest) Format(f fmt.State, verb rune)  { formatRedacted(f, verb, r) }
+func (r LimitOrderForPeople) Format(f fmt.State, verb rune)           { formatRedacted(f, verb, r) }
+func (r MarketOrderForPeople) Format(f fmt.State, verb rune)          { formatRedacted(f, verb, r) }
+func (v RouteVenue) Format(f fmt.State, verb rune)                    { formatRedacted(f, verb, v) }
+func (b AccountBalances) Format(f fmt.State, verb rune)               { formatRedacted(f, verb, b) }
+func (r OrderResult) Format(f fmt.State, verb rune)                   { formatRedacted(f, verb, r) }
+func (r Response[T]) Format(f fmt.State, verb rune)                   { formatRedacted(f, verb, r) }
+
+func (r TradingBalancesRequest) LogValue() slog.Value        { return logRedacted(r) }
+func (r OrderHistoryRequest) LogValue() slog.Value           { return logRedacted(r) }
+func (r PlaceLimitOrderRequest) LogValue() slog.Value        { return logRedacted(r) }
+func (r PlaceMarketOrderRequest) LogValue() slog.Value       { return logRedacted(r) }
+func (r StopOrderRequest) LogValue() slog.Value              { return logRedacted(r) }
+func (r SetLeverageRequest) LogValue() slog.Value            { return logRedacted(r) }
+func (r CancelOrderRequest) LogValue() slog.Value            { return logRedacted(r) }
+func (r CancelOrdersBySymbolRequest) LogValue() slog.Value   { return logRedacted(r) }
+func (r CancelBatchOrdersRequest) LogValue() slog.Value      { return logRedacted(r) }
+func (r CancelOrderForPeople) LogValue() slog.Value          { return logRedacted(r) }
+func (r PlaceBatchMarketOrdersRequest) LogValue() slog.Value { return logRedacted(r) }
+func (r BalanceForPeople) LogValue() slog.Value              { return logRedacted(r) }
+func (r PlaceBatchLimitOrdersRequest) LogValue() slog.Value  { return logRedacted(r) }
+func (r LimitOrderForPeople) LogValue() slog.Value           { return logRedacted(r) }
+func (r MarketOrderForPeople) LogValue() slog.Value          { return logRedacted(r) }
+func (v RouteVenue) LogValue() slog.Value                    { return logRedacted(v) }
+func (r RouteRequest) LogValue() slog.Value                  { return logRedacted(r) }
+func (b AccountBalances) LogValue() slog.Value               { return logRedacted(b) }
+func (r OrderResult) LogValue() slog.Value                   { return logRedacted(r) }
+func (r Response[T]) LogValue() slog.Value                   { return logRedacted(r) }
+
+// Format prints the client's base URL and never its token.
+func (s *SbeeRest) Format(f fmt.State, verb rune) {
+	fmt.Fprintf(f, "sbee.SbeeRest{baseURL:%s auth:%s}", s.baseURL, mask(s.auth))
+}
+
+// Format prints the OMS's keys masked.
+func (m *OMS) Format(f fmt.State, verb rune) {
+	fmt.Fprintf(f, "sbee.OMS{credentials:%+v}", m.credentials)
+}
+
+// Format prints the keystore's path and never its key.
+func (k *Keystore) Format(f fmt.State, verb rune) {
+	fmt.Fprintf(f, "sbee.Keystore{path:%s}", k.path)
+}
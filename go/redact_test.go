@@ -0,0 +1,135 @@
+package sbee
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var secretKeys = Credentials{APIKey: "key-4f1c9a2e", APISecret: "secret-8d3b7e60", APIPass: "pass-27c5f1d9"}
+
+const secretToken = "token-91e6a0b4c3"
+
+// checkNoSecrets fails t if out holds any of secretKeys or secretToken, in
+// the clear or hex encoded.
+func checkNoSecrets(t *testing.T, what, out string) {
+	t.Helper()
+	for _, v := range []string{secretKeys.APIKey, secretKeys.APISecret, secretKeys.APIPass, secretToken} {
+		if strings.Contains(out, v) || strings.Contains(out, hex.EncodeToString([]byte(v))) {
+			t.Errorf("%s leaks %q: %s", what, v, out)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	for _, in := range []string{
+		`{"symbol":"BTC-USDT","apiKey":"key-4f1c9a2e","apiSecret":"secret-8d3b7e60","apiPass":"pass-27c5f1d9"}`,
+		`[{"APIKEY" : "key-4f1c9a2e"}, {"apisecret":"secret-8d3b7e60\"pass-27c5f1d9"}]`,
+		`symbol=BTC-USDT&apiKey=key-4f1c9a2e&apiSecret=secret-8d3b7e60&apiPass=pass-27c5f1d9`,
+		"Authorization: Bearer token-91e6a0b4c3\r\nAccept: text/plain",
+	} {
+		out := Redact(in)
+		checkNoSecrets(t, "Redact", out)
+		if !strings.Contains(out, Redacted) {
+			t.Errorf("Redact(%s) = %s", in, out)
+		}
+	}
+	if in := `{"apiKey":"","symbol":"BTC-USDT"}`; Redact(in) != in {
+		t.Errorf("Redact changed %s to %s", in, Redact(in))
+	}
+
+	h := http.Header{"Authorization": {"Bearer " + secretToken}, "Accept": {"text/plain"}}
+	out := RedactHeader(h)
+	checkNoSecrets(t, "RedactHeader", fmt.Sprint(out))
+	if out.Get("Accept") != "text/plain" || h.Get("Authorization") != "Bearer "+secretToken {
+		t.Errorf("RedactHeader(%v) = %v", h, out)
+	}
+}
+
+func TestFormatRedacts(t *testing.T) {
+	s := NewClient(secretToken)
+	values := []any{
+		secretKeys,
+		TradingBalancesRequest{Symbol: "BTC-USDT", Credentials: secretKeys},
+		OrderHistoryRequest{Symbol: "BTC-USDT", Credentials: secretKeys},
+		PlaceLimitOrderRequest{Symbol: "BTC-USDT", Credentials: secretKeys},
+		&PlaceMarketOrderRequest{Symbol: "BTC-USDT", Credentials: secretKeys},
+		StopOrderRequest{Symbol: "BTC-USDT", Credentials: secretKeys},
+		SetLeverageRequest{Symbol: "BTC-USDT", Credentials: secretKeys},
+		CancelOrderRequest{Symbol: "BTC-USDT", Credentials: secretKeys},
+		CancelOrdersBySymbolRequest{Symbol: "BTC-USDT", Credentials: secretKeys},
+		CancelBatchOrdersRequest{Orders: []BatchCancelOrder{{Symbol: "BTC-USDT"}}, Credentials: secretKeys},
+		CancelOrderForPeople{Symbol: "BTC-USDT", Credentials: secretKeys},
+		PlaceBatchMarketOrdersRequest{Orders: []BatchMarketOrder{{Symbol: "BTC-USDT"}}, Credentials: secretKeys},
+		BalanceForPeople{Symbol: "BTC-USDT", Credentials: secretKeys},
+		PlaceBatchLimitOrdersRequest{Orders: []BatchLimitOrder{{Symbol: "BTC-USDT"}}, Credentials: secretKeys},
+		LimitOrderForPeople{Symbol: "BTC-USDT", Credentials: secretKeys},
+		MarketOrderForPeople{Symbol: "BTC-USDT", Credentials: secretKeys},
+		RouteRequest{Symbol: "BTC-USDT", Venues: []RouteVenue{{Exchange: "Binance", Credentials: secretKeys}}},
+		Response[[]AccountBalances]{Data: []AccountBalances{{APIKey: secretKeys.APIKey, Balances: []Balance{{Symbol: "BTC-USDT"}}}},
+			Raw: []byte(`{"data":[{"apiKey":"key-4f1c9a2e"}]}`)},
+		Response[[]OrderResult]{Data: []OrderResult{{Order: Order{Symbol: "BTC-USDT"}, APIKey: secretKeys.APIKey}}},
+		map[string]any{"client": s, "oms": NewOMS(s, secretKeys), "keystore": &Keystore{path: "BTC-USDT", key: []byte(secretToken)}},
+	}
+	for _, v := range values {
+		for _, format := range []string{"%v", "%+v", "%#v", "%s", "%q", "%d", "%x", "%X"} {
+			out := fmt.Sprintf(format, v)
+			checkNoSecrets(t, fmt.Sprintf("%s of %T", format, v), out)
+			if _, keys := v.(Credentials); format == "%+v" && !keys && !strings.Contains(out, "BTC-USDT") {
+				t.Errorf("%s of %T lost its fields: %s", format, v, out)
+			}
+		}
+		for _, h := range []func(io.Writer) slog.Handler{
+			func(w io.Writer) slog.Handler { return slog.NewTextHandler(w, nil) },
+			func(w io.Writer) slog.Handler { return slog.NewJSONHandler(w, nil) },
+		} {
+			var buf bytes.Buffer
+			slog.New(h(&buf)).Info("request", "value", v)
+			checkNoSecrets(t, fmt.Sprintf("slog of %T", v), buf.String())
+		}
+	}
+}
+
+func TestAPIErrorRedacts(t *testing.T) {
+	ctx := context.Background()
+	status, reply := 0, ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		io.WriteString(w, reply)
+	}))
+	defer srv.Close()
+	s := NewClient(secretToken, WithBaseURL(srv.URL), WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	balances := func() error {
+		_, err := s.TradingBalances(ctx, "Binance", "Spot", "", secretKeys.APIKey, secretKeys.APISecret, secretKeys.APIPass)
+		return err
+	}
+
+	for _, tc := range []struct {
+		status int
+		reply  string
+		is     error
+	}{
+		{http.StatusUnauthorized, "bad token " + secretToken + " for key key-4f1c9a2e", ErrUnauthorized},
+		{http.StatusBadRequest, `{"isSuccess":false,"message":"rejected {\"apiSecret\":\"secret-8d3b7e60\"} pass-27c5f1d9"}`, nil},
+		{http.StatusOK, `{"isSuccess":false,"errorCode":"-2015","message":"Invalid API-key key-4f1c9a2e"}`, ErrUnauthorized},
+	} {
+		status, reply = tc.status, tc.reply
+		err := balances()
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("%d: err = %v", tc.status, err)
+		}
+		checkNoSecrets(t, "APIError", fmt.Sprintf("%v %+v %#v", err, apiErr, apiErr))
+		if tc.is != nil && !errors.Is(err, tc.is) {
+			t.Errorf("%v is not %v", err, tc.is)
+		}
+	}
+}
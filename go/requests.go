@@ -0,0 +1,239 @@
+package sbee
+
+import "encoding/json"
+
+// Request bodies for the POST endpoints. Field names follow doc.sbee.io
+// exactly, including the endpoints that spell the client order id
+// differently ("ClientOrderId", "clientOrderId", "cliOrId").
+
+// Order sides. The API also accepts them in lower case.
+const (
+	SideBuy  = "BUY"
+	SideSell = "SELL"
+)
+
+// Credentials are the exchange API keys sent with every private request.
+// Account, when set and the keys are empty, names the account whose keys the
+// client's CredentialProvider fills in when the request is sent; it is never
+// sent itself.
+type Credentials struct {
+	APIKey    string `json:"apiKey"`
+	APISecret string `json:"apiSecret"`
+	APIPass   string `json:"apiPass"`
+	Account   string `json:"-"`
+}
+
+// TradingBalancesRequest is the body of TradingBalances.
+type TradingBalancesRequest struct {
+	Symbol string `json:"symbol"`
+	Credentials
+}
+
+// OrderHistoryRequest is the body of OrderHistory.
+type OrderHistoryRequest struct {
+	Symbol string `json:"symbol"`
+	State  string `json:"state"`
+	Credentials
+}
+
+// KlineFormationRequest is the body of KlineFormation. StartTime and EndTime
+// are sent as null when unset.
+type KlineFormationRequest struct {
+	Symbol     string      `json:"symbol"`
+	Interval   string      `json:"interval"`
+	Limit      int         `json:"limit"`
+	StartTime  interface{} `json:"startTime"`
+	EndTime    interface{} `json:"endTime"`
+	Formations []Formation `json:"formations"`
+}
+
+// PlaceLimitOrderRequest is the body of PlaceLimitOrder.
+type PlaceLimitOrderRequest struct {
+	Credentials
+	Symbol        string  `json:"symbol"`
+	ClientOrderID string  `json:"ClientOrderId"`
+	Price         Decimal `json:"price"`
+	QuoteQuantity Decimal `json:"quoteQuantity"`
+	BaseQuantity  Decimal `json:"baseQuantity"`
+	Side          string  `json:"side"`
+}
+
+// PlaceMarketOrderRequest is the body of PlaceMarketOrder.
+type PlaceMarketOrderRequest struct {
+	Credentials
+	Symbol        string  `json:"symbol"`
+	ClientOrderID string  `json:"ClientOrderId"`
+	Price         Decimal `json:"price"`
+	QuoteQuantity Decimal `json:"quoteQuantity"`
+	BaseQuantity  Decimal `json:"baseQuantity"`
+	Leverage      int     `json:"leverage"`
+	Contract      int     `json:"contract"`
+	Side          string  `json:"side"`
+}
+
+// StopOrderRequest is the body of PlaceLimitStopLossOrder and
+// PlaceLimitTakeProfitOrder.
+type StopOrderRequest struct {
+	Credentials
+	Symbol        string  `json:"symbol"`
+	Quantity      Decimal `json:"quantity"`
+	ClientOrderID string  `json:"ClientOrderId"`
+	StopPrice     Decimal `json:"stopPrice"`
+	OrderPrice    Decimal `json:"orderPrice"`
+	Price         Decimal `json:"price"`
+	TrailingDelta Decimal `json:"trailingDelta"`
+	Side          string  `json:"side"`
+}
+
+// quoted is a Decimal encoded as a JSON string, as the single-order
+// endpoints take their amounts.
+type quoted Decimal
+
+func (q quoted) MarshalJSON() ([]byte, error) {
+	return json.Marshal(Decimal(q).String())
+}
+
+// MarshalJSON sends the amounts of r as strings.
+func (r PlaceLimitOrderRequest) MarshalJSON() ([]byte, error) {
+	type plain PlaceLimitOrderRequest
+	return json.Marshal(struct {
+		plain
+		Price         quoted `json:"price"`
+		QuoteQuantity quoted `json:"quoteQuantity"`
+		BaseQuantity  quoted `json:"baseQuantity"`
+	}{plain(r), quoted(r.Price), quoted(r.QuoteQuantity), quoted(r.BaseQuantity)})
+}
+
+// MarshalJSON sends the amounts of r as strings.
+func (r PlaceMarketOrderRequest) MarshalJSON() ([]byte, error) {
+	type plain PlaceMarketOrderRequest
+	return json.Marshal(struct {
+		plain
+		Price         quoted `json:"price"`
+		QuoteQuantity quoted `json:"quoteQuantity"`
+		BaseQuantity  quoted `json:"baseQuantity"`
+	}{plain(r), quoted(r.Price), quoted(r.QuoteQuantity), quoted(r.BaseQuantity)})
+}
+
+// MarshalJSON sends the amounts of r as strings.
+func (r StopOrderRequest) MarshalJSON() ([]byte, error) {
+	type plain StopOrderRequest
+	return json.Marshal(struct {
+		plain
+		Quantity      quoted `json:"quantity"`
+		StopPrice     quoted `json:"stopPrice"`
+		OrderPrice    quoted `json:"orderPrice"`
+		Price         quoted `json:"price"`
+		TrailingDelta quoted `json:"trailingDelta"`
+	}{plain(r), quoted(r.Quantity), quoted(r.StopPrice), quoted(r.OrderPrice), quoted(r.Price), quoted(r.TrailingDelta)})
+}
+
+// SetLeverageRequest is the body of SetLeverage.
+type SetLeverageRequest struct {
+	Credentials
+	Symbol   string `json:"symbol"`
+	Leverage string `json:"leverage"`
+}
+
+// CancelOrderRequest is the body of CancelOrder.
+type CancelOrderRequest struct {
+	Credentials
+	Symbol        string `json:"symbol"`
+	OrderID       string `json:"orderId"`
+	ClientOrderID string `json:"clientOrderId"`
+}
+
+// CancelOrdersBySymbolRequest is the body of CancelOrdersBySymbol.
+type CancelOrdersBySymbolRequest struct {
+	Symbol string `json:"symbol"`
+	Credentials
+}
+
+// BatchCancelOrder is one order of a CancelBatchOrders call.
+type BatchCancelOrder struct {
+	Symbol        string `json:"symbol"`
+	ClientOrderID string `json:"clientOrderId"`
+	OrderID       string `json:"orderId"`
+}
+
+// CancelBatchOrdersRequest is the body of CancelBatchOrders.
+type CancelBatchOrdersRequest struct {
+	Credentials
+	Orders []BatchCancelOrder `json:"orders"`
+}
+
+// CancelOrderForPeople is one order of a CancelBatchOrdersForPeople call,
+// each carrying the keys of the wallet it belongs to.
+type CancelOrderForPeople struct {
+	Symbol        string `json:"symbol"`
+	OrderID       string `json:"orderId"`
+	ClientOrderID string `json:"clientOrderId"`
+	Credentials
+}
+
+// BatchMarketOrder is one order of a PlaceBatchMarketOrders call.
+type BatchMarketOrder struct {
+	Symbol        string  `json:"symbol"`
+	QuoteQuantity Decimal `json:"quoteQuantity"`
+	BaseQuantity  Decimal `json:"baseQuantity"`
+	ClientOrderID string  `json:"clientOrderId"`
+	Side          string  `json:"side"`
+}
+
+// PlaceBatchMarketOrdersRequest is the body of PlaceBatchMarketOrders.
+type PlaceBatchMarketOrdersRequest struct {
+	Credentials
+	Orders []BatchMarketOrder `json:"orders"`
+}
+
+// BalanceForPeople is one account of a TradingBalancesForPeople call.
+type BalanceForPeople struct {
+	Symbol string `json:"symbol"`
+	Credentials
+}
+
+// BatchLimitOrder is one order of a PlaceBatchLimitOrders call.
+type BatchLimitOrder struct {
+	Symbol        string  `json:"symbol"`
+	ClientOrderID string  `json:"clientOrderId"`
+	Price         Decimal `json:"price"`
+	QuoteQuantity Decimal `json:"quoteQuantity"`
+	BaseQuantity  Decimal `json:"baseQuantity"`
+	Side          string  `json:"side"`
+}
+
+// PlaceBatchLimitOrdersRequest is the body of PlaceBatchLimitOrders.
+type PlaceBatchLimitOrdersRequest struct {
+	Credentials
+	Orders []BatchLimitOrder `json:"orders"`
+}
+
+// LimitOrderForPeople is one order of a PlaceLimitOrderForPeople call.
+type LimitOrderForPeople struct {
+	Credentials
+	Side          string  `json:"side"`
+	Price         Decimal `json:"price"`
+	BaseQuantity  Decimal `json:"baseQuantity"`
+	QuoteQuantity Decimal `json:"quoteQuantity"`
+	ClientOrderID string  `json:"cliOrId"`
+	Symbol        string  `json:"symbol"`
+}
+
+// MarketOrderForPeople is one order of a PlaceMarketOrderForPeople call.
+type MarketOrderForPeople struct {
+	Symbol        string  `json:"symbol"`
+	QuoteQuantity Decimal `json:"quoteQuantity"`
+	BaseQuantity  Decimal `json:"baseQuantity"`
+	ClientOrderID string  `json:"ClientOrderId"`
+	Side          string  `json:"side"`
+	Credentials
+}
+
+// MultiMarketRequest is the body of the MultiMarket endpoints. Precision is
+// only used by MultiOrderBook.
+type MultiMarketRequest struct {
+	Symbol    string   `json:"symbol"`
+	Depth     int      `json:"depth"`
+	Precision int      `json:"precision,omitempty"`
+	Exchanges []string `json:"exchanges"`
+}
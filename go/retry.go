@@ -0,0 +1,151 @@
+package sbee
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy controls how a failed call is retried. Calls that only read
+// data are retried under the policy; order placement is retried only when
+// the order carries a ClientOrderId, which it does by default (see
+// WithClientOrderIDs), and only after OrderHistory shows that the failed
+// attempt did not place it. Every other call is sent once.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the wait before the second attempt. Each further
+	// wait is Multiplier times longer, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter, between 0 and 1, is the fraction of each wait that is
+	// randomised, so clients that failed together do not retry together.
+	Jitter float64
+	// RetryableStatuses are the HTTP statuses worth another attempt.
+	// Rate-limit errors reported inside a 200 envelope are retried too.
+	RetryableStatuses []int
+	// RetryNetworkErrors retries requests that got no response at all.
+	// Cancelling the call's context always stops retrying.
+	RetryNetworkErrors bool
+}
+
+// DefaultRetryPolicy returns the policy clients use unless WithRetryPolicy
+// replaces it: 3 attempts, 200ms doubling to at most 5s, 20% jitter.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		RetryableStatuses: []int{
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		RetryNetworkErrors: true,
+	}
+}
+
+// WithRetryPolicy replaces DefaultRetryPolicy. Use RetryPolicy{} to send
+// every call exactly once.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(s *SbeeRest) {
+		s.retry = p
+	}
+}
+
+// retryable reports whether err, returned by an attempt made with ctx, is
+// worth another attempt.
+func (p *RetryPolicy) retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.Kind {
+	case KindTransport:
+		return p.RetryNetworkErrors
+	case KindAPI:
+		for _, code := range p.RetryableStatuses {
+			if apiErr.StatusCode == code {
+				return true
+			}
+		}
+		return errors.Is(apiErr, ErrRateLimited)
+	}
+	return false
+}
+
+// backoff returns the wait before attempt n+1, after n failed attempts.
+func (p *RetryPolicy) backoff(n int) time.Duration {
+	mult := p.Multiplier
+	if mult < 1 {
+		mult = 1
+	}
+	wait := float64(p.InitialBackoff) * math.Pow(mult, float64(n-1))
+	if p.MaxBackoff > 0 && wait > float64(p.MaxBackoff) {
+		wait = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		wait -= wait * math.Min(p.Jitter, 1) * rand.Float64()
+	}
+	return time.Duration(wait)
+}
+
+// sleep waits for d or until ctx is done, whichever comes first.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// placedOrder identifies the single order a placement request creates, for
+// reconciliation through OrderHistory.
+type placedOrder struct {
+	symbol        string
+	clientOrderID string
+	credentials   Credentials
+}
+
+// placement returns the order r places, if r is a single order placement.
+func (r *request) placement() (placedOrder, bool) {
+	switch b := r.body.(type) {
+	case PlaceLimitOrderRequest:
+		return placedOrder{b.Symbol, b.ClientOrderID, b.Credentials}, true
+	case PlaceMarketOrderRequest:
+		return placedOrder{b.Symbol, b.ClientOrderID, b.Credentials}, true
+	case StopOrderRequest:
+		return placedOrder{b.Symbol, b.ClientOrderID, b.Credentials}, true
+	}
+	return placedOrder{}, false
+}
+
+// reconcile looks the order up by its ClientOrderId, returning nil when the
+// exchange does not know it.
+func (s *SbeeRest) reconcile(ctx context.Context, r *request, o placedOrder) (*Order, error) {
+	resp, err := s.orderHistory(ctx, r.exchange, r.trade, o.symbol, "ALL", o.credentials)
+	if err != nil {
+		return nil, err
+	}
+	for i := range resp.Data {
+		if resp.Data[i].ClientOrderID == o.clientOrderID {
+			return &resp.Data[i], nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,171 @@
+package sbee
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fastRetry retries quickly so tests do not wait on real backoff.
+var fastRetry = RetryPolicy{
+	MaxAttempts:        3,
+	InitialBackoff:     time.Millisecond,
+	Multiplier:         2,
+	RetryableStatuses:  []int{http.StatusServiceUnavailable},
+	RetryNetworkErrors: true,
+}
+
+// scriptedServer answers each endpoint, keyed by the last path element, with
+// the next of its scripted replies, repeating the last one, and counts calls.
+type scriptedServer struct {
+	mu      sync.Mutex
+	replies map[string][]scriptedReply
+	calls   map[string]int
+}
+
+type scriptedReply struct {
+	status int
+	body   string
+}
+
+func newScriptedServer(t *testing.T, replies map[string][]scriptedReply, opts ...Option) (*SbeeRest, *scriptedServer) {
+	t.Helper()
+	ss := &scriptedServer{replies: replies, calls: map[string]int{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		endpoint := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		ss.mu.Lock()
+		n := ss.calls[endpoint]
+		ss.calls[endpoint]++
+		script := ss.replies[endpoint]
+		ss.mu.Unlock()
+		if len(script) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		reply := script[min(n, len(script)-1)]
+		w.WriteHeader(reply.status)
+		io.WriteString(w, reply.body)
+	}))
+	t.Cleanup(srv.Close)
+	return NewClient("token", append([]Option{WithBaseURL(srv.URL), WithRetryPolicy(fastRetry)}, opts...)...), ss
+}
+
+func (ss *scriptedServer) count(endpoint string) int {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return ss.calls[endpoint]
+}
+
+var (
+	unavailable = scriptedReply{http.StatusServiceUnavailable, `{"isSuccess":false,"message":"busy"}`}
+	succeeded   = scriptedReply{http.StatusOK, `{"isSuccess":true}`}
+)
+
+func TestRetrySafeCalls(t *testing.T) {
+	s, ss := newScriptedServer(t, map[string][]scriptedReply{
+		"OrderBook":    {unavailable, succeeded},
+		"OrderHistory": {unavailable, unavailable, unavailable, succeeded},
+		"CancelOrder":  {unavailable, succeeded},
+	})
+	ctx := context.Background()
+
+	if _, err := s.OrderBook(ctx, "Binance", "Spot", "BTC-USDT", 20); err != nil {
+		t.Errorf("OrderBook: %v", err)
+	}
+	if n := ss.count("OrderBook"); n != 2 {
+		t.Errorf("OrderBook sent %d times, want 2", n)
+	}
+
+	_, err := s.OrderHistory(ctx, "Binance", "Spot", "BTC-USDT", "ALL", "key", "secret", "pass")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("OrderHistory err = %v, want the last 503", err)
+	}
+	if n := ss.count("OrderHistory"); n != fastRetry.MaxAttempts {
+		t.Errorf("OrderHistory sent %d times, want %d", n, fastRetry.MaxAttempts)
+	}
+
+	if _, err := s.CancelOrder(ctx, "Binance", "Spot", "BTC-USDT", "key", "secret", "pass", "1", ""); err == nil {
+		t.Error("CancelOrder retried")
+	}
+	if n := ss.count("CancelOrder"); n != 1 {
+		t.Errorf("CancelOrder sent %d times, want 1", n)
+	}
+}
+
+func TestRetryOrders(t *testing.T) {
+	ctx := context.Background()
+	place := func(s *SbeeRest, clientOrderID string) (*Response[Order], error) {
+		return s.PlaceLimitOrder(ctx, "Binance", "Spot", "BTC-USDT", clientOrderID, d("16000"), d("0"), d("0.005"), "BUY", "key", "secret", "pass")
+	}
+
+	t.Run("without ClientOrderId", func(t *testing.T) {
+		s, ss := newScriptedServer(t, map[string][]scriptedReply{"PlaceLimitOrder": {unavailable, succeeded}}, WithClientOrderIDs(nil))
+		if _, err := place(s, ""); err == nil {
+			t.Error("order without ClientOrderId was retried")
+		}
+		if n := ss.count("PlaceLimitOrder"); n != 1 {
+			t.Errorf("PlaceLimitOrder sent %d times, want 1", n)
+		}
+	})
+
+	t.Run("not placed", func(t *testing.T) {
+		s, ss := newScriptedServer(t, map[string][]scriptedReply{
+			"PlaceLimitOrder": {unavailable, {http.StatusOK, `{"isSuccess":true,"data":{"orderId":"2","clientOrderId":"ID1"}}`}},
+			"OrderHistory":    {{http.StatusOK, `{"isSuccess":true,"data":[{"orderId":"1","clientOrderId":"OTHER"}]}`}},
+		})
+		resp, err := place(s, "ID1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Data.OrderID != "2" || ss.count("PlaceLimitOrder") != 2 || ss.count("OrderHistory") != 1 {
+			t.Errorf("order %q after %d placements and %d lookups", resp.Data.OrderID, ss.count("PlaceLimitOrder"), ss.count("OrderHistory"))
+		}
+	})
+
+	t.Run("already placed", func(t *testing.T) {
+		s, ss := newScriptedServer(t, map[string][]scriptedReply{
+			"PlaceLimitOrder": {unavailable, succeeded},
+			"OrderHistory":    {{http.StatusOK, `{"isSuccess":true,"data":[{"orderId":"7","clientOrderId":"ID1","status":"NEW"}]}`}},
+		})
+		resp, err := place(s, "ID1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Data.OrderID != "7" || ss.count("PlaceLimitOrder") != 1 {
+			t.Errorf("order %q after %d placements, want the existing order and no resend", resp.Data.OrderID, ss.count("PlaceLimitOrder"))
+		}
+	})
+
+	t.Run("lookup fails", func(t *testing.T) {
+		s, ss := newScriptedServer(t, map[string][]scriptedReply{
+			"PlaceLimitOrder": {unavailable, succeeded},
+			"OrderHistory":    {{http.StatusUnauthorized, `{"isSuccess":false}`}},
+		})
+		_, err := place(s, "ID1")
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.Endpoint != "PlaceLimitOrder" || apiErr.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("err = %v, want the original placement error", err)
+		}
+		if n := ss.count("PlaceLimitOrder"); n != 1 {
+			t.Errorf("PlaceLimitOrder sent %d times, want 1", n)
+		}
+	})
+}
+
+func TestRetryBackoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2, Jitter: 0.5}
+	for n, want := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		want *= time.Millisecond
+		got := p.backoff(n + 1)
+		if got > want || got < want/2 {
+			t.Errorf("backoff(%d) = %v, want within [%v, %v]", n+1, got, want/2, want)
+		}
+	}
+}
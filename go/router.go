@@ -0,0 +1,463 @@
+package sbee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrNoRoute is returned by PlanRoute and RouteOrder when no venue can take
+// any part of the order. RoutePlan.Skipped says why.
+var ErrNoRoute = errors.New("sbee: no venue can fill the order")
+
+// DefaultTakerFee is the taker fee rate assumed of a RouteVenue without
+// one: 0.1%.
+var DefaultTakerFee = NewDecimal(1, 3)
+
+// RouteVenue is an exchange the router may use and the account to trade on it.
+type RouteVenue struct {
+	Exchange string
+	Credentials
+	// TakerFee is the fee rate the venue charges on the quote value of a
+	// buy, which the venue's share must leave room for in its balance. Zero
+	// means DefaultTakerFee.
+	TakerFee Decimal
+}
+
+// takerFee returns the fee rate of v.
+func (v RouteVenue) takerFee() Decimal {
+	if v.TakerFee.IsZero() {
+		return DefaultTakerFee
+	}
+	return v.TakerFee
+}
+
+// RouteRequest is a parent order to split across venues.
+type RouteRequest struct {
+	Trade  string
+	Symbol string
+	Side   string
+	// Quantity is the parent size in the base asset.
+	Quantity Decimal
+	// LimitPrice, when set, bounds the levels the router takes: no child
+	// buys above it or sells below it.
+	LimitPrice Decimal
+	// Market places the children as market orders. By default they are
+	// limit orders at the worst price planned on their venue, so a child
+	// never fills beyond the book it was planned on.
+	Market bool
+	// ClientOrderID, when set, gives child n the ClientOrderId
+	// ClientOrderID-n, or as close to it as its venue's ClientOrderIDLimits
+	// allow: with the venue's prefix, "_" or no separator where "-" is not
+	// accepted, and ClientOrderID shortened to leave room for n. Otherwise
+	// each child gets one from the client's generator, see
+	// WithClientOrderIDs.
+	ClientOrderID string
+	// Depth is the book depth fetched from each venue; 0 uses the server
+	// default.
+	Depth int
+	// Venues lists at most one account per exchange.
+	Venues []RouteVenue
+}
+
+// RouteChild is the part of a parent order planned on one venue.
+type RouteChild struct {
+	Exchange      string
+	ClientOrderID string
+	Quantity      Decimal
+	// Price is the limit price of the child: the worst level it is planned
+	// to reach, rounded to the venue's price scale.
+	Price Decimal
+	// Expected is the fill the venue's book promised when planning.
+	Expected Fill
+
+	venue RouteVenue
+}
+
+// RouteSkip is a venue left out of a plan and the reason.
+type RouteSkip struct {
+	Exchange string
+	Reason   string
+	// Err is the error behind Reason when the venue could not be queried.
+	Err error
+}
+
+// RoutePlan is how a parent order would be split.
+type RoutePlan struct {
+	Request  RouteRequest
+	Book     *ConsolidatedBook
+	Children []RouteChild
+	// Expected is the combined fill of the children.
+	Expected Fill
+	// Unrouted is the part of Quantity no venue can take within the
+	// balances, the instrument minimums and LimitPrice.
+	Unrouted Decimal
+	Skipped  []RouteSkip
+}
+
+// ChildResult is the outcome of one child order.
+type ChildResult struct {
+	RouteChild
+	// Order is the venue's answer, nil when placement failed with Err.
+	Order *Order
+	Err   error
+}
+
+// RouteResult aggregates the children of a routed order.
+type RouteResult struct {
+	Plan     *RoutePlan
+	Children []ChildResult
+	// Executed is the base quantity filled across children, Notional its
+	// quote value and AvgPrice Notional / Executed. The quote value of a
+	// child is the QuoteQuantity its venue reports, children being sized in
+	// base; failing that, that of a market child at its reported average
+	// Price, and that of a limit child at the prices planned for it.
+	Executed Decimal
+	Notional Decimal
+	AvgPrice Decimal
+}
+
+// Filled reports whether the children filled the whole parent quantity.
+func (r *RouteResult) Filled() bool {
+	return r.Executed.GreaterThanOrEqual(r.Plan.Request.Quantity)
+}
+
+// RouteOrder splits a parent order across req.Venues for the best expected
+// fill and places the children concurrently. See PlanRoute and ExecuteRoute.
+func (s *SbeeRest) RouteOrder(ctx context.Context, req RouteRequest) (*RouteResult, error) {
+	plan, err := s.PlanRoute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.ExecuteRoute(ctx, plan)
+}
+
+// PlanRoute splits a parent order without placing it. It merges the books of
+// the venues, then walks them best price first, giving each level to the
+// venue that quotes it as long as the venue's free balance covers it: the
+// quote asset, with the taker fee, for a buy, the base asset for a sell.
+// Each venue's share is rounded down to its quantity scale; a venue whose
+// share falls below its minimum quantity or notional from Currencies is
+// dropped and the rest replanned without it.
+//
+// Venues whose book, balances or rules cannot be loaded are skipped. The
+// plan is returned with an error matching ErrNoRoute when no child is left,
+// and none with an *OrderError when Venues lists an exchange twice.
+func (s *SbeeRest) PlanRoute(ctx context.Context, req RouteRequest) (*RoutePlan, error) {
+	sell := strings.EqualFold(req.Side, SideSell)
+	if !sell && !strings.EqualFold(req.Side, SideBuy) {
+		return nil, &OrderError{Endpoint: "RouteOrder", Symbol: req.Symbol, Field: "side", Value: req.Side, Reason: "must be BUY or SELL"}
+	}
+	if req.Quantity.Sign() <= 0 {
+		return nil, &OrderError{Endpoint: "RouteOrder", Symbol: req.Symbol, Field: "quantity", Value: req.Quantity.String(), Reason: "must be positive"}
+	}
+	seen := map[string]bool{}
+	for _, v := range req.Venues {
+		ex := strings.ToLower(s.exchange(v.Exchange))
+		if seen[ex] {
+			return nil, &OrderError{Endpoint: "RouteOrder", Exchange: v.Exchange, Symbol: req.Symbol, Field: "venues", Value: v.Exchange, Reason: "lists the exchange more than once"}
+		}
+		seen[ex] = true
+	}
+
+	venues := make([]venueState, len(req.Venues))
+	exchanges := make([]string, len(req.Venues))
+	var book *ConsolidatedBook
+	var bookErr error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i, v := range req.Venues {
+			exchanges[i] = s.exchange(v.Exchange)
+		}
+		book, bookErr = s.ConsolidatedBook(ctx, ConsolidatedBookRequest{
+			Trade:     req.Trade,
+			Symbol:    req.Symbol,
+			Depth:     req.Depth,
+			Exchanges: exchanges,
+		})
+	}()
+	for i, v := range req.Venues {
+		wg.Add(1)
+		go func(i int, v RouteVenue) {
+			defer wg.Done()
+			venues[i] = s.loadVenue(ctx, req, v, sell)
+		}(i, v)
+	}
+	wg.Wait()
+	if bookErr != nil {
+		return nil, bookErr
+	}
+
+	plan := &RoutePlan{Request: req, Book: book}
+	for _, st := range book.Exchanges {
+		if !st.IsSuccess {
+			plan.Skipped = append(plan.Skipped, RouteSkip{Exchange: st.ExchangeName, Reason: "order book unavailable: " + st.ErrorMessage})
+		}
+	}
+	eligible := map[string]*venueState{}
+	for i := range venues {
+		v := &venues[i]
+		if v.skip != nil {
+			plan.Skipped = append(plan.Skipped, *v.skip)
+			continue
+		}
+		eligible[strings.ToLower(v.venue.Exchange)] = v
+	}
+
+	levels := book.takerSide(req.Side)
+	for {
+		shares := allocate(levels, req, eligible, sell)
+		plan.Children = plan.Children[:0]
+		dropped := false
+		for _, v := range venues {
+			share, ok := shares[strings.ToLower(v.venue.Exchange)]
+			if !ok || eligible[strings.ToLower(v.venue.Exchange)] == nil {
+				continue
+			}
+			qty := v.rules.RoundQuantity(share)
+			fill := book.VenuePrice(v.venue.Exchange, req.Side, qty)
+			reason := ""
+			switch {
+			case qty.Sign() <= 0:
+				reason = fmt.Sprintf("share %s rounds to zero", share)
+			case v.rules.MinQuantity.Sign() > 0 && qty.LessThan(v.rules.MinQuantity):
+				reason = fmt.Sprintf("share %s is below the minimum quantity %s", qty, v.rules.MinQuantity)
+			case v.rules.MinNotional.Sign() > 0 && fill.Notional.LessThan(v.rules.MinNotional):
+				reason = fmt.Sprintf("share notional %s is below the minimum notional %s", fill.Notional, v.rules.MinNotional)
+			}
+			if reason != "" {
+				plan.Skipped = append(plan.Skipped, RouteSkip{Exchange: v.venue.Exchange, Reason: reason})
+				delete(eligible, strings.ToLower(v.venue.Exchange))
+				dropped = true
+				continue
+			}
+			mode := RoundUp
+			if sell {
+				mode = RoundDown
+			}
+			plan.Children = append(plan.Children, RouteChild{
+				Exchange: v.venue.Exchange,
+				Quantity: qty,
+				Price:    v.rules.RoundPrice(fill.WorstPrice, mode),
+				Expected: fill,
+				venue:    v.venue,
+			})
+		}
+		if !dropped {
+			break
+		}
+	}
+
+	var routed Decimal
+	for i := range plan.Children {
+		c := &plan.Children[i]
+		if req.ClientOrderID != "" {
+			limits := ClientOrderIDLimits(c.Exchange)
+			c.ClientOrderID = limits.child(req.ClientOrderID, i+1)
+			if err := limits.Check(c.ClientOrderID); err != nil {
+				return nil, &OrderError{Endpoint: "RouteOrder", Exchange: c.Exchange, Symbol: req.Symbol, Field: "clientOrderId", Value: req.ClientOrderID, Reason: err.Error()}
+			}
+		} else {
+			c.ClientOrderID = s.newClientOrderID(c.Exchange)
+		}
+		routed = routed.Add(c.Quantity)
+		plan.Expected.Notional = plan.Expected.Notional.Add(c.Expected.Notional)
+		plan.Expected.Venues = append(plan.Expected.Venues, VenueQuantity{c.Exchange, c.Quantity})
+		if plan.Expected.WorstPrice.IsZero() || beyond(c.Expected.WorstPrice, plan.Expected.WorstPrice, bookSide(sell)) {
+			plan.Expected.WorstPrice = c.Expected.WorstPrice
+		}
+	}
+	plan.Expected.Size = routed
+	plan.Expected.Complete = routed.GreaterThanOrEqual(req.Quantity)
+	if routed.Sign() > 0 {
+		plan.Expected.AvgPrice = plan.Expected.Notional.Div(routed)
+	}
+	plan.Unrouted = MaxDecimal(req.Quantity.Sub(routed), Decimal{})
+	if len(plan.Children) == 0 {
+		return plan, fmt.Errorf("sbee: RouteOrder %s %s: %w", req.Side, req.Symbol, ErrNoRoute)
+	}
+	return plan, nil
+}
+
+// ExecuteRoute places the children of plan concurrently and aggregates their
+// fills. A child whose ClientOrderId its venue would reject fails with an
+// *OrderError without being sent. It returns an error, joining the
+// children's, only when every child failed; otherwise failures are reported
+// per child.
+func (s *SbeeRest) ExecuteRoute(ctx context.Context, plan *RoutePlan) (*RouteResult, error) {
+	req := plan.Request
+	res := &RouteResult{Plan: plan, Children: make([]ChildResult, len(plan.Children))}
+	var wg sync.WaitGroup
+	for i, c := range plan.Children {
+		wg.Add(1)
+		go func(i int, c RouteChild) {
+			defer wg.Done()
+			var resp *Response[Order]
+			var err error
+			if c.ClientOrderID != "" {
+				err = ClientOrderIDLimits(c.Exchange).Check(c.ClientOrderID)
+			}
+			switch {
+			case err != nil:
+				err = &OrderError{Endpoint: "RouteOrder", Exchange: c.Exchange, Symbol: req.Symbol, Field: "clientOrderId", Value: c.ClientOrderID, Reason: err.Error()}
+			case req.Market:
+				resp, err = s.placeMarketOrder(ctx, c.Exchange, req.Trade, req.Symbol, c.ClientOrderID,
+					c.Price, Decimal{}, c.Quantity, 0, 0, req.Side, c.venue.Credentials)
+			default:
+				resp, err = s.placeLimitOrder(ctx, c.Exchange, req.Trade, req.Symbol, c.ClientOrderID,
+					c.Price, Decimal{}, c.Quantity, req.Side, c.venue.Credentials)
+			}
+			res.Children[i] = ChildResult{RouteChild: c, Err: err}
+			if err == nil {
+				o := resp.Data
+				res.Children[i].Order = &o
+			}
+		}(i, c)
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, c := range res.Children {
+		if c.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.Exchange, c.Err))
+			continue
+		}
+		res.Executed = res.Executed.Add(c.Order.ExecutedQuantity)
+		res.Notional = res.Notional.Add(c.executedQuote(req.Market))
+	}
+	if res.Executed.Sign() > 0 {
+		res.AvgPrice = res.Notional.Div(res.Executed)
+	}
+	if len(errs) > 0 && len(errs) == len(res.Children) {
+		return res, errors.Join(errs...)
+	}
+	return res, nil
+}
+
+// executedQuote returns the quote value of the fills of c, see RouteResult.
+func (c ChildResult) executedQuote(market bool) Decimal {
+	o := c.Order
+	switch {
+	case o.ExecutedQuantity.Sign() <= 0:
+		return Decimal{}
+	case o.QuoteQuantity.Sign() > 0:
+		return o.QuoteQuantity
+	case market && o.Price.Sign() > 0:
+		return o.ExecutedQuantity.Mul(o.Price)
+	}
+	return o.ExecutedQuantity.Mul(c.Expected.AvgPrice)
+}
+
+// venueState is what the router knows of one venue when planning.
+type venueState struct {
+	venue RouteVenue
+	rules Rules
+	// free is the balance that limits the venue's share: quote for a buy,
+	// base for a sell.
+	free Decimal
+	skip *RouteSkip
+}
+
+func (s *SbeeRest) loadVenue(ctx context.Context, req RouteRequest, v RouteVenue, sell bool) venueState {
+	v.Exchange = s.exchange(v.Exchange)
+	st := venueState{venue: v}
+	fail := func(what string, err error) venueState {
+		st.skip = &RouteSkip{Exchange: v.Exchange, Reason: what + ": " + err.Error(), Err: err}
+		return st
+	}
+	rules, err := s.Rules(ctx, v.Exchange, req.Trade, req.Symbol)
+	if err != nil {
+		return fail("rules unavailable", err)
+	}
+	if !rules.IsTradable {
+		st.skip = &RouteSkip{Exchange: v.Exchange, Reason: req.Symbol + " is not tradable"}
+		return st
+	}
+	st.rules = rules
+	resp, err := s.tradingBalances(ctx, v.Exchange, req.Trade, "", v.Credentials)
+	if err != nil {
+		return fail("balances unavailable", err)
+	}
+	asset := routeAsset(rules, req.Symbol, sell)
+	for _, b := range resp.Data {
+		if strings.EqualFold(b.Symbol, asset) {
+			st.free = b.Free
+		}
+	}
+	if st.free.Sign() <= 0 {
+		st.skip = &RouteSkip{Exchange: v.Exchange, Reason: "no free " + asset + " balance"}
+	}
+	return st
+}
+
+// routeAsset returns the asset a venue must hold: the quote asset to buy,
+// the base asset to sell. Symbols are BASE-QUOTE when Currencies does not say.
+func routeAsset(r Rules, symbol string, sell bool) string {
+	base, quote, _ := strings.Cut(symbol, "-")
+	if r.BaseCurrency != "" {
+		base = r.BaseCurrency
+	}
+	if r.QuoteCurrency != "" {
+		quote = r.QuoteCurrency
+	}
+	if sell {
+		return base
+	}
+	return quote
+}
+
+// allocate walks levels best first and returns the base quantity each
+// eligible venue takes, keyed by lower-cased exchange. A buy share and its
+// taker fee fit the venue's free quote balance.
+func allocate(levels []ConsolidatedLevel, req RouteRequest, eligible map[string]*venueState, sell bool) map[string]Decimal {
+	shares := map[string]Decimal{}
+	spent := map[string]Decimal{}
+	remaining := req.Quantity
+	for _, l := range levels {
+		if remaining.Sign() <= 0 {
+			break
+		}
+		if req.LimitPrice.Sign() > 0 && beyond(l.Price, req.LimitPrice, bookSide(sell)) {
+			break
+		}
+		for _, vq := range l.Venues {
+			if remaining.Sign() <= 0 {
+				break
+			}
+			key := strings.ToLower(vq.Exchange)
+			v := eligible[key]
+			if v == nil {
+				continue
+			}
+			cost := l.Price.Mul(one.Add(v.venue.takerFee()))
+			capacity := v.free.Sub(spent[key])
+			if !sell {
+				capacity = capacity.DivRound(cost, DivisionPrecision, RoundDown)
+			}
+			take := MinDecimal(vq.Size, remaining, capacity)
+			if take.Sign() <= 0 {
+				continue
+			}
+			shares[key] = shares[key].Add(take)
+			remaining = remaining.Sub(take)
+			if sell {
+				spent[key] = spent[key].Add(take)
+			} else {
+				spent[key] = spent[key].Add(take.Mul(cost))
+			}
+		}
+	}
+	return shares
+}
+
+// bookSide returns the side of the book a taker walks: bids for a sell.
+func bookSide(sell bool) BookSide {
+	if sell {
+		return BidSide
+	}
+	return AskSide
+}
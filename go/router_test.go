@@ -0,0 +1,175 @@
+package sbee
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newRouterServer serves three venues for BTC-USDT:
+//
+//	Binance asks 100 x 2, 102 x 5; 1000 USDT free
+//	OKX     asks 101 x 2;          151.6515 USDT free, 1.5 at 101 and 0.1%
+//	KuCoin  asks 100.5 x 0.001;    below its 0.01 minimum quantity
+//
+// Binance fills orders whole at an average of 101, reported as their
+// QuoteQuantity; OKX rejects orders for insufficient balance. Placed orders
+// are recorded in placed, keyed by exchange.
+func newRouterServer(t *testing.T) (*SbeeRest, map[string]PlaceLimitOrderRequest) {
+	t.Helper()
+	books := map[string]string{
+		"Binance": `{"asks":[{"price":"100","size":"2"},{"price":"102","size":"5"}]}`,
+		"OKX":     `{"asks":[{"price":"101","size":"2"}]}`,
+		"KuCoin":  `{"asks":[{"price":"100.5","size":"0.001"}]}`,
+	}
+	var mu sync.Mutex
+	placed := map[string]PlaceLimitOrderRequest{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(r.URL.Path, "/")
+		exchange, endpoint := parts[2], parts[len(parts)-1]
+		switch {
+		case exchange == "MultiMarket":
+			var req MultiMarketRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			ex := req.Exchanges[0]
+			io.WriteString(w, `{"isSuccess":true,"data":{"exchanges":[{"exchangeName":"`+ex+`","isSuccess":true}],"orderBook":`+books[ex]+`}}`)
+		case endpoint == "Currencies":
+			io.WriteString(w, `{"isSuccess":true,"data":[{"symbol":"BTC-USDT","baseCurrency":"BTC","quoteCurrency":"USDT","isTradable":true,"priceScale":2,"quantityScale":3,"minQuantity":"0.01","minNotional":"10"}]}`)
+		case endpoint == "TradingBalances":
+			free := map[string]string{"Binance": "1000", "OKX": "151.6515", "KuCoin": "1000"}[exchange]
+			io.WriteString(w, `{"isSuccess":true,"data":[{"symbol":"BTC","free":"0"},{"symbol":"USDT","free":"`+free+`"}]}`)
+		case endpoint == "PlaceLimitOrder":
+			var req PlaceLimitOrderRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			mu.Lock()
+			placed[exchange] = req
+			mu.Unlock()
+			if exchange == "OKX" {
+				io.WriteString(w, `{"isSuccess":false,"message":"Account has insufficient balance for requested action.","errorCode":"-2010"}`)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{"isSuccess": true, "data": Order{
+				Symbol:           req.Symbol,
+				ClientOrderID:    req.ClientOrderID,
+				Side:             req.Side,
+				Status:           "FILLED",
+				Price:            req.Price,
+				BaseQuantity:     req.BaseQuantity,
+				ExecutedQuantity: req.BaseQuantity,
+				QuoteQuantity:    req.BaseQuantity.Mul(d("101")),
+			}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return NewClient("token", WithBaseURL(srv.URL), WithRetryPolicy(RetryPolicy{})), placed
+}
+
+var routeRequest = RouteRequest{
+	Trade:         "Spot",
+	Symbol:        "BTC-USDT",
+	Side:          SideBuy,
+	Quantity:      d("5"),
+	ClientOrderID: "parent",
+	Venues:        []RouteVenue{{Exchange: "Binance"}, {Exchange: "OKX"}, {Exchange: "KuCoin"}},
+}
+
+func TestPlanRoute(t *testing.T) {
+	s, _ := newRouterServer(t)
+	plan, err := s.PlanRoute(context.Background(), routeRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// KuCoin's share is below its minimum, so it is dropped and its level
+	// goes to Binance's 102 instead. OKX's balance buys 1.5 at 101.
+	if len(plan.Children) != 2 {
+		t.Fatalf("children = %+v", plan.Children)
+	}
+	binance, okx := plan.Children[0], plan.Children[1]
+	if binance.Exchange != "Binance" || !binance.Quantity.Equal(d("3.5")) || binance.Price.String() != "102" || binance.ClientOrderID != "parent-1" {
+		t.Errorf("Binance child = %+v", binance)
+	}
+	if okx.Exchange != "OKX" || !okx.Quantity.Equal(d("1.5")) || okx.Price.String() != "101" || okx.ClientOrderID != "parent2" {
+		t.Errorf("OKX child = %+v", okx)
+	}
+	if len(plan.Skipped) != 1 || plan.Skipped[0].Exchange != "KuCoin" || !strings.Contains(plan.Skipped[0].Reason, "minimum quantity") {
+		t.Errorf("skipped = %+v", plan.Skipped)
+	}
+	if !plan.Expected.Complete || !plan.Unrouted.IsZero() || !plan.Expected.Notional.Equal(d("504.5")) {
+		t.Errorf("expected fill = %+v, unrouted %s", plan.Expected, plan.Unrouted)
+	}
+
+	// A limit price below Binance's second level caps the route.
+	req := routeRequest
+	req.LimitPrice = d("101")
+	plan, err = s.PlanRoute(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.Expected.Size.Equal(d("3.5")) || !plan.Unrouted.Equal(d("1.5")) {
+		t.Errorf("with limit: routed %s, unrouted %s", plan.Expected.Size, plan.Unrouted)
+	}
+
+	req.LimitPrice = d("99")
+	if _, err := s.PlanRoute(context.Background(), req); !errors.Is(err, ErrNoRoute) {
+		t.Errorf("limit below the book: err = %v", err)
+	}
+
+	// At a 1% fee OKX's balance buys less, and Binance takes the rest at 102.
+	req = routeRequest
+	req.Venues = []RouteVenue{{Exchange: "Binance"}, {Exchange: "OKX", TakerFee: d("0.01")}}
+	plan, err = s.PlanRoute(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if okx := plan.Children[1]; !okx.Quantity.Equal(d("1.486")) || !plan.Children[0].Quantity.Equal(d("3.513")) {
+		t.Errorf("with a 1%% fee: children = %+v", plan.Children)
+	}
+
+	// A parent id a venue cannot take is refused before anything is sent.
+	req = routeRequest
+	req.ClientOrderID = "parent/1"
+	var orderErr *OrderError
+	if _, err := s.PlanRoute(context.Background(), req); !errors.As(err, &orderErr) || orderErr.Field != "clientOrderId" {
+		t.Errorf("parent id with '/': err = %v", err)
+	}
+
+	// Two accounts on one exchange would each be planned its whole share.
+	req = routeRequest
+	req.Venues = []RouteVenue{{Exchange: "Binance"}, {Exchange: "binance", Credentials: Credentials{Account: "alt"}}}
+	if _, err := s.PlanRoute(context.Background(), req); !errors.As(err, &orderErr) || orderErr.Field != "venues" {
+		t.Errorf("duplicate exchange: err = %v", err)
+	}
+	req.Venues = []RouteVenue{{}, {Exchange: "Binance"}}
+	WithDefaultExchange("Binance")(s)
+	if _, err := s.RouteOrder(context.Background(), req); !errors.Is(err, ErrInvalidOrder) {
+		t.Errorf("default exchange listed again: err = %v", err)
+	}
+}
+
+func TestRouteOrder(t *testing.T) {
+	s, placed := newRouterServer(t)
+	res, err := s.RouteOrder(context.Background(), routeRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := placed["Binance"]; got.ClientOrderID != "parent-1" || !got.BaseQuantity.Equal(d("3.5")) || got.Side != SideBuy {
+		t.Errorf("Binance order = %+v", got)
+	}
+	if _, ok := placed["KuCoin"]; ok {
+		t.Error("order placed on a skipped venue")
+	}
+	if len(res.Children) != 2 || res.Children[0].Order == nil || !errors.Is(res.Children[1].Err, ErrInsufficientBalance) {
+		t.Fatalf("children = %+v", res.Children)
+	}
+	if res.Filled() || !res.Executed.Equal(d("3.5")) || !res.Notional.Equal(d("353.5")) || !res.AvgPrice.Equal(d("101")) {
+		t.Errorf("executed %s at %s, filled %v", res.Executed, res.AvgPrice, res.Filled())
+	}
+}
@@ -0,0 +1,437 @@
+package sbee
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRulesTTL is how long the instrument rules loaded from Currencies are
+// reused before they are fetched again.
+const DefaultRulesTTL = time.Hour
+
+// RulesPolicy says what the order methods do with the instrument rules of the
+// symbol being traded.
+type RulesPolicy int
+
+const (
+	// RulesOff sends orders as given. It is the default.
+	RulesOff RulesPolicy = iota
+	// RulesValidate rejects orders that break the rules with an *OrderError
+	// before they are sent.
+	RulesValidate
+	// RulesRound rounds prices to the price scale and quantities down to the
+	// quantity scale, then validates what is left. Limit prices are rounded
+	// away from the market: buys down, sells up.
+	RulesRound
+)
+
+// WithOrderRules makes PlaceLimitOrder, PlaceMarketOrder, the stop-loss and
+// take-profit calls and the batch placement calls check each order against
+// the rules returned by Currencies for its exchange and trade.
+func WithOrderRules(policy RulesPolicy) Option {
+	return func(s *SbeeRest) {
+		s.rulesPolicy = policy
+	}
+}
+
+// WithRulesTTL sets how long instrument rules are cached. The default is
+// DefaultRulesTTL.
+func WithRulesTTL(d time.Duration) Option {
+	return func(s *SbeeRest) {
+		s.rules.ttl = d
+	}
+}
+
+// Rules are the trading constraints of one symbol, as listed by Currencies.
+type Rules struct {
+	Symbol        string
+	BaseCurrency  string
+	QuoteCurrency string
+	IsTradable    bool
+	PriceScale    int32
+	QuantityScale int32
+	MinQuantity   Decimal
+	MinNotional   Decimal
+}
+
+// maxRulesScale is the largest price or quantity scale RulesFromCurrency
+// accepts: 18, the most decimals a token has (ERC-20's default).
+const maxRulesScale = 18
+
+// RulesFromCurrency returns the rules described by c. It fails if c has a
+// price or quantity scale below 0 or above 18, which no instrument has.
+func RulesFromCurrency(c Currency) (Rules, error) {
+	for _, f := range []struct {
+		name  string
+		scale int
+	}{{"priceScale", c.PriceScale}, {"quantityScale", c.QuantityScale}} {
+		if f.scale < 0 || f.scale > maxRulesScale {
+			return Rules{}, fmt.Errorf("sbee: currency %s: %s %d out of range [0, %d]", c.Symbol, f.name, f.scale, maxRulesScale)
+		}
+	}
+	return Rules{
+		Symbol:        c.Symbol,
+		BaseCurrency:  c.BaseCurrency,
+		QuoteCurrency: c.QuoteCurrency,
+		IsTradable:    c.IsTradable,
+		PriceScale:    int32(c.PriceScale),
+		QuantityScale: int32(c.QuantityScale),
+		MinQuantity:   c.MinQuantity,
+		MinNotional:   c.MinNotional,
+	}, nil
+}
+
+// RoundPrice rounds p to the price scale with mode.
+func (r Rules) RoundPrice(p Decimal, mode RoundingMode) Decimal {
+	if p.Scale() <= r.PriceScale {
+		return p
+	}
+	return p.Round(r.PriceScale, mode)
+}
+
+// RoundQuantity rounds q down to the quantity scale, so a rounded order never
+// spends more than requested.
+func (r Rules) RoundQuantity(q Decimal) Decimal {
+	if q.Scale() <= r.QuantityScale {
+		return q
+	}
+	return q.Round(r.QuantityScale, RoundDown)
+}
+
+// Rules returns the instrument rules of symbol on exchange and trade, loading
+// them through Currencies when they are not cached or have expired. An unknown
+// symbol is reported as an *OrderError matching ErrInvalidSymbol, a symbol
+// whose scales RulesFromCurrency rejects with its error.
+func (s *SbeeRest) Rules(ctx context.Context, Exchange, Trade, symbol string) (Rules, error) {
+	exchange, trade := s.exchange(Exchange), s.trade(Trade)
+	e, err := s.rules.get(ctx, s, exchange, trade)
+	if err != nil {
+		return Rules{}, err
+	}
+	if err := e.invalid[strings.ToUpper(symbol)]; err != nil {
+		return Rules{}, err
+	}
+	r, ok := e.rules[strings.ToUpper(symbol)]
+	if !ok {
+		return Rules{}, &OrderError{
+			Exchange: exchange,
+			Symbol:   symbol,
+			Field:    "symbol",
+			Reason:   fmt.Sprintf("not listed by Currencies on %s %s", exchange, trade),
+		}
+	}
+	return r, nil
+}
+
+// RefreshRules reloads the instrument rules of exchange and trade.
+func (s *SbeeRest) RefreshRules(ctx context.Context, Exchange, Trade string) error {
+	_, err := s.rules.load(ctx, s, s.exchange(Exchange), s.trade(Trade))
+	return err
+}
+
+// rulesCache holds the rules of every symbol, per exchange and trade.
+type rulesCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	markets map[string]rulesEntry
+}
+
+type rulesEntry struct {
+	loaded time.Time
+	rules  map[string]Rules
+	// invalid holds why the symbols whose Currencies entry was rejected by
+	// RulesFromCurrency have no rules.
+	invalid map[string]error
+}
+
+func (c *rulesCache) get(ctx context.Context, s *SbeeRest, exchange, trade string) (rulesEntry, error) {
+	c.mu.Lock()
+	e, ok := c.markets[exchange+"/"+trade]
+	c.mu.Unlock()
+	if ok && time.Since(e.loaded) < c.ttl {
+		return e, nil
+	}
+	return c.load(ctx, s, exchange, trade)
+}
+
+func (c *rulesCache) load(ctx context.Context, s *SbeeRest, exchange, trade string) (rulesEntry, error) {
+	resp, err := s.Currencies(ctx, exchange, trade)
+	if err != nil {
+		return rulesEntry{}, err
+	}
+	e := rulesEntry{loaded: time.Now(), rules: make(map[string]Rules, len(resp.Data)), invalid: make(map[string]error)}
+	for _, cur := range resp.Data {
+		r, err := RulesFromCurrency(cur)
+		if err != nil {
+			e.invalid[strings.ToUpper(cur.Symbol)] = err
+			continue
+		}
+		e.rules[strings.ToUpper(cur.Symbol)] = r
+	}
+	c.mu.Lock()
+	if c.markets == nil {
+		c.markets = make(map[string]rulesEntry)
+	}
+	c.markets[exchange+"/"+trade] = e
+	c.mu.Unlock()
+	return e, nil
+}
+
+// OrderError is an order rejected locally, before it was sent, because it
+// breaks the instrument rules or is inconsistent in itself. It matches
+// ErrInvalidOrder, and ErrInvalidSymbol when the symbol is unknown.
+type OrderError struct {
+	Endpoint string
+	Exchange string
+	Symbol   string
+	// Field is the offending request field, e.g. "price" or
+	// "orders[2].baseQuantity".
+	Field  string
+	Value  string
+	Reason string
+}
+
+func (e *OrderError) Error() string {
+	var b strings.Builder
+	b.WriteString("sbee: ")
+	if e.Endpoint != "" {
+		b.WriteString(e.Endpoint)
+		b.WriteString(": ")
+	}
+	if e.Symbol != "" {
+		b.WriteString(e.Symbol)
+		b.WriteString(" ")
+	}
+	b.WriteString(e.Field)
+	if e.Value != "" {
+		fmt.Fprintf(&b, " %s", e.Value)
+	}
+	b.WriteString(": ")
+	b.WriteString(e.Reason)
+	return b.String()
+}
+
+// Is reports whether target is ErrInvalidOrder, or ErrInvalidSymbol for an
+// unknown symbol.
+func (e *OrderError) Is(target error) bool {
+	return target == ErrInvalidOrder || target == ErrInvalidSymbol && e.Field == "symbol"
+}
+
+// orderKind selects the checks applied to an order.
+type orderKind int
+
+const (
+	limitOrder orderKind = iota
+	marketOrder
+	stopOrder
+)
+
+// orderFields points into one order of a request body, so checkOrder can
+// round it in place. Pointers not used by an order kind are nil.
+type orderFields struct {
+	kind   orderKind
+	prefix string // "orders[i]." inside batches
+	symbol string
+	side   string
+
+	price, base, quote *Decimal
+	// stopPrice and orderPrice are only set for stop orders, whose size is
+	// in base.
+	stopPrice, orderPrice *Decimal
+}
+
+// applyRules checks, and with RulesRound rounds, every order of r's body
+// against the rules of its symbol. Bodies that are not orders pass through.
+func (s *SbeeRest) applyRules(ctx context.Context, r *request) error {
+	if s.rulesPolicy == RulesOff {
+		return nil
+	}
+
+	var orders []orderFields
+	switch b := r.body.(type) {
+	case PlaceLimitOrderRequest:
+		orders = append(orders, orderFields{kind: limitOrder, symbol: b.Symbol, side: b.Side, price: &b.Price, base: &b.BaseQuantity, quote: &b.QuoteQuantity})
+		defer func() { r.body = b }()
+	case PlaceMarketOrderRequest:
+		orders = append(orders, orderFields{kind: marketOrder, symbol: b.Symbol, side: b.Side, price: &b.Price, base: &b.BaseQuantity, quote: &b.QuoteQuantity})
+		defer func() { r.body = b }()
+	case StopOrderRequest:
+		orders = append(orders, orderFields{kind: stopOrder, symbol: b.Symbol, side: b.Side, price: &b.Price, base: &b.Quantity, stopPrice: &b.StopPrice, orderPrice: &b.OrderPrice})
+		defer func() { r.body = b }()
+	case PlaceBatchLimitOrdersRequest:
+		b.Orders = append([]BatchLimitOrder(nil), b.Orders...)
+		for i := range b.Orders {
+			o := &b.Orders[i]
+			orders = append(orders, orderFields{kind: limitOrder, prefix: batchPrefix(i), symbol: o.Symbol, side: o.Side, price: &o.Price, base: &o.BaseQuantity, quote: &o.QuoteQuantity})
+		}
+		defer func() { r.body = b }()
+	case PlaceBatchMarketOrdersRequest:
+		b.Orders = append([]BatchMarketOrder(nil), b.Orders...)
+		for i := range b.Orders {
+			o := &b.Orders[i]
+			orders = append(orders, orderFields{kind: marketOrder, prefix: batchPrefix(i), symbol: o.Symbol, side: o.Side, base: &o.BaseQuantity, quote: &o.QuoteQuantity})
+		}
+		defer func() { r.body = b }()
+	case []LimitOrderForPeople:
+		b = append([]LimitOrderForPeople(nil), b...)
+		for i := range b {
+			o := &b[i]
+			orders = append(orders, orderFields{kind: limitOrder, prefix: batchPrefix(i), symbol: o.Symbol, side: o.Side, price: &o.Price, base: &o.BaseQuantity, quote: &o.QuoteQuantity})
+		}
+		defer func() { r.body = b }()
+	case []MarketOrderForPeople:
+		b = append([]MarketOrderForPeople(nil), b...)
+		for i := range b {
+			o := &b[i]
+			orders = append(orders, orderFields{kind: marketOrder, prefix: batchPrefix(i), symbol: o.Symbol, side: o.Side, base: &o.BaseQuantity, quote: &o.QuoteQuantity})
+		}
+		defer func() { r.body = b }()
+	default:
+		return nil
+	}
+
+	for _, o := range orders {
+		rules, err := s.Rules(ctx, r.exchange, r.trade, o.symbol)
+		if err != nil {
+			if oe, ok := err.(*OrderError); ok {
+				oe.Endpoint, oe.Field = r.endpoint, o.prefix+oe.Field
+			}
+			return err
+		}
+		if err := checkOrder(rules, o, s.rulesPolicy == RulesRound); err != nil {
+			err.Endpoint, err.Exchange = r.endpoint, r.exchange
+			return err
+		}
+	}
+	return nil
+}
+
+func batchPrefix(i int) string {
+	return fmt.Sprintf("orders[%d].", i)
+}
+
+// checkOrder validates o against rules, first rounding it in place when round
+// is set.
+func checkOrder(rules Rules, o orderFields, round bool) *OrderError {
+	fail := func(field string, v *Decimal, format string, args ...interface{}) *OrderError {
+		e := &OrderError{Symbol: o.symbol, Field: o.prefix + field, Reason: fmt.Sprintf(format, args...)}
+		if v != nil {
+			e.Value = v.String()
+		}
+		return e
+	}
+
+	if !rules.IsTradable {
+		return fail("symbol", nil, "not tradable")
+	}
+	side := strings.ToUpper(o.side)
+	if side != "BUY" && side != "SELL" {
+		return fail("side", nil, "%q is not BUY or SELL", o.side)
+	}
+
+	// Prices: the limit price of limit orders, every non-zero price of stop
+	// orders, and an optional reference price on market orders.
+	prices := []struct {
+		name string
+		v    *Decimal
+		mode RoundingMode
+	}{
+		{"price", o.price, RoundHalfEven},
+		{"stopPrice", o.stopPrice, RoundHalfEven},
+		{"orderPrice", o.orderPrice, RoundHalfEven},
+	}
+	if o.kind == limitOrder {
+		prices[0].mode = RoundDown
+		if side == "SELL" {
+			prices[0].mode = RoundUp
+		}
+	}
+	for _, p := range prices {
+		if p.v == nil {
+			continue
+		}
+		if p.v.Sign() < 0 {
+			return fail(p.name, p.v, "must not be negative")
+		}
+		if round {
+			*p.v = rules.RoundPrice(*p.v, p.mode)
+		}
+		if p.v.Round(rules.PriceScale, RoundDown).Cmp(*p.v) != 0 {
+			return fail(p.name, p.v, "has more than %d decimals", rules.PriceScale)
+		}
+	}
+	switch o.kind {
+	case limitOrder:
+		if o.price.Sign() <= 0 {
+			return fail("price", o.price, "limit orders need a positive price")
+		}
+	case stopOrder:
+		if o.stopPrice.Sign() <= 0 {
+			return fail("stopPrice", o.stopPrice, "stop orders need a positive stop price")
+		}
+	}
+
+	// Size: exactly one of base and quote quantity, except that stop orders
+	// are always sized in base and market sells cannot be sized in quote.
+	baseName := "baseQuantity"
+	if o.kind == stopOrder {
+		baseName = "quantity"
+	}
+	if o.base.Sign() < 0 {
+		return fail(baseName, o.base, "must not be negative")
+	}
+	if o.quote != nil && o.quote.Sign() < 0 {
+		return fail("quoteQuantity", o.quote, "must not be negative")
+	}
+	hasBase := o.base.Sign() > 0
+	hasQuote := o.quote != nil && o.quote.Sign() > 0
+	switch {
+	case hasBase && hasQuote:
+		return fail("quoteQuantity", o.quote, "set either baseQuantity or quoteQuantity, not both")
+	case !hasBase && !hasQuote && o.quote == nil:
+		return fail(baseName, o.base, "must be positive")
+	case !hasBase && !hasQuote:
+		return fail("baseQuantity", o.base, "one of baseQuantity or quoteQuantity must be positive")
+	case hasQuote && o.kind == marketOrder && side == "SELL":
+		return fail("quoteQuantity", o.quote, "market sells must be sized in baseQuantity")
+	}
+
+	if hasBase {
+		if round {
+			*o.base = rules.RoundQuantity(*o.base)
+		}
+		if o.base.Round(rules.QuantityScale, RoundDown).Cmp(*o.base) != 0 {
+			return fail(baseName, o.base, "has more than %d decimals", rules.QuantityScale)
+		}
+		if o.base.Sign() <= 0 || o.base.LessThan(rules.MinQuantity) {
+			return fail(baseName, o.base, "below the minimum quantity %s", rules.MinQuantity)
+		}
+	}
+
+	if rules.MinNotional.Sign() > 0 {
+		var notional Decimal
+		switch {
+		case hasQuote:
+			notional = *o.quote
+		case o.price != nil && o.price.Sign() > 0:
+			notional = o.price.Mul(*o.base)
+		case o.stopPrice != nil:
+			notional = o.stopPrice.Mul(*o.base)
+		default:
+			// A market order sized in base has no price to check against.
+			return nil
+		}
+		if notional.LessThan(rules.MinNotional) {
+			field, v := baseName, o.base
+			if hasQuote {
+				field, v = "quoteQuantity", o.quote
+			}
+			return fail(field, v, "order value %s is below the minimum notional %s", notional, rules.MinNotional)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package sbee
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const currenciesReply = `{"isSuccess":true,"data":[
+	{"symbol":"BTC-USDT","isTradable":true,"priceScale":2,"quantityScale":5,"minQuantity":"0.00001","minNotional":"5"},
+	{"symbol":"LUNA-USDT","isTradable":false,"priceScale":4,"quantityScale":2},
+	{"symbol":"BAD-USDT","isTradable":true,"priceScale":-1,"quantityScale":2}
+]}`
+
+// newRulesServer answers Currencies with currenciesReply and every other call
+// with an empty success, recording the last order body sent.
+func newRulesServer(t *testing.T, policy RulesPolicy) (*SbeeRest, *[]byte, *int32) {
+	t.Helper()
+	var body []byte
+	var currencies int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/Currencies") {
+			atomic.AddInt32(&currencies, 1)
+			io.WriteString(w, currenciesReply)
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+		io.WriteString(w, `{"isSuccess":true}`)
+	}))
+	t.Cleanup(srv.Close)
+	return NewClient("token", WithBaseURL(srv.URL), WithOrderRules(policy)), &body, &currencies
+}
+
+func TestOrderRulesValidate(t *testing.T) {
+	ctx := context.Background()
+	s, body, currencies := newRulesServer(t, RulesValidate)
+
+	limit := func(symbol, price, quote, base, side string) error {
+		return errOf(s.PlaceLimitOrder(ctx, "Binance", "Spot", symbol, "ID1", d(price), d(quote), d(base), side, "key", "secret", "pass"))
+	}
+	tests := []struct {
+		name  string
+		err   error
+		field string
+	}{
+		{"valid", limit("BTC-USDT", "16000.5", "0", "0.005", "buy"), ""},
+		{"unknown symbol", limit("DOGE-USDT", "1", "0", "10", "BUY"), "symbol"},
+		{"not tradable", limit("LUNA-USDT", "1", "0", "10", "BUY"), "symbol"},
+		{"bad side", limit("BTC-USDT", "16000", "0", "0.005", "HOLD"), "side"},
+		{"price scale", limit("BTC-USDT", "16000.123", "0", "0.005", "BUY"), "price"},
+		{"no price", limit("BTC-USDT", "0", "0", "0.005", "BUY"), "price"},
+		{"quantity scale", limit("BTC-USDT", "16000", "0", "0.0050001", "BUY"), "baseQuantity"},
+		{"no quantity", limit("BTC-USDT", "16000", "0", "0", "BUY"), "baseQuantity"},
+		{"both quantities", limit("BTC-USDT", "16000", "100", "0.005", "BUY"), "quoteQuantity"},
+		{"min notional", limit("BTC-USDT", "16000", "0", "0.0003", "BUY"), "baseQuantity"},
+		{"market sell in quote", errOf(s.PlaceMarketOrder(ctx, "Binance", "Spot", "BTC-USDT", "ID2", d("0"), d("15"), d("0"), 0, 0, "SELL", "key", "secret", "pass")), "quoteQuantity"},
+		{"stop without stop price", errOf(s.PlaceLimitStopLossOrder(ctx, "Binance", "Spot", "BTC-USDT", d("0.005"), "ID3", d("0"), d("0"), d("27500"), d("0"), "BUY", "key", "secret", "pass")), "stopPrice"},
+		{"batch", errOf(s.PlaceBatchLimitOrders(ctx, "Binance", "Spot", []BatchLimitOrder{
+			{Symbol: "BTC-USDT", Price: d("20000"), BaseQuantity: d("0.005"), Side: "BUY"},
+			{Symbol: "BTC-USDT", Price: d("20000"), BaseQuantity: d("0.000001"), Side: "BUY"},
+		}, "key", "secret", "pass")), "orders[1].baseQuantity"},
+	}
+	for _, tt := range tests {
+		if tt.field == "" {
+			if tt.err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, tt.err)
+			}
+			continue
+		}
+		var oe *OrderError
+		if !errors.As(tt.err, &oe) {
+			t.Errorf("%s: err = %v, want *OrderError", tt.name, tt.err)
+			continue
+		}
+		if oe.Field != tt.field || !errors.Is(tt.err, ErrInvalidOrder) {
+			t.Errorf("%s: field = %q, want %q (%v)", tt.name, oe.Field, tt.field, oe)
+		}
+	}
+	if !errors.Is(tests[1].err, ErrInvalidSymbol) {
+		t.Errorf("unknown symbol does not match ErrInvalidSymbol: %v", tests[1].err)
+	}
+	if err := limit("BAD-USDT", "1", "0", "10", "BUY"); err == nil || !strings.Contains(err.Error(), "priceScale -1") {
+		t.Errorf("negative price scale: err = %v", err)
+	}
+	if _, err := RulesFromCurrency(Currency{Symbol: "X", QuantityScale: 1 << 20}); err == nil {
+		t.Error("absurd quantity scale accepted")
+	}
+	if n := atomic.LoadInt32(currencies); n != 1 {
+		t.Errorf("Currencies fetched %d times, want 1", n)
+	}
+	// Only the valid order reached the server.
+	if !strings.Contains(string(*body), `"ClientOrderId":"ID1"`) {
+		t.Errorf("last body sent = %s", *body)
+	}
+}
+
+func TestOrderRulesRound(t *testing.T) {
+	ctx := context.Background()
+	s, body, _ := newRulesServer(t, RulesRound)
+
+	if _, err := s.PlaceLimitOrder(ctx, "Binance", "Spot", "BTC-USDT", "ID1", d("16000.129"), d("0"), d("0.0050009"), "SELL", "key", "secret", "pass"); err != nil {
+		t.Fatal(err)
+	}
+	var got PlaceLimitOrderRequest
+	if err := json.Unmarshal(*body, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Price.String() != "16000.13" || got.BaseQuantity.String() != "0.00500" {
+		t.Errorf("sent price %s quantity %s, want 16000.13 and 0.00500", got.Price, got.BaseQuantity)
+	}
+
+	// Rounding down below the minimum is still rejected.
+	err := errOf(s.PlaceLimitOrder(ctx, "Binance", "Spot", "BTC-USDT", "ID2", d("16000"), d("0"), d("0.000004"), "BUY", "key", "secret", "pass"))
+	if !errors.Is(err, ErrInvalidOrder) {
+		t.Errorf("err = %v, want ErrInvalidOrder", err)
+	}
+}
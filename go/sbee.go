@@ -0,0 +1,844 @@
+/*
+WHAT IS SBEE?
+SBEE EXCHANGE ENGINE (SBEE) IS AN INTERMEDIARY SOFTWARE THAT ACTS AS A LINK CONNECTING CRYPTOCURRENCY EXCHANGES AND APPLICATIONS.
+BY INTEGRATING WITH SBEE API SERVICES, APPLICATIONS CAN ESTABLISH CONNECTIONS TO MULTIPLE CRYPTOCURRENCY EXCHANGES SEAMLESSLY.
+THIS INTEGRATION PROVIDES USERS WITH THE FLEXIBILITY TO TRADE ON VARIOUS EXCHANGES, ACCESS LIVE DATA, AND PERFORM DIVERSE TRANSACTIONS ALL FROM A CENTRALIZED PLATFORM.
+TO UTILIZE THIS LIBRARY, YOU NEED TO GENERATE A FREE BEARER TOKEN FROM WWW.SBEE.IO.
+BY ENTERING THE NAME OF THE EXCHANGE YOU WANT TO OPERATE ON AND PROVIDING YOUR TRANSACTION DETAILS,
+YOU CAN EASILY AND QUICKLY EXECUTE YOUR TRANSACTIONS. FOR DETAILED INFORMATION ABOUT THE SERVICE, YOU CAN ACCESS DOC.SBEE.IO.
+*/
+package sbee
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+/*
+https://doc.sbee.io/api/get-system-time
+Get System Time
+Exchange server time information
+@params Exchange='Binance'
+*/
+func (s *SbeeRest) SystemTime(ctx context.Context, Exchange string) (*Response[Timestamp], error) {
+	return call[Timestamp](ctx, s, &request{
+		method:   http.MethodGet,
+		endpoint: "SystemTime",
+		exchange: s.exchange(Exchange),
+		path:     fmt.Sprintf("/Crypto/%s/SystemTime", s.exchange(Exchange)),
+	})
+}
+
+/*
+https://doc.sbee.io/api/public-endpoints/spot/recent-trades
+Recent Trades
+The purpose of using the "Recent Trades" method in cryptocurrency futures trading is to view the recent trades that have taken place on a specific futures contract.
+Past fulfilled buy and sell orders
+@params Exchange='Binance'
+@params Trade ='Spot' //Futures
+@params symbol='BTC-USDT'
+@params limit='20'
+*/
+func (s *SbeeRest) RecentTrades(ctx context.Context, Exchange, Trade, symbol, depth string) (*Response[RecentTrades], error) {
+	return call[RecentTrades](ctx, s, &request{
+		method:   http.MethodGet,
+		endpoint: "RecentTrades",
+		exchange: s.exchange(Exchange),
+		trade:    s.trade(Trade),
+		query:    url.Values{"symbol": {symbol}, "depth": {depth}},
+	})
+}
+
+/*
+	https://doc.sbee.io/api/public-endpoints/spot/currencies
+	Currencies
+	Gets all tradable pairs and their quantity or price scales.
+	@params Exchange='Binance'
+	@params Trade ='Spot' //Futures
+*/
+
+func (s *SbeeRest) Currencies(ctx context.Context, Exchange, Trade string) (*Response[[]Currency], error) {
+	return call[[]Currency](ctx, s, &request{
+		method:   http.MethodGet,
+		endpoint: "Currencies",
+		exchange: s.exchange(Exchange),
+		trade:    s.trade(Trade),
+	})
+}
+
+/*
+https://doc.sbee.io/api/spot/trading-balances
+Reads wallet information.Gets all cash balances.
+Withdraws all coins when symbol information is left blank
+@params Exchange='Binance'
+@params Trade ='Spot' //Futures
+@params symbol='USDT'
+@params apiKey='Key...'
+@params apiSecret='Secret...'
+@params apiPass='Pass..'
+
+Deprecated: Use SbeeRest.Account, whose TradingBalances resolves the keys
+when the request is sent.
+*/
+func (s *SbeeRest) TradingBalances(ctx context.Context, Exchange, Trade, symbol, apiKey, apiSecret, apiPass string) (*Response[[]Balance], error) {
+	return s.tradingBalances(ctx, Exchange, Trade, symbol, Credentials{APIKey: apiKey, APISecret: apiSecret, APIPass: apiPass})
+}
+
+// tradingBalances is TradingBalances with the keys in c. It and the other
+// lowercase order and account methods take Credentials whole, so that c may
+// instead name an Account whose keys are resolved when the request is sent.
+func (s *SbeeRest) tradingBalances(ctx context.Context, Exchange, Trade, symbol string, c Credentials) (*Response[[]Balance], error) {
+	return call[[]Balance](ctx, s, &request{
+		method:     http.MethodPost,
+		endpoint:   "TradingBalances",
+		idempotent: true,
+		exchange:   s.exchange(Exchange),
+		trade:      s.trade(Trade),
+		body: TradingBalancesRequest{
+			Symbol:      symbol,
+			Credentials: c,
+		},
+	})
+}
+
+/*
+	https://doc.sbee.io/api/spot/order-history
+	My buy and sell orders
+	@params Exchange='Binance'
+	@params Trade ='Spot' //Futures
+	@params symbol='BTC-USDT'
+	@params state='NEW,ALL,FILLED,CANCELED'
+	@params apiKey='Key...'
+	@params apiSecret='Secret...'
+	@params apiPass='Pass..'
+*/
+
+// Deprecated: Use SbeeRest.Account, whose OrderHistory resolves the keys
+// when the request is sent.
+func (s *SbeeRest) OrderHistory(ctx context.Context, Exchange, Trade, symbol, state, apiKey, apiSecret, apiPass string) (*Response[[]Order], error) {
+	return s.orderHistory(ctx, Exchange, Trade, symbol, state, Credentials{APIKey: apiKey, APISecret: apiSecret, APIPass: apiPass})
+}
+
+func (s *SbeeRest) orderHistory(ctx context.Context, Exchange, Trade, symbol, state string, c Credentials) (*Response[[]Order], error) {
+	return call[[]Order](ctx, s, &request{
+		method:     http.MethodPost,
+		endpoint:   "OrderHistory",
+		idempotent: true,
+		exchange:   s.exchange(Exchange),
+		trade:      s.trade(Trade),
+		body: OrderHistoryRequest{
+			Symbol:      symbol,
+			State:       state,
+			Credentials: c,
+		},
+	})
+}
+
+/*
+https://doc.sbee.io/api/public-endpoints/spot/kline
+KLine
+Kline/candlestick bars for a symbol. The Kline/Candlestick Stream push updates to the current klines/candlestick every second.
+Pulls historical candle information
+@params Exchange='Binance'
+@params Trade ='Spot' //Futures
+@params symbol='BTC-USDT'
+@params interval='1m, 5m, 15m, 30m, 1h, 4h, 1d, 1M'
+@params startTime='1689170400000'
+@params startTime='1689970459999'
+@params endTime='1603152000'
+@params limit='10'
+*/
+func (s *SbeeRest) KLine(ctx context.Context, Exchange, Trade, symbol, interval, startTime, endTime string, limit int) (*Response[[]Kline], error) {
+	return call[[]Kline](ctx, s, &request{
+		method:   http.MethodGet,
+		endpoint: "KLine",
+		exchange: s.exchange(Exchange),
+		trade:    s.trade(Trade),
+		query: url.Values{
+			"symbol":    {symbol},
+			"interval":  {interval},
+			"startTime": {startTime},
+			"endTime":   {endTime},
+			"limit":     {strconv.Itoa(limit)},
+		},
+	})
+}
+
+/*
+https://doc.sbee.io/api/spot/kline-formation
+Kline Formation
+It is a technical analysis tool used in cryptocurrency trading.
+@params Exchange='Binance'
+@params Trade ='Spot' //Futures
+@params symbol='BTC-USDT'
+@params interval='1m, 5m, 15m, 30m, 1h, 4h, 1d, 1M'
+@params startTime='1689170400000'
+@params startTime='1689970459999'
+@params endTime='1603152000'
+@params limit='100'
+@params formations=[]Formation{MaxFormation(30, SourceClose), DXFormation(14), MACDFormation(12, 26, 9, SourceClose)}
+*/
+func (s *SbeeRest) KlineFormation(ctx context.Context, Exchange, Trade, symbol, interval string, limit int, formations []Formation, startTime, endTime interface{}) (*Response[[]FormationSeries], error) {
+	for i, f := range formations {
+		if reason := f.invalid(); reason != "" {
+			return nil, &OrderError{Endpoint: "KlineFormation", Exchange: s.exchange(Exchange), Symbol: symbol, Field: fmt.Sprintf("formations[%d]", i), Value: f.Name, Reason: reason}
+		}
+	}
+	if startTime == nil || endTime == nil {
+		startTime = nil
+		endTime = nil
+	}
+
+	return call[[]FormationSeries](ctx, s, &request{
+		method:     http.MethodPost,
+		endpoint:   "KlineFormation",
+		idempotent: true,
+		exchange:   s.exchange(Exchange),
+		trade:      s.trade(Trade),
+		body: KlineFormationRequest{
+			Symbol:     symbol,
+			Interval:   interval,
+			Limit:      limit,
+			StartTime:  startTime,
+			EndTime:    endTime,
+			Formations: formations,
+		},
+	})
+}
+
+/*
+Order Book
+Gets an instant list of all open orders for a product.
+@params Exchange='Binance'
+@params Trade ='Spot' //Futures
+@params symbol='BTC-USDT'
+@params depth='20'
+*/
+func (s *SbeeRest) OrderBook(ctx context.Context, Exchange, Trade, symbol string, depth int) (*Response[OrderBook], error) {
+	return call[OrderBook](ctx, s, &request{
+		method:   http.MethodGet,
+		endpoint: "OrderBook",
+		exchange: s.exchange(Exchange),
+		trade:    s.trade(Trade),
+		query:    url.Values{"symbol": {symbol}, "depth": {strconv.Itoa(depth)}},
+	})
+}
+
+/*
+Tickers
+Gets snapshot information about the latest transaction price, best bid/ask and 24h transaction volume.
+@params 'Binance'
+@params Trade ='Spot' //Futures
+@params 'BTC-USDT'
+*/
+func (s *SbeeRest) Tickers(ctx context.Context, Exchange, Trade, symbol string) (*Response[[]Ticker], error) {
+	return call[[]Ticker](ctx, s, &request{
+		method:   http.MethodGet,
+		endpoint: "Tickers",
+		exchange: s.exchange(Exchange),
+		trade:    s.trade(Trade),
+		query:    url.Values{"symbol": {symbol}},
+	})
+}
+
+/*
+https://doc.sbee.io/api/spot/limit-order
+Enters a buy or sell order
+@params Exchange='Binance'
+@params Trade ='Spot' //Futures
+@params symbol='BTC-USDT'
+@params ClientOrderId='ID3231'
+@params price='16000'
+@params quoteQuantity='0'
+@params baseQuantity='0.005'
+@params side='BUY'
+@params apiKey='Key...'
+@params apiSecret='Secret...'
+@params apiPass='Pass..'
+
+Deprecated: Use SbeeRest.Account, whose PlaceLimitOrder resolves the keys
+when the request is sent.
+*/
+func (s *SbeeRest) PlaceLimitOrder(ctx context.Context, Exchange, Trade, symbol, ClientOrderId string, price, quoteQuantity, baseQuantity Decimal, side, apiKey, apiSecret, apiPass string) (*Response[Order], error) {
+	return s.placeLimitOrder(ctx, Exchange, Trade, symbol, ClientOrderId, price, quoteQuantity, baseQuantity, side, Credentials{APIKey: apiKey, APISecret: apiSecret, APIPass: apiPass})
+}
+
+func (s *SbeeRest) placeLimitOrder(ctx context.Context, Exchange, Trade, symbol, ClientOrderId string, price, quoteQuantity, baseQuantity Decimal, side string, c Credentials) (*Response[Order], error) {
+	return call[Order](ctx, s, &request{
+		method:   http.MethodPost,
+		endpoint: "PlaceLimitOrder",
+		exchange: s.exchange(Exchange),
+		trade:    s.trade(Trade),
+		body: PlaceLimitOrderRequest{
+			Credentials:   c,
+			Symbol:        symbol,
+			ClientOrderID: ClientOrderId,
+			Price:         price,
+			QuoteQuantity: quoteQuantity,
+			BaseQuantity:  baseQuantity,
+			Side:          side,
+		},
+	})
+}
+
+/*
+https://doc.sbee.io/api/spot/market-order
+Executes a buy or sell order at market price
+@params Exchange='Binance'
+@params Trade ='Spot' //Futures
+@params symbol='BTC-USDT';
+@params ClientOrderId='ID326511'
+@params price='26000'
+@params quoteQuantity='15'
+@params baseQuantity='0'
+@params side='BUY'
+@params apiKey='Key...'
+@params apiSecret='Secret...'
+@params apiPass='Pass..'
+
+Deprecated: Use SbeeRest.Account, whose PlaceMarketOrder resolves the keys
+when the request is sent.
+*/
+func (s *SbeeRest) PlaceMarketOrder(ctx context.Context, Exchange, Trade, symbol, ClientOrderId string, price, quoteQuantity, baseQuantity Decimal, leverage, contract int, side, apiKey, apiSecret, apiPass string) (*Response[Order], error) {
+	return s.placeMarketOrder(ctx, Exchange, Trade, symbol, ClientOrderId, price, quoteQuantity, baseQuantity, leverage, contract, side, Credentials{APIKey: apiKey, APISecret: apiSecret, APIPass: apiPass})
+}
+
+func (s *SbeeRest) placeMarketOrder(ctx context.Context, Exchange, Trade, symbol, ClientOrderId string, price, quoteQuantity, baseQuantity Decimal, leverage, contract int, side string, c Credentials) (*Response[Order], error) {
+	return call[Order](ctx, s, &request{
+		method:   http.MethodPost,
+		endpoint: "PlaceMarketOrder",
+		exchange: s.exchange(Exchange),
+		trade:    s.trade(Trade),
+		body: PlaceMarketOrderRequest{
+			Credentials:   c,
+			Symbol:        symbol,
+			ClientOrderID: ClientOrderId,
+			Price:         price,
+			QuoteQuantity: quoteQuantity,
+			BaseQuantity:  baseQuantity,
+			Leverage:      leverage,
+			Contract:      contract,
+			Side:          side,
+		},
+	})
+}
+
+/*
+https://doc.sbee.io/api/spot/limit-stop-loss-order
+Enters a buy or sell stop loss limit order
+@params Exchange='Binance'
+@params Trade ='Spot' //Futures
+@params symbol='BTC-USDT'
+@params quantity='0.0005'
+@params ClientOrderId='ID653'
+@params stopPrice='28000'
+@params orderPrice='0'
+@params price='27500'
+@params trailingDelta='0'
+@params side='BUY'
+@params apiKey='Key...'
+@params apiSecret='Secret...'
+@params apiPass='Pass..'
+
+Deprecated: Use SbeeRest.Account, whose PlaceLimitStopLossOrder resolves the keys
+when the request is sent.
+*/
+func (s *SbeeRest) PlaceLimitStopLossOrder(ctx context.Context, Exchange, Trade, symbol string, quantity Decimal, ClientOrderId string, stopPrice, orderPrice, price, trailingDelta Decimal, side, apiKey, apiSecret, apiPass string) (*Response[Order], error) {
+	return s.placeLimitStopLossOrder(ctx, Exchange, Trade, symbol, quantity, ClientOrderId, stopPrice, orderPrice, price, trailingDelta, side, Credentials{APIKey: apiKey, APISecret: apiSecret, APIPass: apiPass})
+}
+
+func (s *SbeeRest) placeLimitStopLossOrder(ctx context.Context, Exchange, Trade, symbol string, quantity Decimal, ClientOrderId string, stopPrice, orderPrice, price, trailingDelta Decimal, side string, c Credentials) (*Response[Order], error) {
+	return call[Order](ctx, s, &request{
+		method:   http.MethodPost,
+		endpoint: "PlaceLimitStopLossOrder",
+		exchange: s.exchange(Exchange),
+		trade:    s.trade(Trade),
+		body: StopOrderRequest{
+			Credentials:   c,
+			Symbol:        symbol,
+			Quantity:      quantity,
+			ClientOrderID: ClientOrderId,
+			StopPrice:     stopPrice,
+			OrderPrice:    orderPrice,
+			Price:         price,
+			TrailingDelta: trailingDelta,
+			Side:          side,
+		},
+	})
+}
+
+/*
+https://doc.sbee.io/api/spot/limit-take-profit-order
+Limit Take Profit Order A type of limit order that specifies the exact price at which to close out an open position for a profit.
+@params Exchange='Binance'
+@params Trade ='Spot' //Futures
+@params symbol='BTC-USDT'
+@params quantity='0.005'
+@params ClientOrderId='ID653323'
+@params stopPrice='25000'
+@params orderPrice='22000'
+@params price='20000'
+@params trailingDelta='0'
+@params apiKey='Key...'
+@params apiSecret='Secret...'
+@params apiPass='Pass..'
+
+Deprecated: Use SbeeRest.Account, whose PlaceLimitTakeProfitOrder resolves the keys
+when the request is sent.
+*/
+func (s *SbeeRest) PlaceLimitTakeProfitOrder(ctx context.Context, Exchange, Trade, symbol string, quantity Decimal, ClientOrderId string, stopPrice, orderPrice, price, trailingDelta Decimal, side, apiKey, apiSecret, apiPass string) (*Response[Order], error) {
+	return s.placeLimitTakeProfitOrder(ctx, Exchange, Trade, symbol, quantity, ClientOrderId, stopPrice, orderPrice, price, trailingDelta, side, Credentials{APIKey: apiKey, APISecret: apiSecret, APIPass: apiPass})
+}
+
+func (s *SbeeRest) placeLimitTakeProfitOrder(ctx context.Context, Exchange, Trade, symbol string, quantity Decimal, ClientOrderId string, stopPrice, orderPrice, price, trailingDelta Decimal, side string, c Credentials) (*Response[Order], error) {
+	return call[Order](ctx, s, &request{
+		method:   http.MethodPost,
+		endpoint: "PlaceLimitTakeProfitOrder",
+		exchange: s.exchange(Exchange),
+		trade:    s.trade(Trade),
+		body: StopOrderRequest{
+			Credentials:   c,
+			Symbol:        symbol,
+			Quantity:      quantity,
+			ClientOrderID: ClientOrderId,
+			StopPrice:     stopPrice,
+			OrderPrice:    orderPrice,
+			Price:         price,
+			TrailingDelta: trailingDelta,
+			Side:          side,
+		},
+	})
+}
+
+/*
+https://doc.sbee.io/api/private-endpoints/futures/set-leverage
+Set Leverage
+Allows the leverage value to be defined.
+@params Exchange='Binance'
+@params Trade ='Spot' //Futures
+@params symbol='BTC-USDT'
+@params leverage='5'
+
+Deprecated: Use SbeeRest.Account, whose SetLeverage resolves the keys
+when the request is sent.
+*/
+func (s *SbeeRest) SetLeverage(ctx context.Context, Exchange, Trade, symbol, leverage, apiKey, apiSecret, apiPass string) (*Response[Leverage], error) {
+	return s.setLeverage(ctx, Exchange, Trade, symbol, leverage, Credentials{APIKey: apiKey, APISecret: apiSecret, APIPass: apiPass})
+}
+
+func (s *SbeeRest) setLeverage(ctx context.Context, Exchange, Trade, symbol, leverage string, c Credentials) (*Response[Leverage], error) {
+	return call[Leverage](ctx, s, &request{
+		method:   http.MethodPost,
+		endpoint: "SetLeverage",
+		exchange: s.exchange(Exchange),
+		trade:    s.trade(Trade),
+		body: SetLeverageRequest{
+			Credentials: c,
+			Symbol:      symbol,
+			Leverage:    leverage,
+		},
+	})
+}
+
+/*
+https://doc.sbee.io/api/spot/cancel-order
+Cancels the entered buy or sell order
+@params Exchange='Binance'
+@params Trade ='Spot' //Futures
+@params symbol='BTC-USDT'
+@params orderId='43523123123'
+@params clientOrderId='ID3421'
+@params apiKey='Key...'
+@params apiSecret='Secret...'
+@params apiPass='Pass..'
+
+Deprecated: Use SbeeRest.Account, whose CancelOrder resolves the keys
+when the request is sent.
+*/
+func (s *SbeeRest) CancelOrder(ctx context.Context, Exchange, Trade, symbol, apiKey, apiSecret, apiPass, orderId, clientOrderId string) (*Response[Order], error) {
+	return s.cancelOrder(ctx, Exchange, Trade, symbol, orderId, clientOrderId, Credentials{APIKey: apiKey, APISecret: apiSecret, APIPass: apiPass})
+}
+
+func (s *SbeeRest) cancelOrder(ctx context.Context, Exchange, Trade, symbol, orderId, clientOrderId string, c Credentials) (*Response[Order], error) {
+	return call[Order](ctx, s, &request{
+		method:   http.MethodPost,
+		endpoint: "CancelOrder",
+		exchange: s.exchange(Exchange),
+		trade:    s.trade(Trade),
+		body: CancelOrderRequest{
+			Credentials:   c,
+			Symbol:        symbol,
+			OrderID:       orderId,
+			ClientOrderID: clientOrderId,
+		},
+	})
+}
+
+/*
+https://doc.sbee.io/api/spot/cancel-order-by-symbol
+Cancel Order By Symbol
+Cancel the buy or sell order entered with the symbol name.
+@param $Exchange='Binance'
+@param $Trade ='Spot' //Futures
+@param $symbol='BTC-USDT'
+@param $orderId='43523123123'
+@param $clientOrderId='ID3421'
+@param $apiKey='Key...'
+@param $apiSecret='Secret...'
+@param $apiPass='Pass..'
+*/
+
+/*
+https://doc.sbee.io/api/spot/batch-processes/cancel-batch-orders
+Cancels a buy or sell bulk order entered in the same wallet
+
+	orders := []sbee.BatchCancelOrder{
+		{Symbol: "BTC-USDT", ClientOrderID: "ID123", OrderID: "ID124"},
+		{Symbol: "BTC-USDT", ClientOrderID: "ID126", OrderID: "ID127"},
+	}
+
+@params Exchange='Binance'
+@params Trade ='Spot' //Futures
+@params apiKey='Key...'
+@params apiSecret='Secret...'
+@params apiPass='Pass..'
+
+Deprecated: Use SbeeRest.Account, whose CancelBatchOrders resolves the keys
+when the request is sent.
+*/
+func (s *SbeeRest) CancelBatchOrders(ctx context.Context, Exchange, Trade string, orders []BatchCancelOrder, apiKey, apiSecret, apiPass string) (*Response[[]OrderResult], error) {
+	return s.cancelBatchOrders(ctx, Exchange, Trade, orders, Credentials{APIKey: apiKey, APISecret: apiSecret, APIPass: apiPass})
+}
+
+func (s *SbeeRest) cancelBatchOrders(ctx context.Context, Exchange, Trade string, orders []BatchCancelOrder, c Credentials) (*Response[[]OrderResult], error) {
+	return call[[]OrderResult](ctx, s, &request{
+		method:   http.MethodPost,
+		endpoint: "CancelBatchOrders",
+		exchange: s.exchange(Exchange),
+		trade:    s.trade(Trade),
+		body: CancelBatchOrdersRequest{
+			Credentials: c,
+			Orders:      orders,
+		},
+	})
+}
+
+/*
+https://doc.sbee.io/api/spot/batch-processes/cancel-batch-orders-for-people
+Bulk buy or sell order entered from different wallets cancels
+
+	orders := []sbee.CancelOrderForPeople{
+		{Symbol: "BTC-USDT", ClientOrderID: "ID901", Credentials: sbee.Credentials{APIKey: "Key1...", APISecret: "Secret1...", APIPass: "Pass1.."}},
+		{Symbol: "BTC-USDT", ClientOrderID: "ID902", Credentials: sbee.Credentials{APIKey: "Key2...", APISecret: "Secret2...", APIPass: "Pass2.."}},
+	}
+
+@params Exchange='Binance'
+@params Trade ='Spot' //Futures
+*/
+func (s *SbeeRest) CancelBatchOrdersForPeople(ctx context.Context, Exchange, Trade string, orders []CancelOrderForPeople) (*Response[[]OrderResult], error) {
+	return call[[]OrderResult](ctx, s, &request{
+		method:   http.MethodPost,
+		endpoint: "CancelBatchOrdersForPeople",
+		exchange: s.exchange(Exchange),
+		trade:    s.trade(Trade),
+		body:     orders,
+	})
+}
+
+/*
+https://doc.sbee.io/api/spot/batch-processes/batch-market-orders
+Batch Market Orders
+Open more than once market transactions from a single account.
+
+	orders := []sbee.BatchMarketOrder{
+		{Symbol: "BTC-USDT", QuoteQuantity: sbee.DecimalFromInt(1), ClientOrderID: "ID123", Side: "buy"},
+		{Symbol: "BTC-USDT", QuoteQuantity: sbee.DecimalFromInt(1), ClientOrderID: "ID124", Side: "buy"},
+	}
+
+@params Exchange='Binance'
+@params Trade ='Spot' //Futures
+@params apiKey='Key...'
+@params apiSecret='Secret...'
+@params apiPass='Pass..'
+
+Deprecated: Use SbeeRest.Account, whose PlaceBatchMarketOrders resolves the keys
+when the request is sent.
+*/
+func (s *SbeeRest) PlaceBatchMarketOrders(ctx context.Context, Exchange, Trade string, orders []BatchMarketOrder, apiKey, apiSecret, apiPass string) (*Response[[]OrderResult], error) {
+	return s.placeBatchMarketOrders(ctx, Exchange, Trade, orders, Credentials{APIKey: apiKey, APISecret: apiSecret, APIPass: apiPass})
+}
+
+func (s *SbeeRest) placeBatchMarketOrders(ctx context.Context, Exchange, Trade string, orders []BatchMarketOrder, c Credentials) (*Response[[]OrderResult], error) {
+	return call[[]OrderResult](ctx, s, &request{
+		method:   http.MethodPost,
+		endpoint: "PlaceBatchMarketOrders",
+		exchange: s.exchange(Exchange),
+		trade:    s.trade(Trade),
+		body: PlaceBatchMarketOrdersRequest{
+			Credentials: c,
+			Orders:      orders,
+		},
+	})
+}
+
+/*
+https://doc.sbee.io/api/spot/trading-balances-for-people
+Trading Balances For People
+Gets all cash balances for more than one account.
+@params Exchange='Binance'
+@params Trade ='Spot' //Futures
+
+	accounts := []sbee.BalanceForPeople{
+		{Symbol: "BTC-USDT", Credentials: sbee.Credentials{APIKey: "Key1...", APISecret: "Secret1..."}},
+		{Symbol: "XRP-USDT", Credentials: sbee.Credentials{APIKey: "Key2...", APISecret: "Secret2..."}},
+	}
+*/
+func (s *SbeeRest) TradingBalancesForPeople(ctx context.Context, Exchange, Trade string, accounts []BalanceForPeople) (*Response[[]AccountBalances], error) {
+	return call[[]AccountBalances](ctx, s, &request{
+		method:     http.MethodPost,
+		endpoint:   "TradingBalancesForPeople",
+		idempotent: true,
+		exchange:   s.exchange(Exchange),
+		trade:      s.trade(Trade),
+		body:       accounts,
+	})
+}
+
+/*
+Cancels the entered buy and sell orders according to the symbol.
+@param $Exchange='Binance'
+@param $Trade ='Spot' //Futures
+@param $symbol='BTC-USDT'
+@param $apiKey='Key...'
+@param $apiSecret='Secret...'
+@param $apiPass='Pass..'
+
+Deprecated: Use SbeeRest.Account, whose CancelOrdersBySymbol resolves the keys
+when the request is sent.
+*/
+func (s *SbeeRest) CancelOrdersBySymbol(ctx context.Context, Exchange, Trade, symbol, apiKey, apiSecret, apiPass string) (*Response[[]Order], error) {
+	return s.cancelOrdersBySymbol(ctx, Exchange, Trade, symbol, Credentials{APIKey: apiKey, APISecret: apiSecret, APIPass: apiPass})
+}
+
+func (s *SbeeRest) cancelOrdersBySymbol(ctx context.Context, Exchange, Trade, symbol string, c Credentials) (*Response[[]Order], error) {
+	return call[[]Order](ctx, s, &request{
+		method:   http.MethodPost,
+		endpoint: "CancelOrdersBySymbol",
+		exchange: s.exchange(Exchange),
+		trade:    s.trade(Trade),
+		body: CancelOrdersBySymbolRequest{
+			Symbol:      symbol,
+			Credentials: c,
+		},
+	})
+}
+
+/*
+https://doc.sbee.io/api/spot/batch-processes/batch-limit-orders
+Enters bulk limit buy and sell orders from the same wallet
+
+	orders := []sbee.BatchLimitOrder{
+		{Symbol: "BTC-USDT", ClientOrderID: "ID5501", Price: sbee.DecimalFromInt(20000), BaseQuantity: sbee.MustParseDecimal("0.005"), Side: "BUY"},
+		{Symbol: "BTC-USDT", ClientOrderID: "ID5502", Price: sbee.DecimalFromInt(20000), BaseQuantity: sbee.MustParseDecimal("0.005"), Side: "BUY"},
+	}
+
+@params Exchange='Binance'
+@params Trade ='Spot' //Futures
+@params apiKey='Key...'
+@params apiSecret='Secret...'
+@params apiPass='Pass..'
+
+Deprecated: Use SbeeRest.Account, whose PlaceBatchLimitOrders resolves the keys
+when the request is sent.
+*/
+func (s *SbeeRest) PlaceBatchLimitOrders(ctx context.Context, Exchange, Trade string, orders []BatchLimitOrder, apiKey, apiSecret, apiPass string) (*Response[[]OrderResult], error) {
+	return s.placeBatchLimitOrders(ctx, Exchange, Trade, orders, Credentials{APIKey: apiKey, APISecret: apiSecret, APIPass: apiPass})
+}
+
+func (s *SbeeRest) placeBatchLimitOrders(ctx context.Context, Exchange, Trade string, orders []BatchLimitOrder, c Credentials) (*Response[[]OrderResult], error) {
+	return call[[]OrderResult](ctx, s, &request{
+		method:   http.MethodPost,
+		endpoint: "PlaceBatchLimitOrders",
+		exchange: s.exchange(Exchange),
+		trade:    s.trade(Trade),
+		body: PlaceBatchLimitOrdersRequest{
+			Credentials: c,
+			Orders:      orders,
+		},
+	})
+}
+
+/*
+	https://doc.sbee.io/api/spot/limit-order-for-people
+	Enters bulk limit buy and sell orders from different wallets
+	orders := []sbee.LimitOrderForPeople{
+		{Symbol: "BTC-USDT", Side: "buy", Price: sbee.DecimalFromInt(10000), BaseQuantity: sbee.MustParseDecimal("0.001"), ClientOrderID: "UD01", Credentials: sbee.Credentials{APIKey: "Key1...", APISecret: "Secret1..."}},
+		{Symbol: "BTC-USDT", Side: "buy", Price: sbee.DecimalFromInt(10000), BaseQuantity: sbee.MustParseDecimal("0.001"), ClientOrderID: "UD02", Credentials: sbee.Credentials{APIKey: "Key2...", APISecret: "Secret2..."}},
+	}
+	@param $Exchange='Binance'
+	@param $Trade ='Spot' //Futures
+*/
+// PlaceLimitOrderForPeople places a limit order for a specific exchange and trade
+func (s *SbeeRest) PlaceLimitOrderForPeople(ctx context.Context, Exchange, Trade string, orders []LimitOrderForPeople) (*Response[[]OrderResult], error) {
+	return call[[]OrderResult](ctx, s, &request{
+		method:   http.MethodPost,
+		endpoint: "PlaceLimitOrderForPeople",
+		exchange: s.exchange(Exchange),
+		trade:    s.trade(Trade),
+		body:     orders,
+	})
+}
+
+/*
+	https://doc.sbee.io/api/spot/market-order-for-people
+	Enters bulk market buy and sell orders from different wallets
+	orders := []sbee.MarketOrderForPeople{
+		{Symbol: "BTC-USDT", QuoteQuantity: sbee.DecimalFromInt(11), ClientOrderID: "UD01", Side: "BUY", Credentials: sbee.Credentials{APIKey: "Key1...", APISecret: "Secret1..."}},
+		{Symbol: "BTC-USDT", QuoteQuantity: sbee.DecimalFromInt(11), ClientOrderID: "UD02", Side: "BUY", Credentials: sbee.Credentials{APIKey: "Key2...", APISecret: "Secret2..."}},
+	}
+	@param $Exchange='Binance'
+	@param $Trade ='Spot' //Futures
+*/
+// PlaceMarketOrderForPeople places a market order for a specific exchange and trade
+func (s *SbeeRest) PlaceMarketOrderForPeople(ctx context.Context, Exchange, Trade string, orders []MarketOrderForPeople) (*Response[[]OrderResult], error) {
+	return call[[]OrderResult](ctx, s, &request{
+		method:   http.MethodPost,
+		endpoint: "PlaceMarketOrderForPeople",
+		exchange: s.exchange(Exchange),
+		trade:    s.trade(Trade),
+		body:     orders,
+	})
+}
+
+/*
+	https://doc.sbee.io/api/info-markets
+	It provides information about the owned stock exchange and the service endpoints used in the exchange.
+*/
+// Markets retrieves market information
+func (s *SbeeRest) Markets(ctx context.Context) (*Response[[]Market], error) {
+	return call[[]Market](ctx, s, &request{
+		method:   http.MethodGet,
+		endpoint: "Markets",
+		path:     "/Crypto/Info/Markets",
+	})
+}
+
+/*
+	https://doc.sbee.io/api/fintech
+	It adjusts the value of currencies relative to each other.
+*/
+// MoneyPairValues retrieves money pair values
+func (s *SbeeRest) MoneyPairValues(ctx context.Context) (*Response[[]MoneyPair], error) {
+	return call[[]MoneyPair](ctx, s, &request{
+		method:   http.MethodGet,
+		endpoint: "MoneyPairValues",
+		path:     "/Fintech/MoneyPairValues",
+	})
+}
+
+/*
+	https://doc.sbee.io/api/multi-market/order-book
+	Order Book
+	Provides the depth of buy and sell orders (quantity and price levels) for a specific asset across multiple exchanges.
+	@param $Trade ='Spot' //Futures
+	data := sbee.MultiMarketRequest{
+		Symbol:    "ADA-USDT",
+		Depth:     50,
+		Precision: 3,
+		Exchanges: []string{"Binance", "Kraken", "KuCoin", "Bybit", "OKX", "GateIO", "Mexc"},
+	}
+*/
+// MultiOrderBook retrieves multi-market order book
+func (s *SbeeRest) MultiOrderBook(ctx context.Context, Trade string, data MultiMarketRequest) (*Response[MultiOrderBook], error) {
+	return call[MultiOrderBook](ctx, s, &request{
+		method:     http.MethodPost,
+		endpoint:   "MultiOrderBook",
+		idempotent: true,
+		trade:      s.trade(Trade),
+		path:       fmt.Sprintf("/Crypto/MultiMarket/%s/OrderBook", s.trade(Trade)),
+		body:       data,
+	})
+}
+
+/*
+	https://doc.sbee.io/api/multi-market/recent-trades
+	Recent Trades
+	Shows recent trade transactions for a specific asset.
+	@param $Trade ='Spot' //Futures
+	data := sbee.MultiMarketRequest{
+		Symbol:    "BTC-USDT",
+		Depth:     50,
+		Exchanges: []string{"Binance", "Kraken", "KuCoin", "Bybit", "OKX", "GateIO", "Mexc"},
+	}
+*/
+// MultiRecentTrades retrieves multi-market recent trades
+func (s *SbeeRest) MultiRecentTrades(ctx context.Context, Trade string, data MultiMarketRequest) (*Response[MultiRecentTrades], error) {
+	return call[MultiRecentTrades](ctx, s, &request{
+		method:     http.MethodPost,
+		endpoint:   "MultiRecentTrades",
+		idempotent: true,
+		trade:      s.trade(Trade),
+		path:       fmt.Sprintf("/Crypto/MultiMarket/%s/RecentTrades", s.trade(Trade)),
+		body:       data,
+	})
+}
+
+/*
+	https://doc.sbee.io/api/multi-market/stepped-order-book
+	Stepped Order Book
+	Provides a stepped order book, displaying specific increments between price levels.
+	@param $Trade ='Spot' //Futures
+	data := sbee.MultiMarketRequest{
+		Symbol:    "BTC-USDT",
+		Depth:     30,
+		Exchanges: []string{"Binance", "CryptoCom", "Kraken", "KuCoin", "Bybit", "Okx", "GateIO", "Mexc", "Biconomy", "BinanceUS", "Bitfinex", "Bitget", "BitMart", "CoinW", "Huobi", "WhiteBit"},
+	}
+*/
+// SteppedOrderBook retrieves stepped order book for multi-market
+func (s *SbeeRest) SteppedOrderBook(ctx context.Context, Trade string, data MultiMarketRequest) (*Response[SteppedOrderBook], error) {
+	return call[SteppedOrderBook](ctx, s, &request{
+		method:     http.MethodPost,
+		endpoint:   "SteppedOrderBook",
+		idempotent: true,
+		trade:      s.trade(Trade),
+		path:       fmt.Sprintf("/Crypto/MultiMarket/%s/SteppedOrderBook", s.trade(Trade)),
+		body:       data,
+	})
+}
+
+/*
+	https://doc.sbee.io/api/news
+	$language='en';
+	$pageSize=20;
+	$pageNumber=1;
+	$data= $Exchange->News($language,  $pageSize, $pageNumber);
+*/
+// News retrieves a list of news based on language, page size, and page number
+func (s *SbeeRest) News(ctx context.Context, language string, pageSize, pageNumber int) (*Response[[]NewsItem], error) {
+	return call[[]NewsItem](ctx, s, &request{
+		method:   http.MethodGet,
+		endpoint: "News",
+		path:     "/Crypto/News/List",
+		query: url.Values{
+			"language":   {language},
+			"pageSize":   {strconv.Itoa(pageSize)},
+			"pageNumber": {strconv.Itoa(pageNumber)},
+		},
+	})
+}
+
+/*
+https://doc.sbee.io/api/country
+Country
+The "country" endpoint provides information about a specific country.
+*/
+func (s *SbeeRest) Country(ctx context.Context) (*Response[[]Country], error) {
+	return call[[]Country](ctx, s, &request{
+		method:   http.MethodGet,
+		endpoint: "Country",
+		path:     "/Crypto/Country/List",
+	})
+}
@@ -0,0 +1,682 @@
+package sbee
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stream channels.
+const (
+	ChannelTickers = "tickers"
+	ChannelTrades  = "trades"
+	ChannelBook    = "book"
+	ChannelKlines  = "klines"
+)
+
+// Stream defaults, overridden with StreamOptions.
+const (
+	DefaultPollInterval  = 2 * time.Second
+	DefaultHeartbeat     = 15 * time.Second
+	DefaultFallbackAfter = 5 * time.Second
+	DefaultStreamBuffer  = 256
+)
+
+// pollBookDepth is the depth of the OrderBook snapshots polled for book
+// subscriptions.
+const pollBookDepth = 50
+
+// ErrStreamClosed is returned when subscribing on a closed Stream.
+var ErrStreamClosed = errors.New("sbee: stream closed")
+
+// WithStreamURL sets the WebSocket endpoint (ws:// or wss://) of Stream.
+// Without one, every stream subscription is served by polling the REST API.
+func WithStreamURL(u string) Option {
+	return func(s *SbeeRest) {
+		s.streamURL = u
+	}
+}
+
+// StreamOption configures a Stream.
+type StreamOption func(*Stream)
+
+// WithPollInterval sets how often subscriptions poll the REST API while the
+// WebSocket is unavailable. The default is DefaultPollInterval.
+func WithPollInterval(d time.Duration) StreamOption {
+	return func(st *Stream) {
+		st.pollInterval = d
+	}
+}
+
+// WithHeartbeat sets how often the stream pings the server. A connection
+// silent for two heartbeats is dropped and reconnected. The default is
+// DefaultHeartbeat.
+func WithHeartbeat(d time.Duration) StreamOption {
+	return func(st *Stream) {
+		st.heartbeat = d
+	}
+}
+
+// WithFallbackAfter sets how long the WebSocket may be down before
+// subscriptions fall back to polling. The default is DefaultFallbackAfter.
+func WithFallbackAfter(d time.Duration) StreamOption {
+	return func(st *Stream) {
+		st.fallbackAfter = d
+	}
+}
+
+// WithStreamBuffer sets the channel capacity of each subscription. The
+// default is DefaultStreamBuffer.
+func WithStreamBuffer(n int) StreamOption {
+	return func(st *Stream) {
+		st.buffer = n
+	}
+}
+
+// WithStreamErrorHandler calls fn with connection and polling errors, which
+// the stream otherwise recovers from silently. fn must not block.
+func WithStreamErrorHandler(fn func(error)) StreamOption {
+	return func(st *Stream) {
+		st.onError = fn
+	}
+}
+
+// Topic identifies a stream: one channel of one symbol on one exchange and
+// trade type. Interval is only set for klines.
+type Topic struct {
+	Channel  string `json:"channel"`
+	Exchange string `json:"exchange"`
+	Trade    string `json:"trade"`
+	Symbol   string `json:"symbol"`
+	Interval string `json:"interval,omitempty"`
+}
+
+// BookUpdate is a change to an order book. A level with a zero Size is
+// removed. Snapshot updates replace the whole book; polled updates are
+// always snapshots.
+type BookUpdate struct {
+	Snapshot bool `json:"snapshot"`
+	// FirstUpdateID and LastUpdateID are the exchange sequence numbers the
+	// update covers, for gap detection. They are zero when unknown.
+	FirstUpdateID int64        `json:"firstUpdateId"`
+	LastUpdateID  int64        `json:"lastUpdateId"`
+	Bids          []PriceLevel `json:"bids"`
+	Asks          []PriceLevel `json:"asks"`
+	Time          Timestamp    `json:"time"`
+}
+
+// Stream delivers real-time market data over a WebSocket, reconnecting and
+// resubscribing on failure and pinging the server to detect dead
+// connections. While the WebSocket is down for longer than the fallback
+// delay, or when the client has no stream URL, each subscription polls the
+// matching REST endpoint instead. Create one with SbeeRest.Stream and
+// release it with Close.
+//
+// Messages are JSON text frames. The client sends
+// {"op":"subscribe"|"unsubscribe", <Topic fields>} and expects data as
+// {<Topic fields>, "data": <payload or array of payloads>}, with payloads
+// shaped like the REST models: Ticker, Trade, BookUpdate and Kline.
+type Stream struct {
+	client        *SbeeRest
+	url           string
+	pollInterval  time.Duration
+	heartbeat     time.Duration
+	fallbackAfter time.Duration
+	buffer        int
+	onError       func(error)
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu      sync.Mutex
+	subs    map[Topic]map[subscriber]struct{}
+	conn    *wsConn
+	gen     int // incremented on every connection
+	polling bool
+	closed  bool
+}
+
+// subscriber is the type-independent side of a Subscription.
+type subscriber interface {
+	deliver(raw json.RawMessage) error
+	startPolling(ctx context.Context, interval time.Duration)
+	stopPolling()
+	close()
+}
+
+// Stream starts a Stream on the client's stream URL.
+func (s *SbeeRest) Stream(opts ...StreamOption) *Stream {
+	st := &Stream{
+		client:        s,
+		url:           s.streamURL,
+		pollInterval:  DefaultPollInterval,
+		heartbeat:     DefaultHeartbeat,
+		fallbackAfter: DefaultFallbackAfter,
+		buffer:        DefaultStreamBuffer,
+		subs:          make(map[Topic]map[subscriber]struct{}),
+		done:          make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(st)
+	}
+	st.ctx, st.cancel = context.WithCancel(context.Background())
+	if st.url == "" {
+		st.polling = true
+		close(st.done)
+	} else {
+		go st.run()
+	}
+	return st
+}
+
+// Close disconnects the stream, stops polling and closes the channel of
+// every subscription.
+func (st *Stream) Close() error {
+	st.mu.Lock()
+	if st.closed {
+		st.mu.Unlock()
+		return nil
+	}
+	st.closed = true
+	conn := st.conn
+	var subs []subscriber
+	for _, set := range st.subs {
+		for sub := range set {
+			subs = append(subs, sub)
+		}
+	}
+	st.subs = nil
+	st.mu.Unlock()
+
+	st.cancel()
+	if conn != nil {
+		conn.close()
+	}
+	<-st.done
+	for _, sub := range subs {
+		sub.stopPolling()
+		sub.close()
+	}
+	return nil
+}
+
+// Connected reports whether the WebSocket is currently up.
+func (st *Stream) Connected() bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.conn != nil
+}
+
+// Polling reports whether subscriptions are currently served by polling.
+func (st *Stream) Polling() bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.polling
+}
+
+// Tickers subscribes to the 24h ticker of symbol.
+func (st *Stream) Tickers(exchange, trade, symbol string) (*Subscription[Ticker], error) {
+	t := st.topic(ChannelTickers, exchange, trade, symbol, "")
+	return subscribe(st, t, func(ctx context.Context, emit func(Ticker)) error {
+		resp, err := st.client.Tickers(ctx, t.Exchange, t.Trade, t.Symbol)
+		if err != nil {
+			return err
+		}
+		for _, tk := range resp.Data {
+			emit(tk)
+		}
+		return nil
+	})
+}
+
+// Trades subscribes to the trades of symbol. Each time polling starts, the
+// trades already on the exchange are skipped, the WebSocket having delivered
+// them.
+func (st *Stream) Trades(exchange, trade, symbol string) (*Subscription[Trade], error) {
+	t := st.topic(ChannelTrades, exchange, trade, symbol, "")
+	// Trades carry no id: those sharing the newest timestamp are told
+	// apart by price, amount and side.
+	type tradeKey struct{ price, amount, side string }
+	return subscribePolls(st, t, func() pollFunc[Trade] {
+		var (
+			seeded bool
+			last   Timestamp
+			seen   = map[tradeKey]bool{}
+		)
+		return func(ctx context.Context, emit func(Trade)) error {
+			resp, err := st.client.RecentTrades(ctx, t.Exchange, t.Trade, t.Symbol, "100")
+			if err != nil {
+				return err
+			}
+			trades := resp.Data.Trades
+			sort.SliceStable(trades, func(i, j int) bool { return trades[i].Timestamp < trades[j].Timestamp })
+			for _, tr := range trades {
+				key := tradeKey{tr.Price.String(), tr.Amount.String(), tr.Side}
+				if tr.Timestamp < last || tr.Timestamp == last && seen[key] {
+					continue
+				}
+				if tr.Timestamp > last {
+					last, seen = tr.Timestamp, map[tradeKey]bool{}
+				}
+				seen[key] = true
+				if seeded {
+					emit(tr)
+				}
+			}
+			seeded = true
+			return nil
+		}
+	})
+}
+
+// Book subscribes to the order book diffs of symbol. When polling, every
+// update is a snapshot.
+func (st *Stream) Book(exchange, trade, symbol string) (*Subscription[BookUpdate], error) {
+	t := st.topic(ChannelBook, exchange, trade, symbol, "")
+	return subscribe(st, t, func(ctx context.Context, emit func(BookUpdate)) error {
+		resp, err := st.client.OrderBook(ctx, t.Exchange, t.Trade, t.Symbol, pollBookDepth)
+		if err != nil {
+			return err
+		}
+		emit(BookUpdate{
+			Snapshot:     true,
+			LastUpdateID: resp.Data.LastUpdateID,
+			Bids:         resp.Data.Bids,
+			Asks:         resp.Data.Asks,
+			Time:         Timestamp(time.Now().UnixMilli()),
+		})
+		return nil
+	})
+}
+
+// Klines subscribes to the candles of symbol at interval, e.g. "1m". The
+// current candle is delivered again each time it changes.
+func (st *Stream) Klines(exchange, trade, symbol, interval string) (*Subscription[Kline], error) {
+	t := st.topic(ChannelKlines, exchange, trade, symbol, interval)
+	var last Kline
+	return subscribe(st, t, func(ctx context.Context, emit func(Kline)) error {
+		resp, err := st.client.KLine(ctx, t.Exchange, t.Trade, t.Symbol, t.Interval, "", "", 2)
+		if err != nil {
+			return err
+		}
+		for _, k := range resp.Data {
+			if k.OpenTime < last.OpenTime || klineEqual(k, last) {
+				continue
+			}
+			last = k
+			emit(k)
+		}
+		return nil
+	})
+}
+
+func klineEqual(a, b Kline) bool {
+	return a.OpenTime == b.OpenTime && a.CloseTime == b.CloseTime &&
+		a.Open.Equal(b.Open) && a.High.Equal(b.High) && a.Low.Equal(b.Low) &&
+		a.Close.Equal(b.Close) && a.Volume.Equal(b.Volume)
+}
+
+func (st *Stream) topic(channel, exchange, trade, symbol, interval string) Topic {
+	return Topic{
+		Channel:  channel,
+		Exchange: st.client.exchange(exchange),
+		Trade:    st.client.trade(trade),
+		Symbol:   symbol,
+		Interval: interval,
+	}
+}
+
+// pollFunc fetches the values of a topic over REST and emits them.
+type pollFunc[T any] func(ctx context.Context, emit func(T)) error
+
+// subscribe registers a new subscription to t, served by poll while the
+// stream is polling.
+func subscribe[T any](st *Stream, t Topic, poll pollFunc[T]) (*Subscription[T], error) {
+	return subscribePolls(st, t, func() pollFunc[T] { return poll })
+}
+
+// subscribePolls is subscribe with a poll function made afresh by newPoll
+// each time polling starts, for polls that keep state between calls.
+func subscribePolls[T any](st *Stream, t Topic, newPoll func() pollFunc[T]) (*Subscription[T], error) {
+	c := make(chan T, st.buffer)
+	sub := &Subscription[T]{C: c, c: c, topic: t, st: st, newPoll: newPoll}
+
+	st.mu.Lock()
+	if st.closed {
+		st.mu.Unlock()
+		return nil, ErrStreamClosed
+	}
+	set := st.subs[t]
+	if set == nil {
+		set = make(map[subscriber]struct{})
+		st.subs[t] = set
+	}
+	set[sub] = struct{}{}
+	first := len(set) == 1
+	conn, polling := st.conn, st.polling
+	st.mu.Unlock()
+
+	if first && conn != nil {
+		st.send(conn, "subscribe", t)
+	}
+	if polling {
+		sub.startPolling(st.ctx, st.pollInterval)
+	}
+	return sub, nil
+}
+
+func (st *Stream) unsubscribe(t Topic, sub subscriber) {
+	st.mu.Lock()
+	set, ok := st.subs[t]
+	if !ok {
+		st.mu.Unlock()
+		return
+	}
+	delete(set, sub)
+	last := len(set) == 0
+	if last {
+		delete(st.subs, t)
+	}
+	conn := st.conn
+	st.mu.Unlock()
+
+	if last && conn != nil {
+		st.send(conn, "unsubscribe", t)
+	}
+}
+
+func (st *Stream) send(conn *wsConn, op string, t Topic) {
+	msg, _ := json.Marshal(struct {
+		Op string `json:"op"`
+		Topic
+	}{op, t})
+	if err := conn.writeText(msg); err != nil {
+		st.reportError(err)
+	}
+}
+
+func (st *Stream) reportError(err error) {
+	if st.onError != nil {
+		st.onError(err)
+	}
+}
+
+// run keeps the WebSocket connected until the stream is closed.
+func (st *Stream) run() {
+	defer close(st.done)
+
+	backoff := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 30 * time.Second, Multiplier: 2, Jitter: 0.2}
+	failures := 0
+	st.armFallback(0)
+	for st.ctx.Err() == nil {
+		header := http.Header{"Authorization": {"Bearer " + st.client.auth}}
+		if st.client.userAgent != "" {
+			header.Set("User-Agent", st.client.userAgent)
+		}
+		conn, err := dialWebSocket(st.ctx, st.url, header)
+		if err != nil {
+			if st.ctx.Err() != nil {
+				return
+			}
+			st.reportError(err)
+			failures++
+			sleep(st.ctx, backoff.backoff(failures))
+			continue
+		}
+		failures = 0
+		conn.readTimeout = 2 * st.heartbeat
+
+		gen := st.attach(conn)
+		err = st.serve(conn)
+		st.detach(conn, gen)
+		if st.ctx.Err() != nil {
+			return
+		}
+		st.reportError(err)
+	}
+}
+
+// attach makes conn the stream's connection, resubscribes every topic and
+// stops polling.
+func (st *Stream) attach(conn *wsConn) int {
+	st.mu.Lock()
+	st.conn = conn
+	st.gen++
+	gen := st.gen
+	topics := make([]Topic, 0, len(st.subs))
+	for t := range st.subs {
+		topics = append(topics, t)
+	}
+	st.mu.Unlock()
+
+	for _, t := range topics {
+		st.send(conn, "subscribe", t)
+	}
+	st.setPolling(false)
+	return gen
+}
+
+func (st *Stream) detach(conn *wsConn, gen int) {
+	conn.close()
+	st.mu.Lock()
+	if st.conn == conn {
+		st.conn = nil
+	}
+	st.mu.Unlock()
+	st.armFallback(gen)
+}
+
+// armFallback starts polling if the connection of generation gen is still
+// the latest and no new one is up after the fallback delay.
+func (st *Stream) armFallback(gen int) {
+	time.AfterFunc(st.fallbackAfter, func() {
+		st.mu.Lock()
+		down := st.conn == nil && st.gen == gen && !st.closed
+		st.mu.Unlock()
+		if down {
+			st.setPolling(true)
+		}
+	})
+}
+
+func (st *Stream) setPolling(on bool) {
+	st.mu.Lock()
+	if st.polling == on || st.closed {
+		st.mu.Unlock()
+		return
+	}
+	st.polling = on
+	var subs []subscriber
+	for _, set := range st.subs {
+		for sub := range set {
+			subs = append(subs, sub)
+		}
+	}
+	st.mu.Unlock()
+
+	for _, sub := range subs {
+		if on {
+			sub.startPolling(st.ctx, st.pollInterval)
+		} else {
+			sub.stopPolling()
+		}
+	}
+}
+
+// serve reads conn until it fails, pinging it every heartbeat.
+func (st *Stream) serve(conn *wsConn) error {
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		t := time.NewTicker(st.heartbeat)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+				if conn.ping() != nil {
+					conn.conn.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		raw, err := conn.readMessage()
+		if err != nil {
+			return err
+		}
+		var msg struct {
+			Op      string `json:"op"`
+			Message string `json:"message"`
+			Topic
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			st.reportError(err)
+			continue
+		}
+		if msg.Op == "error" {
+			st.reportError(errors.New("sbee: stream: " + msg.Message))
+			continue
+		}
+		if len(msg.Data) == 0 {
+			// Acknowledgements and application-level pongs.
+			continue
+		}
+		st.mu.Lock()
+		subs := make([]subscriber, 0, len(st.subs[msg.Topic]))
+		for sub := range st.subs[msg.Topic] {
+			subs = append(subs, sub)
+		}
+		st.mu.Unlock()
+		for _, sub := range subs {
+			if err := sub.deliver(msg.Data); err != nil {
+				st.reportError(err)
+			}
+		}
+	}
+}
+
+// Subscription is a stream of values of type T. Values arrive on C, which is
+// closed by Unsubscribe or Stream.Close. Values that arrive while C is full
+// are dropped and counted; book consumers should resync on a gap.
+type Subscription[T any] struct {
+	C <-chan T
+
+	c     chan T
+	topic Topic
+	st    *Stream
+	// newPoll makes the poll function of each polling run.
+	newPoll func() pollFunc[T]
+	// pollMu serialises polls, so that a run stopping does not overlap the
+	// next.
+	pollMu sync.Mutex
+
+	mu         sync.Mutex
+	closed     bool
+	pollCancel context.CancelFunc
+	dropped    atomic.Int64
+}
+
+// Topic returns what s is subscribed to.
+func (s *Subscription[T]) Topic() Topic {
+	return s.topic
+}
+
+// Dropped returns the number of values dropped because C was full.
+func (s *Subscription[T]) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Unsubscribe stops s and closes C.
+func (s *Subscription[T]) Unsubscribe() {
+	s.st.unsubscribe(s.topic, s)
+	s.stopPolling()
+	s.close()
+}
+
+func (s *Subscription[T]) emit(v T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.c <- v:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+func (s *Subscription[T]) deliver(raw json.RawMessage) error {
+	if len(raw) > 0 && raw[0] == '[' {
+		var vs []T
+		if err := json.Unmarshal(raw, &vs); err != nil {
+			return err
+		}
+		for _, v := range vs {
+			s.emit(v)
+		}
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return err
+	}
+	s.emit(v)
+	return nil
+}
+
+func (s *Subscription[T]) startPolling(ctx context.Context, interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || s.pollCancel != nil {
+		return
+	}
+	ctx, s.pollCancel = context.WithCancel(ctx)
+	poll := s.newPoll()
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			s.pollMu.Lock()
+			err := poll(ctx, s.emit)
+			s.pollMu.Unlock()
+			if err != nil && ctx.Err() == nil {
+				s.st.reportError(err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+}
+
+func (s *Subscription[T]) stopPolling() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pollCancel != nil {
+		s.pollCancel()
+		s.pollCancel = nil
+	}
+}
+
+func (s *Subscription[T]) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.c)
+	}
+}
@@ -0,0 +1,251 @@
+package sbee
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newWebSocketServer serves WebSocket connections with handle and returns
+// its ws:// URL.
+func newWebSocketServer(t *testing.T, handle func(c *wsConn)) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "bad handshake", http.StatusBadRequest)
+			return
+		}
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		io.WriteString(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+			"Sec-WebSocket-Accept: "+acceptKey(r.Header.Get("Sec-WebSocket-Key"))+"\r\n\r\n")
+		rw.Flush()
+		handle(&wsConn{conn: conn, br: rw.Reader})
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func receive[T any](t *testing.T, c <-chan T) T {
+	t.Helper()
+	select {
+	case v, ok := <-c:
+		if !ok {
+			t.Fatal("subscription closed")
+		}
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a stream value")
+	}
+	var zero T
+	return zero
+}
+
+func TestStreamReconnectsAndResubscribes(t *testing.T) {
+	var mu sync.Mutex
+	var subscribes []Topic
+	connections := 0
+	u := newWebSocketServer(t, func(c *wsConn) {
+		mu.Lock()
+		connections++
+		first := connections == 1
+		mu.Unlock()
+
+		raw, err := c.readMessage()
+		if err != nil {
+			return
+		}
+		var msg struct {
+			Op string `json:"op"`
+			Topic
+		}
+		json.Unmarshal(raw, &msg)
+		mu.Lock()
+		subscribes = append(subscribes, msg.Topic)
+		mu.Unlock()
+		if msg.Op != "subscribe" {
+			t.Errorf("op = %q", msg.Op)
+		}
+
+		// A fragmented message, then a ping the client must answer.
+		head := `{"channel":"tickers","exchange":"Binance","trade":"Spot",`
+		c.conn.Write(append([]byte{opText, byte(len(head))}, head...))
+		data := `"symbol":"BTC-USDT","data":{"symbol":"BTC-USDT","last":"42014.5"}}`
+		if first {
+			data = strings.Replace(data, "42014.5", "42000", 1)
+		}
+		c.conn.Write(append([]byte{opContinuation | 0x80, byte(len(data))}, data...))
+		if first {
+			// Drop the connection to force a reconnect.
+			return
+		}
+		c.writeFrame(opPing, []byte("hi"))
+		c.readMessage()
+	})
+
+	s := NewClient("token", WithStreamURL(u), WithDefaultExchange("Binance"), WithDefaultTrade("Spot"))
+	st := s.Stream(WithHeartbeat(time.Second))
+	defer st.Close()
+	sub, err := st.Tickers("", "", "BTC-USDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := receive(t, sub.C); got.Last.String() != "42000" {
+		t.Errorf("first ticker last = %s", got.Last)
+	}
+	if got := receive(t, sub.C); got.Last.String() != "42014.5" {
+		t.Errorf("ticker after reconnect last = %s", got.Last)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := Topic{Channel: ChannelTickers, Exchange: "Binance", Trade: "Spot", Symbol: "BTC-USDT"}
+	if len(subscribes) != 2 || subscribes[0] != want || subscribes[1] != want {
+		t.Errorf("subscribes = %+v, want %+v twice", subscribes, want)
+	}
+	if st.Polling() {
+		t.Error("stream is polling while connected")
+	}
+}
+
+func TestStreamPollingFallback(t *testing.T) {
+	var mu sync.Mutex
+	polls := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		endpoint := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		mu.Lock()
+		polls[endpoint]++
+		n := polls[endpoint]
+		mu.Unlock()
+		switch endpoint {
+		case "RecentTrades":
+			trades := `{"price":"1","amount":"1","side":"BUY","timestamp":"1000"}`
+			if n > 1 {
+				trades += `,{"price":"2","amount":"1","side":"SELL","timestamp":"1000"},{"price":"3","amount":"1","side":"BUY","timestamp":"2000"}`
+			}
+			io.WriteString(w, `{"isSuccess":true,"data":{"recentTrades":[`+trades+`]}}`)
+		case "OrderBook":
+			io.WriteString(w, `{"isSuccess":true,"data":{"bids":[{"price":"99","size":"1"}],"asks":[{"price":"101","size":"2"}]}}`)
+		}
+	}))
+	defer srv.Close()
+
+	// No stream URL: subscriptions poll from the start.
+	s := NewClient("token", WithBaseURL(srv.URL))
+	st := s.Stream(WithPollInterval(10 * time.Millisecond))
+	defer st.Close()
+
+	trades, err := st.Trades("Binance", "Spot", "BTC-USDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := receive(t, trades.C); got.Price.String() != "2" {
+		t.Errorf("first polled trade price = %s, want 2 (trade 1 predates the subscription)", got.Price)
+	}
+	if got := receive(t, trades.C); got.Price.String() != "3" {
+		t.Errorf("second polled trade price = %s, want 3", got.Price)
+	}
+
+	book, err := st.Book("Binance", "Spot", "BTC-USDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := receive(t, book.C); !got.Snapshot || len(got.Bids) != 1 || got.Asks[0].Price.String() != "101" {
+		t.Errorf("polled book = %+v", got)
+	}
+	book.Unsubscribe()
+	for range book.C {
+		// Drain values sent before Unsubscribe closed C.
+	}
+
+	st.Close()
+	if _, err := st.Tickers("Binance", "Spot", "BTC-USDT"); err != ErrStreamClosed {
+		t.Errorf("subscribe after Close: err = %v", err)
+	}
+}
+
+func TestStreamTradesPollingResumes(t *testing.T) {
+	var mu sync.Mutex
+	polls := 0
+	trades := []string{`{"price":"1","amount":"1","side":"BUY","timestamp":"1000"}`}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		polls++
+		io.WriteString(w, `{"isSuccess":true,"data":{"recentTrades":[`+strings.Join(trades, ",")+`]}}`)
+	}))
+	defer srv.Close()
+	// trade adds a trade once the poll in flight has answered.
+	trade := func(price, timestamp string) {
+		t.Helper()
+		mu.Lock()
+		n := polls
+		mu.Unlock()
+		for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+			mu.Lock()
+			if polls > n {
+				trades = append(trades, `{"price":"`+price+`","amount":"1","side":"BUY","timestamp":"`+timestamp+`"}`)
+				mu.Unlock()
+				return
+			}
+			mu.Unlock()
+			if time.Now().After(deadline) {
+				t.Fatal("no poll")
+			}
+		}
+	}
+
+	s := NewClient("token", WithBaseURL(srv.URL))
+	st := s.Stream(WithPollInterval(5 * time.Millisecond))
+	defer st.Close()
+	sub, err := st.Trades("Binance", "Spot", "BTC-USDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	trade("2", "2000")
+	if got := receive(t, sub.C); got.Price.String() != "2" {
+		t.Errorf("first polled trade price = %s, want 2", got.Price)
+	}
+
+	// The WebSocket comes back and delivers trade 3, then drops again.
+	st.setPolling(false)
+	mu.Lock()
+	trades = append(trades, `{"price":"3","amount":"1","side":"BUY","timestamp":"3000"}`)
+	mu.Unlock()
+	st.setPolling(true)
+	trade("4", "4000")
+	if got := receive(t, sub.C); got.Price.String() != "4" {
+		t.Errorf("trade polled after the second fallback has price %s, want 4", got.Price)
+	}
+}
+
+func TestStreamFallsBackWhenUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/Tickers") {
+			io.WriteString(w, `{"isSuccess":true,"data":[{"symbol":"BTC-USDT","last":"1"}]}`)
+			return
+		}
+		http.Error(w, "no websocket here", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	s := NewClient("token", WithBaseURL(srv.URL), WithStreamURL("ws"+strings.TrimPrefix(srv.URL, "http")))
+	st := s.Stream(WithFallbackAfter(20*time.Millisecond), WithPollInterval(10*time.Millisecond))
+	defer st.Close()
+	sub, err := st.Tickers("Binance", "Spot", "BTC-USDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := receive(t, sub.C); got.Last.String() != "1" || !st.Polling() || st.Connected() {
+		t.Errorf("ticker %+v, polling %v, connected %v", got, st.Polling(), st.Connected())
+	}
+}
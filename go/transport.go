@@ -0,0 +1,220 @@
+package sbee
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// contentType is the media type the sbee API expects for POST bodies.
+const contentType = "application/json-patch+json"
+
+// request describes one API call. endpoint, exchange and trade identify the
+// call in errors. path is relative to the base URL and defaults to
+// /Crypto/{exchange}/{trade}/{endpoint}. idempotent marks POST calls that
+// only read data and so may be retried like GETs.
+type request struct {
+	method     string
+	endpoint   string
+	exchange   string
+	trade      string
+	path       string
+	query      url.Values
+	body       interface{}
+	idempotent bool
+}
+
+// makeRequest sends r and returns the response, whose body has already been
+// read into the returned bytes and closed. A non-nil body is encoded as JSON
+// and sent with a Content-Type and Content-Length; query is appended to the
+// URL when set. The request is bound to ctx, so cancelling ctx or reaching
+// its deadline aborts it, including while the response body is read, or
+// while it waits for the rate limiter. Non-2xx responses are returned as an
+// *APIError.
+func (s *SbeeRest) makeRequest(ctx context.Context, r *request, info *RequestInfo) (*http.Response, []byte, error) {
+	if r.method != http.MethodGet && r.method != http.MethodPost {
+		return nil, nil, errors.New("invalid HTTP method")
+	}
+
+	path := r.path
+	if path == "" {
+		path = fmt.Sprintf("/Crypto/%s/%s/%s", r.exchange, r.trade, r.endpoint)
+	}
+	u := s.baseURL + path
+	if len(r.query) > 0 {
+		u += "?" + r.query.Encode()
+	}
+
+	var payload []byte
+	if r.body != nil {
+		var err error
+		payload, err = json.Marshal(r.body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("encode request: %w", err)
+		}
+	}
+
+	// A nil io.Reader, not a nil *bytes.Reader, keeps GET requests bodiless.
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.method, u, reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req.Header.Set("accept", "text/plain")
+	req.Header.Set("Authorization", "Bearer "+s.auth)
+	if payload != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if s.userAgent != "" {
+		req.Header.Set("User-Agent", s.userAgent)
+	}
+	for k, vs := range info.Header {
+		req.Header[k] = vs
+	}
+
+	if err := s.limiter.wait(ctx, r); err != nil {
+		return nil, nil, r.error(KindTransport, nil, err)
+	}
+	start := time.Now()
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		info.Latency = time.Since(start)
+		return nil, nil, r.error(KindTransport, nil, err)
+	}
+	defer resp.Body.Close()
+	s.limiter.observe(r, resp)
+
+	raw, err := io.ReadAll(resp.Body)
+	info.Latency = time.Since(start)
+	info.StatusCode, info.RequestID = resp.StatusCode, requestID(resp.Header)
+	if err != nil {
+		return resp, nil, r.error(KindTransport, resp, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := r.error(KindAPI, resp, nil)
+		// The gateway usually still answers with its envelope.
+		var env Response[json.RawMessage]
+		if json.Unmarshal(raw, &env) == nil {
+			apiErr.Code, apiErr.Message = env.ErrorCode, env.Message
+		}
+		if apiErr.Message == "" {
+			apiErr.Message = string(bytes.TrimSpace(raw))
+		}
+		return resp, raw, apiErr
+	}
+	return resp, raw, nil
+}
+
+// error builds an *APIError for r. resp may be nil.
+func (r *request) error(kind ErrorKind, resp *http.Response, err error) *APIError {
+	e := &APIError{
+		Kind:     kind,
+		Exchange: r.exchange,
+		Endpoint: r.endpoint,
+		Err:      err,
+	}
+	if resp != nil {
+		e.StatusCode = resp.StatusCode
+		e.RequestID = requestID(resp.Header)
+	}
+	return e
+}
+
+// requestID returns the request ID the gateway echoed back, if any.
+func requestID(h http.Header) string {
+	for _, k := range []string{"X-Request-Id", "X-Correlation-Id", "Request-Id"} {
+		if v := h.Get(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// call sends r and decodes the sbee envelope with a payload of type T. An
+// envelope with isSuccess false is returned as an *APIError alongside the
+// decoded response. Order bodies are first checked against the instrument
+// rules selected with WithOrderRules, and failed attempts are retried as the
+// client's RetryPolicy and r allow.
+func call[T any](ctx context.Context, s *SbeeRest, r *request) (_ *Response[T], err error) {
+	if err := s.resolveAccounts(ctx, r); err != nil {
+		return nil, &APIError{Kind: KindCredentials, Exchange: r.exchange, Endpoint: r.endpoint, Err: err}
+	}
+	s.assignClientOrderIDs(r)
+	defer func() { r.tagClientOrderIDs(err) }()
+	if err := s.applyRules(ctx, r); err != nil {
+		return nil, err
+	}
+
+	maxAttempts := 1
+	order, isOrder := r.placement()
+	switch {
+	case isOrder && order.clientOrderID != "":
+		maxAttempts = s.retry.MaxAttempts
+	case !isOrder && (r.method == http.MethodGet || r.idempotent):
+		maxAttempts = s.retry.MaxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := callOnce[T](ctx, s, r, attempt)
+		if err == nil {
+			r.echoClientOrderIDs(&resp.Data)
+			return resp, nil
+		}
+		if attempt >= maxAttempts || !s.retry.retryable(ctx, err) {
+			return resp, err
+		}
+		if serr := sleep(ctx, s.retry.backoff(attempt)); serr != nil {
+			return resp, err
+		}
+		if isOrder {
+			// The failed attempt may still have reached the exchange. Only
+			// send the order again once the exchange says it has no order
+			// with this ClientOrderId; if that cannot be established, give
+			// up with the original error.
+			placed, rerr := s.reconcile(ctx, r, order)
+			if rerr != nil {
+				return resp, err
+			}
+			if placed != nil {
+				if data, ok := any(*placed).(T); ok {
+					return &Response[T]{IsSuccess: true, Data: data}, nil
+				}
+			}
+		}
+	}
+}
+
+// callOnce makes the given attempt of call, between the client's hooks. Its
+// errors never echo the token or the request's keys.
+func callOnce[T any](ctx context.Context, s *SbeeRest, r *request, attempt int) (_ *Response[T], err error) {
+	info := r.info(attempt)
+	ctx = s.beforeRequest(ctx, info)
+	defer func() { s.afterRequest(ctx, info, err) }()
+
+	httpResp, raw, err := s.makeRequest(ctx, r, info)
+	if err != nil {
+		return nil, s.redactError(r, err)
+	}
+
+	resp := &Response[T]{Raw: raw}
+	if err := json.Unmarshal(raw, resp); err != nil {
+		return nil, r.error(KindDecode, httpResp, err)
+	}
+	if !resp.IsSuccess {
+		apiErr := r.error(KindAPI, httpResp, nil)
+		apiErr.Code, apiErr.Message = resp.ErrorCode, resp.Message
+		return resp, s.redactError(r, apiErr)
+	}
+	return resp, nil
+}
@@ -0,0 +1,407 @@
+package sbee
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// captured is what the test server saw of a single request.
+type captured struct {
+	method        string
+	path          string
+	query         string
+	contentType   string
+	contentLength int64
+	auth          string
+	body          []byte
+}
+
+func newTestServer(t *testing.T, reply string) (*SbeeRest, *captured) {
+	t.Helper()
+	var got captured
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("read body: %v", err)
+		}
+		got = captured{
+			method:        r.Method,
+			path:          r.URL.Path,
+			query:         r.URL.RawQuery,
+			contentType:   r.Header.Get("Content-Type"),
+			contentLength: r.ContentLength,
+			auth:          r.Header.Get("Authorization"),
+			body:          body,
+		}
+		io.WriteString(w, reply)
+	}))
+	t.Cleanup(srv.Close)
+	return NewClient("token", WithBaseURL(srv.URL)), &got
+}
+
+var testKeys = Credentials{APIKey: "key", APISecret: "secret", APIPass: "pass"}
+
+func TestPostWrappersSendBody(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		call func(s *SbeeRest) error
+		path string
+		want interface{}
+	}{
+		{
+			name: "TradingBalances",
+			call: func(s *SbeeRest) error {
+				return errOf(s.TradingBalances(ctx, "Binance", "Spot", "USDT", "key", "secret", "pass"))
+			},
+			path: "/Crypto/Binance/Spot/TradingBalances",
+			want: map[string]interface{}{"symbol": "USDT", "apiKey": "key", "apiSecret": "secret", "apiPass": "pass"},
+		},
+		{
+			name: "OrderHistory",
+			call: func(s *SbeeRest) error {
+				return errOf(s.OrderHistory(ctx, "Binance", "Spot", "BTC-USDT", "ALL", "key", "secret", "pass"))
+			},
+			path: "/Crypto/Binance/Spot/OrderHistory",
+			want: map[string]interface{}{"symbol": "BTC-USDT", "state": "ALL", "apiKey": "key", "apiSecret": "secret", "apiPass": "pass"},
+		},
+		{
+			name: "KlineFormation",
+			call: func(s *SbeeRest) error {
+				return errOf(s.KlineFormation(ctx, "Binance", "Spot", "BTC-USDT", "1h", 100, []Formation{MaxFormation(30, SourceClose), DXFormation(14)}, nil, nil))
+			},
+			path: "/Crypto/Binance/Spot/KlineFormation",
+			want: map[string]interface{}{"symbol": "BTC-USDT", "interval": "1h", "limit": json.Number("100"), "startTime": nil, "endTime": nil, "formations": []interface{}{
+				map[string]interface{}{"Formation": "MAX", "TimePeriod": json.Number("30"), "Source": "close"},
+				map[string]interface{}{"Formation": "DX", "TimePeriod": json.Number("14")},
+			}},
+		},
+		{
+			name: "PlaceLimitOrder",
+			call: func(s *SbeeRest) error {
+				return errOf(s.PlaceLimitOrder(ctx, "Binance", "Spot", "BTC-USDT", "ID3231", d("16000"), d("0"), d("0.005"), "BUY", "key", "secret", "pass"))
+			},
+			path: "/Crypto/Binance/Spot/PlaceLimitOrder",
+			want: map[string]interface{}{
+				"symbol": "BTC-USDT", "ClientOrderId": "ID3231", "price": "16000", "quoteQuantity": "0", "baseQuantity": "0.005",
+				"side": "BUY", "apiKey": "key", "apiSecret": "secret", "apiPass": "pass",
+			},
+		},
+		{
+			name: "PlaceMarketOrder",
+			call: func(s *SbeeRest) error {
+				return errOf(s.PlaceMarketOrder(ctx, "Binance", "Futures", "BTC-USDT", "ID326511", d("26000"), d("15"), d("0"), 5, 1, "BUY", "key", "secret", "pass"))
+			},
+			path: "/Crypto/Binance/Futures/PlaceMarketOrder",
+			want: map[string]interface{}{
+				"symbol": "BTC-USDT", "ClientOrderId": "ID326511", "price": "26000", "quoteQuantity": "15", "baseQuantity": "0",
+				"leverage": json.Number("5"), "contract": json.Number("1"), "side": "BUY", "apiKey": "key", "apiSecret": "secret", "apiPass": "pass",
+			},
+		},
+		{
+			name: "PlaceLimitStopLossOrder",
+			call: func(s *SbeeRest) error {
+				return errOf(s.PlaceLimitStopLossOrder(ctx, "Binance", "Spot", "BTC-USDT", d("0.0005"), "ID653", d("28000"), d("0"), d("27500"), d("0"), "BUY", "key", "secret", "pass"))
+			},
+			path: "/Crypto/Binance/Spot/PlaceLimitStopLossOrder",
+			want: map[string]interface{}{
+				"symbol": "BTC-USDT", "quantity": "0.0005", "ClientOrderId": "ID653", "stopPrice": "28000", "orderPrice": "0",
+				"price": "27500", "trailingDelta": "0", "side": "BUY", "apiKey": "key", "apiSecret": "secret", "apiPass": "pass",
+			},
+		},
+		{
+			name: "PlaceLimitTakeProfitOrder",
+			call: func(s *SbeeRest) error {
+				return errOf(s.PlaceLimitTakeProfitOrder(ctx, "Binance", "Spot", "BTC-USDT", d("0.005"), "ID653323", d("25000"), d("22000"), d("20000"), d("0"), "SELL", "key", "secret", "pass"))
+			},
+			path: "/Crypto/Binance/Spot/PlaceLimitTakeProfitOrder",
+			want: map[string]interface{}{
+				"symbol": "BTC-USDT", "quantity": "0.005", "ClientOrderId": "ID653323", "stopPrice": "25000", "orderPrice": "22000",
+				"price": "20000", "trailingDelta": "0", "side": "SELL", "apiKey": "key", "apiSecret": "secret", "apiPass": "pass",
+			},
+		},
+		{
+			name: "SetLeverage",
+			call: func(s *SbeeRest) error {
+				return errOf(s.SetLeverage(ctx, "Binance", "Futures", "BTC-USDT", "5", "key", "secret", "pass"))
+			},
+			path: "/Crypto/Binance/Futures/SetLeverage",
+			want: map[string]interface{}{"symbol": "BTC-USDT", "leverage": "5", "apiKey": "key", "apiSecret": "secret", "apiPass": "pass"},
+		},
+		{
+			name: "CancelOrder",
+			call: func(s *SbeeRest) error {
+				return errOf(s.CancelOrder(ctx, "Binance", "Spot", "BTC-USDT", "key", "secret", "pass", "43523123123", "ID3421"))
+			},
+			path: "/Crypto/Binance/Spot/CancelOrder",
+			want: map[string]interface{}{
+				"symbol": "BTC-USDT", "orderId": "43523123123", "clientOrderId": "ID3421",
+				"apiKey": "key", "apiSecret": "secret", "apiPass": "pass",
+			},
+		},
+		{
+			name: "CancelOrdersBySymbol",
+			call: func(s *SbeeRest) error {
+				_, err := s.CancelOrdersBySymbol(ctx, "Binance", "Spot", "BTC-USDT", "key", "secret", "pass")
+				return err
+			},
+			path: "/Crypto/Binance/Spot/CancelOrdersBySymbol",
+			want: map[string]interface{}{"symbol": "BTC-USDT", "apiKey": "key", "apiSecret": "secret", "apiPass": "pass"},
+		},
+		{
+			name: "CancelBatchOrders",
+			call: func(s *SbeeRest) error {
+				_, err := s.CancelBatchOrders(ctx, "Binance", "Spot", []BatchCancelOrder{
+					{Symbol: "BTC-USDT", ClientOrderID: "ID123", OrderID: "ID124"},
+				}, "key", "secret", "pass")
+				return err
+			},
+			path: "/Crypto/Binance/Spot/CancelBatchOrders",
+			want: map[string]interface{}{
+				"apiKey": "key", "apiSecret": "secret", "apiPass": "pass",
+				"orders": []interface{}{
+					map[string]interface{}{"symbol": "BTC-USDT", "clientOrderId": "ID123", "orderId": "ID124"},
+				},
+			},
+		},
+		{
+			name: "CancelBatchOrdersForPeople",
+			call: func(s *SbeeRest) error {
+				_, err := s.CancelBatchOrdersForPeople(ctx, "Binance", "Spot", []CancelOrderForPeople{
+					{Symbol: "BTC-USDT", ClientOrderID: "ID901", Credentials: testKeys},
+				})
+				return err
+			},
+			path: "/Crypto/Binance/Spot/CancelBatchOrdersForPeople",
+			want: []interface{}{
+				map[string]interface{}{
+					"symbol": "BTC-USDT", "orderId": "", "clientOrderId": "ID901",
+					"apiKey": "key", "apiSecret": "secret", "apiPass": "pass",
+				},
+			},
+		},
+		{
+			name: "PlaceBatchMarketOrders",
+			call: func(s *SbeeRest) error {
+				_, err := s.PlaceBatchMarketOrders(ctx, "Binance", "Spot", []BatchMarketOrder{
+					{Symbol: "BTC-USDT", QuoteQuantity: d("1"), ClientOrderID: "ID123", Side: "buy"},
+				}, "key", "secret", "pass")
+				return err
+			},
+			path: "/Crypto/Binance/Spot/PlaceBatchMarketOrders",
+			want: map[string]interface{}{
+				"apiKey": "key", "apiSecret": "secret", "apiPass": "pass",
+				"orders": []interface{}{
+					map[string]interface{}{"symbol": "BTC-USDT", "quoteQuantity": json.Number("1"), "baseQuantity": json.Number("0"), "clientOrderId": "ID123", "side": "buy"},
+				},
+			},
+		},
+		{
+			name: "TradingBalancesForPeople",
+			call: func(s *SbeeRest) error {
+				_, err := s.TradingBalancesForPeople(ctx, "Binance", "Spot", []BalanceForPeople{
+					{Symbol: "BTC-USDT", Credentials: testKeys},
+				})
+				return err
+			},
+			path: "/Crypto/Binance/Spot/TradingBalancesForPeople",
+			want: []interface{}{
+				map[string]interface{}{"symbol": "BTC-USDT", "apiKey": "key", "apiSecret": "secret", "apiPass": "pass"},
+			},
+		},
+		{
+			name: "PlaceBatchLimitOrders",
+			call: func(s *SbeeRest) error {
+				_, err := s.PlaceBatchLimitOrders(ctx, "Binance", "Spot", []BatchLimitOrder{
+					{Symbol: "BTC-USDT", ClientOrderID: "ID5501", Price: d("20000"), BaseQuantity: d("0.005"), Side: "BUY"},
+				}, "key", "secret", "pass")
+				return err
+			},
+			path: "/Crypto/Binance/Spot/PlaceBatchLimitOrders",
+			want: map[string]interface{}{
+				"apiKey": "key", "apiSecret": "secret", "apiPass": "pass",
+				"orders": []interface{}{
+					map[string]interface{}{
+						"symbol": "BTC-USDT", "clientOrderId": "ID5501", "price": json.Number("20000"), "quoteQuantity": json.Number("0"),
+						"baseQuantity": json.Number("0.005"), "side": "BUY",
+					},
+				},
+			},
+		},
+		{
+			name: "PlaceLimitOrderForPeople",
+			call: func(s *SbeeRest) error {
+				_, err := s.PlaceLimitOrderForPeople(ctx, "Binance", "Spot", []LimitOrderForPeople{
+					{Credentials: testKeys, Side: "buy", Price: d("10000"), BaseQuantity: d("0.001"), ClientOrderID: "UD01", Symbol: "BTC-USDT"},
+				})
+				return err
+			},
+			path: "/Crypto/Binance/Spot/PlaceLimitOrderForPeople",
+			want: []interface{}{
+				map[string]interface{}{
+					"apiKey": "key", "apiSecret": "secret", "apiPass": "pass", "side": "buy", "price": json.Number("10000"),
+					"baseQuantity": json.Number("0.001"), "quoteQuantity": json.Number("0"), "cliOrId": "UD01", "symbol": "BTC-USDT",
+				},
+			},
+		},
+		{
+			name: "PlaceMarketOrderForPeople",
+			call: func(s *SbeeRest) error {
+				_, err := s.PlaceMarketOrderForPeople(ctx, "Binance", "Spot", []MarketOrderForPeople{
+					{Symbol: "BTC-USDT", QuoteQuantity: d("11"), ClientOrderID: "UD01", Side: "BUY", Credentials: testKeys},
+				})
+				return err
+			},
+			path: "/Crypto/Binance/Spot/PlaceMarketOrderForPeople",
+			want: []interface{}{
+				map[string]interface{}{
+					"symbol": "BTC-USDT", "quoteQuantity": json.Number("11"), "baseQuantity": json.Number("0"), "ClientOrderId": "UD01", "side": "BUY",
+					"apiKey": "key", "apiSecret": "secret", "apiPass": "pass",
+				},
+			},
+		},
+		{
+			name: "MultiOrderBook",
+			call: func(s *SbeeRest) error {
+				_, err := s.MultiOrderBook(ctx, "Spot", MultiMarketRequest{Symbol: "ADA-USDT", Depth: 50, Precision: 3, Exchanges: []string{"Binance", "OKX"}})
+				return err
+			},
+			path: "/Crypto/MultiMarket/Spot/OrderBook",
+			want: map[string]interface{}{"symbol": "ADA-USDT", "depth": json.Number("50"), "precision": json.Number("3"), "exchanges": []interface{}{"Binance", "OKX"}},
+		},
+		{
+			name: "MultiRecentTrades",
+			call: func(s *SbeeRest) error {
+				_, err := s.MultiRecentTrades(ctx, "Spot", MultiMarketRequest{Symbol: "BTC-USDT", Depth: 50, Exchanges: []string{"Binance"}})
+				return err
+			},
+			path: "/Crypto/MultiMarket/Spot/RecentTrades",
+			want: map[string]interface{}{"symbol": "BTC-USDT", "depth": json.Number("50"), "exchanges": []interface{}{"Binance"}},
+		},
+		{
+			name: "SteppedOrderBook",
+			call: func(s *SbeeRest) error {
+				_, err := s.SteppedOrderBook(ctx, "Spot", MultiMarketRequest{Symbol: "BTC-USDT", Depth: 30, Exchanges: []string{"Kraken"}})
+				return err
+			},
+			path: "/Crypto/MultiMarket/Spot/SteppedOrderBook",
+			want: map[string]interface{}{"symbol": "BTC-USDT", "depth": json.Number("30"), "exchanges": []interface{}{"Kraken"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, got := newTestServer(t, `{"isSuccess":true}`)
+			if err := tt.call(s); err != nil {
+				t.Fatalf("call: %v", err)
+			}
+			if got.method != http.MethodPost {
+				t.Errorf("method = %s, want POST", got.method)
+			}
+			if got.path != tt.path {
+				t.Errorf("path = %s, want %s", got.path, tt.path)
+			}
+			if got.contentType != contentType {
+				t.Errorf("Content-Type = %q, want %q", got.contentType, contentType)
+			}
+			if got.contentLength != int64(len(got.body)) || got.contentLength == 0 {
+				t.Errorf("Content-Length = %d, body is %d bytes", got.contentLength, len(got.body))
+			}
+			if got.auth != "Bearer token" {
+				t.Errorf("Authorization = %q", got.auth)
+			}
+			var body interface{}
+			dec := json.NewDecoder(bytes.NewReader(got.body))
+			dec.UseNumber()
+			if err := dec.Decode(&body); err != nil {
+				t.Fatalf("body is not JSON: %v: %s", err, got.body)
+			}
+			if !reflect.DeepEqual(body, tt.want) {
+				t.Errorf("body = %#v\nwant %#v", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetWrappersSendQuery(t *testing.T) {
+	ctx := context.Background()
+	s, got := newTestServer(t, `{"isSuccess":true}`)
+	if _, err := s.OrderBook(ctx, "Binance", "Spot", "BTC-USDT", 20); err != nil {
+		t.Fatalf("OrderBook: %v", err)
+	}
+	if got.method != http.MethodGet || got.path != "/Crypto/Binance/Spot/OrderBook" {
+		t.Errorf("request = %s %s", got.method, got.path)
+	}
+	if got.query != "depth=20&symbol=BTC-USDT" {
+		t.Errorf("query = %s", got.query)
+	}
+	if len(got.body) != 0 || got.contentType != "" {
+		t.Errorf("GET sent a body: %q (%s)", got.body, got.contentType)
+	}
+}
+
+func TestDefaultExchangeAndTrade(t *testing.T) {
+	ctx := context.Background()
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		io.WriteString(w, `{"isSuccess":true}`)
+	}))
+	defer srv.Close()
+
+	s := NewClient("token", WithBaseURL(srv.URL), WithDefaultExchange("OKX"), WithDefaultTrade("Futures"))
+	if _, err := s.Tickers(ctx, "", "", "BTC-USDT"); err != nil {
+		t.Fatalf("Tickers: %v", err)
+	}
+	if want := "/Crypto/OKX/Futures/Tickers"; path != want {
+		t.Errorf("path = %s, want %s", path, want)
+	}
+}
+
+func TestContextCancelsRequest(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	s := NewClient("token", WithBaseURL(srv.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.PlaceLimitOrder(ctx, "Binance", "Spot", "BTC-USDT", "ID1", d("16000"), d("0"), d("0.005"), "BUY", "key", "secret", "pass")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("PlaceLimitOrder succeeded after its deadline")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("PlaceLimitOrder ignored its context deadline")
+	}
+}
+
+// d parses a decimal test constant.
+func d(s string) Decimal {
+	return MustParseDecimal(s)
+}
+
+// errOf drops the response of a wrapper call so table entries can return
+// just its error.
+func errOf[T any](_ *Response[T], err error) error {
+	return err
+}